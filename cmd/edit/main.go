@@ -0,0 +1,91 @@
+// edit 基于 editor 包的行编辑器
+//
+// 用法:
+//
+//	edit <文件>
+//
+// 文件不存在时从空文档开始。从标准输入逐行读取命令，除 editor.LineEditor.Exec
+// 支持的编辑命令外，还支持：
+//
+//	w [文件]   保存（默认写回原文件）
+//	q          退出；有未保存的修改时需要连续输入两次
+//	h          显示帮助
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/howard/go.study/pkg/editor"
+)
+
+const help = `p [N[,M]]   打印        a N 文本   在第 N 行后追加
+i N 文本    在前面插入  c N 文本   替换整行
+d N[,M]     删除        s N 旧 新  行内替换
+u / r       撤销 / 重做  =          行数
+w [文件]    保存        q          退出`
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "用法: edit <文件>")
+		os.Exit(2)
+	}
+	if err := run(os.Args[1], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "edit:", err)
+		os.Exit(1)
+	}
+}
+
+// run 打开文件并执行从 stdin 读到的命令
+func run(path string, stdin io.Reader, stdout io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	saved := string(data)
+	e := editor.NewLineEditor(saved, stdout)
+	fmt.Fprintf(stdout, "%s: %d 行\n", path, e.Buffer().LineCount())
+
+	quitting := false
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		line := scanner.Text()
+		name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+
+		switch name {
+		case "q":
+			if e.String() != saved && !quitting {
+				fmt.Fprintln(stdout, "? 有未保存的修改，再次输入 q 放弃修改")
+				quitting = true
+				continue
+			}
+			return nil
+		case "w":
+			target := path
+			if arg != "" {
+				target = arg
+			}
+			if err := os.WriteFile(target, []byte(e.String()), 0o644); err != nil {
+				fmt.Fprintf(stdout, "? %v\n", err)
+				break
+			}
+			if target == path {
+				saved = e.String()
+			}
+			fmt.Fprintf(stdout, "已写入 %s（%d 行）\n", target, e.Buffer().LineCount())
+		case "h":
+			fmt.Fprintln(stdout, help)
+		default:
+			if err := e.Exec(line); err != nil {
+				fmt.Fprintf(stdout, "? %v\n", err)
+			}
+		}
+		quitting = false
+	}
+	return scanner.Err()
+}
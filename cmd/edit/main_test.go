@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("a\nb\nc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次 q 因为有未保存的修改被拒绝，保存后再退出
+	script := strings.Join([]string{"d 1", "d 1", "u", "q", "w", "q"}, "\n")
+	var out strings.Builder
+	if err := run(path, strings.NewReader(script), &out); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "b\nc\n" {
+		t.Errorf("expected one undo per command, file is %q", data)
+	}
+	if !strings.Contains(out.String(), "有未保存的修改") {
+		t.Errorf("expected unsaved changes warning, got:\n%s", out.String())
+	}
+}
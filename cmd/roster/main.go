@@ -0,0 +1,170 @@
+// roster 学生名册管理命令
+//
+// 用法:
+//
+//	roster convert <输入文件> <输出文件>
+//	roster query [选项] <文件>
+//
+// 文件格式由扩展名（.csv 或 .json）决定。query 支持的选项：
+//
+//	-grade A        只保留指定成绩
+//	-min-age 18     最小年龄（含）
+//	-max-age 22     最大年龄（含）
+//	-sort grade,-age 多键排序，前缀 - 表示降序
+//	-group          按成绩分组并输出平均年龄，不能与 -format、-o 同时使用
+//	-format table   输出格式：table、csv 或 json
+//	-o out.json     写入文件而不是标准输出（格式由扩展名决定）
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/howard/go.study/internal/roster"
+	"github.com/howard/go.study/internal/stage2"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "roster:", err)
+		os.Exit(1)
+	}
+}
+
+// run 执行子命令，用法说明写入 stderr
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("缺少子命令（convert 或 query）")
+	}
+
+	switch args[0] {
+	case "convert":
+		return runConvert(args[1:], stdout)
+	case "query":
+		return runQuery(args[1:], stdout, stderr)
+	default:
+		return fmt.Errorf("未知子命令: %s", args[0])
+	}
+}
+
+// runConvert 在 CSV 和 JSON 之间转换
+func runConvert(args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("用法: roster convert <输入文件> <输出文件>")
+	}
+
+	students, err := roster.LoadFile(args[0])
+	if err != nil {
+		return err
+	}
+	if err := roster.SaveFile(args[1], students); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "已导出 %d 名学生到 %s\n", len(students), args[1])
+	return nil
+}
+
+// runQuery 过滤、排序、分组并输出
+func runQuery(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: roster query [选项] <文件>")
+		fs.PrintDefaults()
+	}
+	grade := fs.String("grade", "", "只保留指定成绩")
+	minAge := fs.Int("min-age", 0, "最小年龄")
+	maxAge := fs.Int("max-age", 0, "最大年龄")
+	sortSpec := fs.String("sort", "", "排序键，如 grade,-age")
+	group := fs.Bool("group", false, "按成绩分组")
+	format := fs.String("format", "table", "输出格式: table、csv、json")
+	output := fs.String("o", "", "输出文件")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: roster query [选项] <文件>")
+	}
+	if *group {
+		// 分组结果只能以表格输出
+		var conflict []string
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "format" || f.Name == "o" {
+				conflict = append(conflict, "-"+f.Name)
+			}
+		})
+		if len(conflict) > 0 {
+			return fmt.Errorf("-group 不能与 %s 同时使用", strings.Join(conflict, "、"))
+		}
+	}
+
+	keys, err := roster.ParseSortKeys(*sortSpec)
+	if err != nil {
+		return err
+	}
+	students, err := roster.LoadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	query := roster.Query{Grade: *grade, MinAge: *minAge, MaxAge: *maxAge, SortBy: keys}
+	result, err := query.Run(students)
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		if err := roster.SaveFile(*output, result); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "已导出 %d 名学生到 %s\n", len(result), *output)
+		return nil
+	}
+
+	if *group {
+		return printGroups(stdout, roster.GroupByGrade(result))
+	}
+
+	switch *format {
+	case "table":
+		return printTable(stdout, result)
+	case "csv", "json":
+		return roster.Write(stdout, roster.Format(*format), result)
+	default:
+		return fmt.Errorf("未知输出格式: %s", *format)
+	}
+}
+
+// printTable 以对齐的表格输出学生
+func printTable(w io.Writer, students []stage2.Student) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\t姓名\t年龄\t成绩\t科目")
+	for _, s := range students {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\n",
+			s.ID, s.Name, s.Age, s.Grade, strings.Join(s.Subjects, ", "))
+	}
+	return tw.Flush()
+}
+
+// printGroups 输出分组统计
+func printGroups(w io.Writer, groups []roster.GradeGroup) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "成绩\t人数\t平均年龄\t学生")
+	for _, g := range groups {
+		names := make([]string, len(g.Students))
+		for i, s := range g.Students {
+			names[i] = s.Name
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%s\n",
+			g.Grade, g.Count, g.AverageAge, strings.Join(names, ", "))
+	}
+	return tw.Flush()
+}
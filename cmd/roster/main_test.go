@@ -0,0 +1,70 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/howard/go.study/internal/roster"
+	"github.com/howard/go.study/internal/stage2"
+)
+
+func writeRoster(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "students.csv")
+	students := []stage2.Student{
+		{ID: 1, Name: "Alice", Age: 20, Grade: "A"},
+		{ID: 2, Name: "Bob", Age: 22, Grade: "B"},
+		{ID: 3, Name: "Carol", Age: 19, Grade: "A"},
+	}
+	if err := roster.SaveFile(path, students); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestQuery(t *testing.T) {
+	path := writeRoster(t)
+	tests := []struct {
+		name    string
+		args    []string
+		want    string // stdout 中应包含的内容
+		wantErr string
+	}{
+		{"table", []string{"-grade", "A", "-sort", "-age", path}, "Alice", ""},
+		{"json", []string{"-format", "json", "-max-age", "19", path}, `"name": "Carol"`, ""},
+		{"group", []string{"-group", path}, "Alice, Carol", ""},
+		{"group with format", []string{"-group", "-format", "csv", path}, "", "-group 不能与 -format 同时使用"},
+		{"group with output", []string{"-group", "-o", "x.json", path}, "", "-group 不能与 -o 同时使用"},
+		{"unknown format", []string{"-format", "xml", path}, "", "未知输出格式"},
+		{"missing file argument", nil, "", "用法"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			err := run(append([]string{"query"}, tt.args...), &stdout, &stderr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(stdout.String(), tt.want) {
+				t.Errorf("expected %q in output:\n%s", tt.want, stdout.String())
+			}
+		})
+	}
+}
+
+func TestQueryHelp(t *testing.T) {
+	var stdout, stderr strings.Builder
+	if err := run([]string{"query", "-h"}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr.String(), "用法: roster query") || !strings.Contains(stderr.String(), "-group") {
+		t.Errorf("expected usage on stderr, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,282 @@
+// Package render 把 stage2、stage3 和 geometry 包的形状渲染成 SVG
+//
+// 形状按输入顺序从左到右排列，放不下时换行，每个形状下方标注名称、面积和周长。
+// 形状自身的坐标（例如 stage2.Circle.Center）不参与布局。
+// 其他实现了 geometry.Polygonal 的形状按多边形绘制。
+package render
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/howard/go.study/internal/stage2"
+	"github.com/howard/go.study/internal/stage3"
+	"github.com/howard/go.study/pkg/geometry"
+)
+
+// ErrUnsupportedShape 渲染器不认识的形状类型
+var ErrUnsupportedShape = errors.New("render: 不支持的形状")
+
+// Shape 可渲染的形状，stage2.Shape 和 stage3.Shape 都满足该接口
+type Shape interface {
+	Area() float64
+	Perimeter() float64
+}
+
+const (
+	defaultWidth   = 800
+	defaultScale   = 10
+	defaultPadding = 20
+	labelLine      = 16 // 每行标签的高度
+	labelLines     = 2
+	fontSize       = 12
+	strokeColor    = "#333333"
+)
+
+// 各类形状的默认填充色
+const (
+	circleFill    = "#ffb703"
+	rectangleFill = "#8ecae6"
+	triangleFill  = "#90be6d"
+	polygonFill   = "#cdb4db"
+)
+
+// Option 渲染器配置选项
+type Option func(*Renderer)
+
+// WithWidth 设置画布宽度（像素），形状超出时画布会自动加宽
+func WithWidth(width float64) Option {
+	return func(r *Renderer) {
+		r.width = width
+	}
+}
+
+// WithScale 设置每个形状单位对应的像素数
+func WithScale(scale float64) Option {
+	return func(r *Renderer) {
+		r.scale = scale
+	}
+}
+
+// WithPadding 设置画布边距和形状间距（像素）
+func WithPadding(padding float64) Option {
+	return func(r *Renderer) {
+		r.padding = padding
+	}
+}
+
+// Renderer SVG 渲染器
+type Renderer struct {
+	width   float64
+	scale   float64
+	padding float64
+}
+
+// NewRenderer 创建渲染器
+func NewRenderer(opts ...Option) *Renderer {
+	r := &Renderer{width: defaultWidth, scale: defaultScale, padding: defaultPadding}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// figure 形状的绘制信息，宽高以像素为单位
+type figure struct {
+	name   string
+	w, h   float64
+	draw   func(x, y float64) string // 以 (x, y) 为左上角输出 SVG 元素
+	area   float64
+	perim  float64
+	labelW float64
+}
+
+// cell 布局后的形状位置
+type cell struct {
+	fig  figure
+	x, y float64
+}
+
+// Render 把形状渲染为 SVG 写入 w
+func (r *Renderer) Render(w io.Writer, shapes []Shape) error {
+	if r.scale <= 0 {
+		return fmt.Errorf("render: 缩放比例必须为正数")
+	}
+
+	figs := make([]figure, 0, len(shapes))
+	for i, s := range shapes {
+		fig, err := r.figureOf(s)
+		if err != nil {
+			return fmt.Errorf("第%d个形状: %w", i+1, err)
+		}
+		figs = append(figs, fig)
+	}
+
+	cells, width, height := r.layout(figs)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s">`+"\n",
+		num(width), num(height), num(width), num(height))
+	fmt.Fprintf(bw, `  <rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+	for _, c := range cells {
+		fmt.Fprintf(bw, "  <g>\n    %s\n", c.fig.draw(c.x, c.y))
+		labelY := c.y + c.fig.h + labelLine
+		fmt.Fprintf(bw, `    <text x="%s" y="%s" font-family="sans-serif" font-size="%d">%s</text>`+"\n",
+			num(c.x), num(labelY), fontSize, html.EscapeString(c.fig.name))
+		fmt.Fprintf(bw, `    <text x="%s" y="%s" font-family="sans-serif" font-size="%d">A=%.2f P=%.2f</text>`+"\n",
+			num(c.x), num(labelY+labelLine), fontSize, c.fig.area, c.fig.perim)
+		fmt.Fprintf(bw, "  </g>\n")
+	}
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// RenderFile 把形状渲染为 SVG 文件
+func (r *Renderer) RenderFile(path string, shapes []Shape) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Render(f, shapes); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Render 使用默认配置渲染
+func Render(w io.Writer, shapes []Shape) error {
+	return NewRenderer().Render(w, shapes)
+}
+
+// RenderFile 使用默认配置渲染到文件
+func RenderFile(path string, shapes []Shape) error {
+	return NewRenderer().RenderFile(path, shapes)
+}
+
+// layout 从左到右排列形状，返回各形状位置和画布尺寸
+func (r *Renderer) layout(figs []figure) ([]cell, float64, float64) {
+	cells := make([]cell, 0, len(figs))
+	x, y := r.padding, r.padding
+	rowHeight := 0.0
+	width := r.width
+
+	for _, fig := range figs {
+		cellW := math.Max(fig.w, fig.labelW)
+		cellH := fig.h + labelLine*labelLines
+		// 当前行放不下时换行，行首的形状即使超宽也直接放置
+		if x > r.padding && x+cellW+r.padding > r.width {
+			x = r.padding
+			y += rowHeight + r.padding
+			rowHeight = 0
+		}
+		cells = append(cells, cell{fig: fig, x: x, y: y})
+		x += cellW + r.padding
+		rowHeight = math.Max(rowHeight, cellH)
+		width = math.Max(width, x)
+	}
+	return cells, math.Ceil(width), math.Ceil(y + rowHeight + r.padding)
+}
+
+// figureOf 根据形状的具体类型生成绘制信息
+func (r *Renderer) figureOf(s Shape) (figure, error) {
+	if s == nil {
+		return figure{}, fmt.Errorf("%w: nil", ErrUnsupportedShape)
+	}
+
+	var fig figure
+	switch v := s.(type) {
+	case stage2.ColoredCircle:
+		fig = r.circle("ColoredCircle", v.Radius, rgb(v.Color))
+	case *stage2.ColoredCircle:
+		fig = r.circle("ColoredCircle", v.Radius, rgb(v.Color))
+	case stage2.Circle:
+		fig = r.circle("Circle", v.Radius, circleFill)
+	case *stage2.Circle:
+		fig = r.circle("Circle", v.Radius, circleFill)
+	case stage2.Rectangle:
+		fig = r.rectangle(v.Width(), v.Height())
+	case *stage2.Rectangle:
+		fig = r.rectangle(v.Width(), v.Height())
+	case stage3.Circle:
+		fig = r.circle("Circle", v.Radius, circleFill)
+	case *stage3.Circle:
+		fig = r.circle("Circle", v.Radius, circleFill)
+	case stage3.Rectangle:
+		fig = r.rectangle(v.Width, v.Height)
+	case *stage3.Rectangle:
+		fig = r.rectangle(v.Width, v.Height)
+	case stage3.Triangle:
+		fig = r.polygon("Triangle", v.Polygon(), triangleFill)
+	case *stage3.Triangle:
+		fig = r.polygon("Triangle", v.Polygon(), triangleFill)
+	case geometry.Circle:
+		fig = r.circle("Circle", v.Radius, circleFill)
+	case *geometry.Circle:
+		fig = r.circle("Circle", v.Radius, circleFill)
+	case geometry.Rect:
+		fig = r.rectangle(v.Width(), v.Height())
+	case *geometry.Rect:
+		fig = r.rectangle(v.Width(), v.Height())
+	case geometry.Polygonal:
+		fig = r.polygon("Polygon", v.Polygon(), polygonFill)
+	default:
+		return figure{}, fmt.Errorf("%w: %T", ErrUnsupportedShape, s)
+	}
+
+	fig.area = s.Area()
+	fig.perim = s.Perimeter()
+	// 按每个字符约 0.6 倍字号估算标签宽度
+	label := fmt.Sprintf("A=%.2f P=%.2f", fig.area, fig.perim)
+	fig.labelW = float64(max(len(fig.name), len(label))) * fontSize * 0.6
+	return fig, nil
+}
+
+func (r *Renderer) circle(name string, radius float64, fill string) figure {
+	d := 2 * radius * r.scale
+	return figure{name: name, w: d, h: d, draw: func(x, y float64) string {
+		return fmt.Sprintf(`<circle cx="%s" cy="%s" r="%s" fill="%s" stroke="%s"/>`,
+			num(x+d/2), num(y+d/2), num(d/2), fill, strokeColor)
+	}}
+}
+
+func (r *Renderer) rectangle(width, height float64) figure {
+	w, h := width*r.scale, height*r.scale
+	return figure{name: "Rectangle", w: w, h: h, draw: func(x, y float64) string {
+		return fmt.Sprintf(`<rect x="%s" y="%s" width="%s" height="%s" fill="%s" stroke="%s"/>`,
+			num(x), num(y), num(w), num(h), rectangleFill, strokeColor)
+	}}
+}
+
+// polygon 按顶点绘制多边形，SVG 的 y 轴向下，绘制时上下翻转
+func (r *Renderer) polygon(name string, pg geometry.Polygon, fill string) figure {
+	b := pg.Bounds()
+	w, h := b.Width()*r.scale, b.Height()*r.scale
+	return figure{name: name, w: w, h: h, draw: func(x, y float64) string {
+		points := make([]string, len(pg))
+		for i, p := range pg {
+			points[i] = num(x+(p.X-b.Min.X)*r.scale) + "," + num(y+(b.Max.Y-p.Y)*r.scale)
+		}
+		return fmt.Sprintf(`<polygon points="%s" fill="%s" stroke="%s"/>`,
+			strings.Join(points, " "), fill, strokeColor)
+	}}
+}
+
+// rgb 把 stage2.Color 转为 SVG 颜色
+func rgb(c stage2.Color) string {
+	clamp := func(v int) int { return min(max(v, 0), 255) }
+	return fmt.Sprintf("rgb(%d,%d,%d)", clamp(c.R), clamp(c.G), clamp(c.B))
+}
+
+// num 格式化坐标，最多保留两位小数并去掉多余的零
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,174 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/howard/go.study/internal/stage2"
+	"github.com/howard/go.study/internal/stage3"
+	"github.com/howard/go.study/pkg/geometry"
+)
+
+var update = flag.Bool("update", false, "更新 testdata 中的 golden 文件")
+
+// checkGolden 比较输出与 testdata/name.golden.svg，-update 时重写 golden 文件
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden.svg")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取 golden 文件失败（使用 -update 生成）: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s 与 golden 文件不一致:\n--- got\n%s\n--- want\n%s", name, got, want)
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		shapes []Shape
+	}{
+		{
+			name: "stage2",
+			shapes: []Shape{
+				stage2.Circle{Radius: 3},
+				stage2.NewColoredCircle(4, 230, 57, 70),
+				stage2.Rectangle{TopLeft: stage2.Point{X: 0, Y: 0}, BottomRight: stage2.Point{X: 8, Y: 5}},
+			},
+		},
+		{
+			name: "stage3",
+			shapes: []Shape{
+				stage3.Rectangle{Width: 6, Height: 4},
+				stage3.Circle{Radius: 2.5},
+				stage3.Triangle{Base: 6, Height: 4},
+				stage3.Triangle{Base: 4, Height: 3, Offset: 3}, // 顶点超出底边
+			},
+		},
+		{
+			name: "wrapped",
+			opts: []Option{WithWidth(300), WithScale(8), WithPadding(10)},
+			shapes: []Shape{
+				&stage3.Rectangle{Width: 10, Height: 5},
+				&stage2.Circle{Radius: 5},
+				&stage3.Triangle{Base: 40, Height: 10}, // 比画布还宽，独占一行
+				stage2.ColoredCircle{Circle: stage2.Circle{Radius: 2}, Color: stage2.Color{R: 300, G: -1, B: 128}},
+			},
+		},
+		{
+			name: "geometry",
+			shapes: []Shape{
+				geometry.Circle{Center: geometry.Pt(5, 5), Radius: 2},
+				&geometry.Rect{Min: geometry.Pt(0, 0), Max: geometry.Pt(6, 3)},
+				geometry.Polygon{geometry.Pt(0, 0), geometry.Pt(4, 0), geometry.Pt(4, 4), geometry.Pt(2, 6), geometry.Pt(0, 4)},
+				mustTransform(t, geometry.Rotate(math.Pi/4), geometry.NewRect(geometry.Pt(0, 0), geometry.Pt(4, 2))),
+				mustTransform(t, geometry.Scale(2, 1), geometry.Circle{Radius: 2}),
+				diamond{size: 3}, // 只实现了 geometry.Polygonal
+			},
+		},
+		{name: "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := NewRenderer(tt.opts...).Render(&buf, tt.shapes); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, buf.Bytes())
+
+			// 输出必须是格式良好的 XML
+			dec := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+			for {
+				_, err := dec.Token()
+				if err != nil {
+					if err != io.EOF {
+						t.Fatalf("invalid XML: %v", err)
+					}
+					break
+				}
+			}
+		})
+	}
+}
+
+// mustTransform 变换 geometry 的形状，用于测试变换结果可以渲染
+func mustTransform(t *testing.T, tr geometry.Transform, s geometry.Shape) geometry.Shape {
+	t.Helper()
+	out, err := tr.Shape(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// diamond 只实现 geometry.Polygonal 的形状
+type diamond struct{ size float64 }
+
+func (d diamond) Polygon() geometry.Polygon {
+	return geometry.Polygon{geometry.Pt(d.size, 0), geometry.Pt(2*d.size, d.size), geometry.Pt(d.size, 2*d.size), geometry.Pt(0, d.size)}
+}
+func (d diamond) Area() float64      { return d.Polygon().Area() }
+func (d diamond) Perimeter() float64 { return d.Polygon().Perimeter() }
+
+func TestRenderUsesColoredCircleColor(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, []Shape{stage2.NewColoredCircle(1, 1, 2, 3)}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `fill="rgb(1,2,3)"`) {
+		t.Errorf("expected circle color in output:\n%s", buf.String())
+	}
+}
+
+// hexagon 渲染器不认识的形状
+type hexagon struct{}
+
+func (hexagon) Area() float64      { return 1 }
+func (hexagon) Perimeter() float64 { return 1 }
+
+func TestRenderErrors(t *testing.T) {
+	var buf bytes.Buffer
+	err := Render(&buf, []Shape{stage3.Circle{Radius: 1}, hexagon{}})
+	if !errors.Is(err, ErrUnsupportedShape) || !strings.Contains(err.Error(), "hexagon") {
+		t.Errorf("expected ErrUnsupportedShape naming hexagon, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Error("nothing should be written on error")
+	}
+	if err := NewRenderer(WithScale(0)).Render(&buf, nil); err == nil {
+		t.Error("expected error for zero scale")
+	}
+}
+
+func TestRenderFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shapes.svg")
+	shapes := []Shape{stage3.Triangle{Base: 6, Height: 4}}
+	if err := RenderFile(path, shapes); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want bytes.Buffer
+	Render(&want, shapes)
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Error("file output differs from writer output")
+	}
+}
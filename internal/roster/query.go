@@ -0,0 +1,142 @@
+package roster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/howard/go.study/internal/stage2"
+)
+
+// SortKey 排序键，Desc 为 true 时降序
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// compareFuncs 各字段的比较函数，返回负数、零或正数
+var compareFuncs = map[string]func(a, b stage2.Student) int{
+	"id":    func(a, b stage2.Student) int { return a.ID - b.ID },
+	"name":  func(a, b stage2.Student) int { return strings.Compare(a.Name, b.Name) },
+	"age":   func(a, b stage2.Student) int { return a.Age - b.Age },
+	"grade": func(a, b stage2.Student) int { return strings.Compare(a.Grade, b.Grade) },
+}
+
+// ParseSortKeys 解析形如 "grade,-age,name" 的排序描述，前缀 - 表示降序
+func ParseSortKeys(spec string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key := SortKey{Field: strings.ToLower(part)}
+		if strings.HasPrefix(key.Field, "-") {
+			key.Desc = true
+			key.Field = key.Field[1:]
+		} else {
+			key.Field = strings.TrimPrefix(key.Field, "+")
+		}
+
+		if _, ok := compareFuncs[key.Field]; !ok {
+			return nil, fmt.Errorf("未知的排序字段: %s", key.Field)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Query 学生查询条件，零值字段表示不限制
+type Query struct {
+	Grade  string
+	MinAge int
+	MaxAge int
+	SortBy []SortKey
+}
+
+// Match 判断学生是否满足过滤条件
+func (q Query) Match(s stage2.Student) bool {
+	if q.Grade != "" && !strings.EqualFold(s.Grade, q.Grade) {
+		return false
+	}
+	if q.MinAge > 0 && s.Age < q.MinAge {
+		return false
+	}
+	if q.MaxAge > 0 && s.Age > q.MaxAge {
+		return false
+	}
+	return true
+}
+
+// Run 过滤并排序，不修改输入切片
+func (q Query) Run(students []stage2.Student) ([]stage2.Student, error) {
+	if q.MinAge > 0 && q.MaxAge > 0 && q.MinAge > q.MaxAge {
+		return nil, fmt.Errorf("年龄范围无效: %d > %d", q.MinAge, q.MaxAge)
+	}
+
+	var result []stage2.Student
+	for _, s := range students {
+		if q.Match(s) {
+			result = append(result, s)
+		}
+	}
+
+	for _, key := range q.SortBy {
+		if _, ok := compareFuncs[key.Field]; !ok {
+			return nil, fmt.Errorf("未知的排序字段: %s", key.Field)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		for _, key := range q.SortBy {
+			c := compareFuncs[key.Field](result[i], result[j])
+			if c == 0 {
+				continue
+			}
+			if key.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+
+	return result, nil
+}
+
+// GradeGroup 按成绩分组的统计
+type GradeGroup struct {
+	Grade      string
+	Count      int
+	AverageAge float64
+	Students   []stage2.Student
+}
+
+// GroupByGrade 按成绩分组并计算平均年龄，结果按成绩排序
+func GroupByGrade(students []stage2.Student) []GradeGroup {
+	index := make(map[string]int)
+	var groups []GradeGroup
+	for _, s := range students {
+		i, ok := index[s.Grade]
+		if !ok {
+			i = len(groups)
+			index[s.Grade] = i
+			groups = append(groups, GradeGroup{Grade: s.Grade})
+		}
+		groups[i].Students = append(groups[i].Students, s)
+	}
+
+	for i := range groups {
+		total := 0
+		for _, s := range groups[i].Students {
+			total += s.Age
+		}
+		groups[i].Count = len(groups[i].Students)
+		groups[i].AverageAge = float64(total) / float64(groups[i].Count)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Grade < groups[j].Grade
+	})
+	return groups
+}
@@ -0,0 +1,217 @@
+// Package roster 学生名册的导入导出（CSV/JSON）
+package roster
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/howard/go.study/internal/stage2"
+)
+
+// Format 文件格式
+type Format string
+
+const (
+	// CSV 逗号分隔文件，科目之间用分号分隔
+	CSV Format = "csv"
+	// JSON 学生对象数组
+	JSON Format = "json"
+)
+
+// csvHeader CSV 文件的标准列
+var csvHeader = []string{"id", "name", "age", "grade", "subjects"}
+
+// subjectSep CSV 中科目的分隔符
+const subjectSep = ";"
+
+// FormatOf 根据文件扩展名判断格式
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return CSV, nil
+	case ".json":
+		return JSON, nil
+	default:
+		return "", fmt.Errorf("无法识别文件格式: %s", path)
+	}
+}
+
+// Read 按指定格式读取学生列表
+func Read(r io.Reader, format Format) ([]stage2.Student, error) {
+	switch format {
+	case CSV:
+		return ReadCSV(r)
+	case JSON:
+		return ReadJSON(r)
+	default:
+		return nil, fmt.Errorf("不支持的格式: %s", format)
+	}
+}
+
+// Write 按指定格式写出学生列表
+func Write(w io.Writer, format Format, students []stage2.Student) error {
+	switch format {
+	case CSV:
+		return WriteCSV(w, students)
+	case JSON:
+		return WriteJSON(w, students)
+	default:
+		return fmt.Errorf("不支持的格式: %s", format)
+	}
+}
+
+// LoadFile 读取学生文件，格式由扩展名决定
+func LoadFile(path string) ([]stage2.Student, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	students, err := Read(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return students, nil
+}
+
+// SaveFile 写出学生文件，格式由扩展名决定
+func SaveFile(path string, students []stage2.Student) error {
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, format, students); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadCSV 读取 CSV，首行必须是表头，列顺序不限
+func ReadCSV(r io.Reader) ([]stage2.Student, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "age", "grade"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV 缺少列: %s", required)
+		}
+	}
+
+	get := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var students []stage2.Student
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		s := stage2.Student{
+			Name:  get(record, "name"),
+			Grade: get(record, "grade"),
+		}
+		if id := get(record, "id"); id != "" {
+			if s.ID, err = strconv.Atoi(id); err != nil {
+				return nil, fmt.Errorf("第%d行: 无效的ID %q", line, id)
+			}
+		}
+		age := get(record, "age")
+		if s.Age, err = strconv.Atoi(age); err != nil {
+			return nil, fmt.Errorf("第%d行: 无效的年龄 %q", line, age)
+		}
+		if subjects := get(record, "subjects"); subjects != "" {
+			for _, subject := range strings.Split(subjects, subjectSep) {
+				if subject = strings.TrimSpace(subject); subject != "" {
+					s.Subjects = append(s.Subjects, subject)
+				}
+			}
+		}
+		students = append(students, s)
+	}
+	return students, nil
+}
+
+// WriteCSV 写出带表头的 CSV
+func WriteCSV(w io.Writer, students []stage2.Student) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, s := range students {
+		record := []string{
+			strconv.Itoa(s.ID),
+			s.Name,
+			strconv.Itoa(s.Age),
+			s.Grade,
+			strings.Join(s.Subjects, subjectSep),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ReadJSON 读取学生对象数组
+func ReadJSON(r io.Reader) ([]stage2.Student, error) {
+	var students []stage2.Student
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&students); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("解析JSON失败: %w", err)
+	}
+	return students, nil
+}
+
+// WriteJSON 写出缩进格式的学生对象数组
+func WriteJSON(w io.Writer, students []stage2.Student) error {
+	if students == nil {
+		students = []stage2.Student{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(students)
+}
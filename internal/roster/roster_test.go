@@ -0,0 +1,182 @@
+package roster
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/howard/go.study/internal/stage2"
+)
+
+func sampleStudents() []stage2.Student {
+	return []stage2.Student{
+		{ID: 1, Name: "Alice", Age: 20, Grade: "A", Subjects: []string{"Math", "Physics"}},
+		{ID: 2, Name: "Bob", Age: 19, Grade: "B", Subjects: []string{"Chemistry"}},
+		{ID: 3, Name: "Carol", Age: 21, Grade: "A"},
+		{ID: 4, Name: "David", Age: 19, Grade: "A", Subjects: []string{"English"}},
+		{ID: 5, Name: "Eve", Age: 22, Grade: "C"},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{CSV, JSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, sampleStudents()); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Read(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, sampleStudents()) {
+				t.Errorf("round trip mismatch:\n%+v\n%+v", got, sampleStudents())
+			}
+		})
+	}
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "class.csv")
+	jsonPath := filepath.Join(dir, "class.json")
+
+	if err := SaveFile(csvPath, sampleStudents()); err != nil {
+		t.Fatal(err)
+	}
+	students, err := LoadFile(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveFile(jsonPath, students); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sampleStudents()) {
+		t.Errorf("file round trip mismatch: %+v", got)
+	}
+
+	if _, err := LoadFile(filepath.Join(dir, "class.txt")); err == nil {
+		t.Error("expected error for unknown extension")
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []stage2.Student
+		wantErr string
+	}{
+		{
+			name:  "reordered columns without id",
+			input: "grade, name, age, subjects\nA, Alice, 20, Math; Physics\n",
+			want:  []stage2.Student{{Name: "Alice", Age: 20, Grade: "A", Subjects: []string{"Math", "Physics"}}},
+		},
+		{
+			name:  "empty input",
+			input: "",
+		},
+		{
+			name:    "missing column",
+			input:   "name,grade\nAlice,A\n",
+			wantErr: "缺少列: age",
+		},
+		{
+			name:    "bad age",
+			input:   "name,age,grade\nAlice,20,A\nBob,abc,B\n",
+			wantErr: "第3行: 无效的年龄",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCSV(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	names := func(students []stage2.Student) []string {
+		var result []string
+		for _, s := range students {
+			result = append(result, s.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		sort  string
+		want  []string
+	}{
+		{"no filter keeps order", Query{}, "", []string{"Alice", "Bob", "Carol", "David", "Eve"}},
+		{"grade filter", Query{Grade: "a"}, "", []string{"Alice", "Carol", "David"}},
+		{"age range", Query{MinAge: 20, MaxAge: 21}, "", []string{"Alice", "Carol"}},
+		{"age then name desc", Query{}, "age,-name", []string{"David", "Bob", "Alice", "Carol", "Eve"}},
+		{"grade then age desc", Query{}, "grade,-age", []string{"Carol", "Alice", "David", "Bob", "Eve"}},
+		{"filter and sort", Query{Grade: "A"}, "+age", []string{"David", "Alice", "Carol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseSortKeys(tt.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.query.SortBy = keys
+
+			input := sampleStudents()
+			got, err := tt.query.Run(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(names(got), tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, names(got))
+			}
+			if !reflect.DeepEqual(input, sampleStudents()) {
+				t.Error("Run modified its input")
+			}
+		})
+	}
+
+	if _, err := ParseSortKeys("age,height"); err == nil {
+		t.Error("expected error for unknown sort key")
+	}
+	if _, err := (Query{MinAge: 30, MaxAge: 20}).Run(sampleStudents()); err == nil {
+		t.Error("expected error for inverted age range")
+	}
+}
+
+func TestGroupByGrade(t *testing.T) {
+	groups := GroupByGrade(sampleStudents())
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(groups))
+	}
+
+	a := groups[0]
+	if a.Grade != "A" || a.Count != 3 || a.AverageAge != 20 {
+		t.Errorf("unexpected group A: %+v", a)
+	}
+	if groups[1].Grade != "B" || groups[2].Grade != "C" || groups[2].AverageAge != 22 {
+		t.Errorf("unexpected groups: %+v", groups)
+	}
+}
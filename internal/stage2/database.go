@@ -0,0 +1,508 @@
+package stage2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/howard/go.study/pkg/fsm"
+)
+
+var (
+	// ErrNotConnected 数据库未连接
+	ErrNotConnected = errors.New("数据库未连接")
+	// ErrAlreadyConnected 数据库已连接
+	ErrAlreadyConnected = errors.New("数据库已连接")
+	// ErrBadConn 连接已损坏，连接池会丢弃它而不是放回空闲列表
+	ErrBadConn = errors.New("连接已损坏")
+)
+
+// DBState 数据库连接状态
+type DBState string
+
+// DBEvent 数据库连接事件
+type DBEvent string
+
+const (
+	DBDisconnected DBState = "disconnected"
+	DBConnected    DBState = "connected"
+
+	DBConnect    DBEvent = "connect"
+	DBDisconnect DBEvent = "disconnect"
+)
+
+// dbLifecycle 数据库连接状态机
+var dbLifecycle = fsm.MustDefine(fsm.Spec[DBState, DBEvent]{
+	States: []DBState{DBDisconnected, DBConnected},
+	Events: []DBEvent{DBConnect, DBDisconnect},
+	Transitions: []fsm.Transition[DBState, DBEvent]{
+		{From: DBDisconnected, Event: DBConnect, To: DBConnected},
+		{From: DBConnected, Event: DBDisconnect, To: DBDisconnected},
+	},
+})
+
+// Driver 数据库驱动接口
+type Driver interface {
+	Open(dsn string) (Conn, error)
+}
+
+// Conn 驱动返回的单个连接，连接池保证同一时刻只有一个使用者
+type Conn interface {
+	Exec(ctx context.Context, command string, args ...string) (string, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// PoolStats 连接池统计
+type PoolStats struct {
+	MaxOpen int
+	Open    int
+	Idle    int
+	InUse   int
+}
+
+// Database 数据库单例，内部维护一个连接池
+type Database struct {
+	connectionString string
+	driver           Driver
+	maxOpen          int
+	maxIdle          int
+
+	mu      sync.Mutex
+	state   *fsm.Machine[DBState, DBEvent] // 由 mu 保护
+	idle    []Conn
+	numOpen int
+	sem     chan struct{} // 限制最大打开连接数，maxOpen 为 0 时为 nil
+}
+
+// DatabaseOption 数据库选项
+type DatabaseOption func(*Database)
+
+// WithMaxOpenConns 设置最大打开连接数，0 表示不限制
+func WithMaxOpenConns(n int) DatabaseOption {
+	return func(db *Database) {
+		db.maxOpen = n
+	}
+}
+
+// WithMaxIdleConns 设置最大空闲连接数
+func WithMaxIdleConns(n int) DatabaseOption {
+	return func(db *Database) {
+		db.maxIdle = n
+	}
+}
+
+var (
+	dbInstance *Database
+	dbOnce     sync.Once
+)
+
+// GetDatabase 获取数据库单例（并发安全），使用内存驱动
+func GetDatabase() *Database {
+	dbOnce.Do(func() {
+		dbInstance = NewDatabase(NewMemoryDriver(), "localhost:5432/myapp",
+			WithMaxOpenConns(10), WithMaxIdleConns(2))
+	})
+	return dbInstance
+}
+
+// NewDatabase 创建一个独立的数据库实例
+func NewDatabase(driver Driver, dsn string, options ...DatabaseOption) *Database {
+	db := &Database{
+		connectionString: dsn,
+		driver:           driver,
+		maxIdle:          2,
+	}
+	for _, option := range options {
+		option(db)
+	}
+	db.state = dbLifecycle.MustNew(DBDisconnected, fsm.OnEnter(DBConnected, db.open))
+	if db.maxOpen > 0 {
+		db.sem = make(chan struct{}, db.maxOpen)
+		if db.maxIdle > db.maxOpen {
+			db.maxIdle = db.maxOpen
+		}
+	}
+	return db
+}
+
+// Connect 连接数据库：打开并验证第一个连接
+func (db *Database) Connect() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	err := db.state.Fire(context.Background(), DBConnect)
+	if errors.Is(err, fsm.ErrInvalidTransition) {
+		return ErrAlreadyConnected
+	}
+	return err
+}
+
+// open 进入 connected 状态的钩子，调用方持有 mu；失败时保持断开状态
+func (db *Database) open(ctx context.Context, _ fsm.Transition[DBState, DBEvent]) error {
+	conn, err := db.driver.Open(db.connectionString)
+	if err != nil {
+		return fmt.Errorf("连接 %s 失败: %w", db.connectionString, err)
+	}
+	if err := conn.Ping(ctx); err != nil {
+		conn.Close()
+		return fmt.Errorf("连接 %s 失败: %w", db.connectionString, err)
+	}
+
+	// 上次断开时仍在使用的连接还计在 numOpen 中，归还时才会减去，因此这里只能累加
+	if db.maxIdle > 0 {
+		db.idle = append(db.idle, conn)
+		db.numOpen++
+	} else {
+		conn.Close()
+	}
+	return nil
+}
+
+// Disconnect 断开数据库连接：关闭所有空闲连接，使用中的连接在归还时关闭
+func (db *Database) Disconnect() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.state.Fire(context.Background(), DBDisconnect); err != nil {
+		if errors.Is(err, fsm.ErrInvalidTransition) {
+			return ErrNotConnected
+		}
+		return err
+	}
+
+	var errs []error
+	for _, conn := range db.idle {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		db.numOpen--
+	}
+	db.idle = nil
+	return errors.Join(errs...)
+}
+
+// IsConnected 检查连接状态
+func (db *Database) IsConnected() bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.state.Is(DBConnected)
+}
+
+// StateDiagram 连接状态图（Graphviz DOT），当前状态高亮
+func (db *Database) StateDiagram() string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.state.DOT("database")
+}
+
+// Stats 返回连接池统计
+func (db *Database) Stats() PoolStats {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return PoolStats{
+		MaxOpen: db.maxOpen,
+		Open:    db.numOpen,
+		Idle:    len(db.idle),
+		InUse:   db.numOpen - len(db.idle),
+	}
+}
+
+// Exec 从连接池取出一个连接执行命令
+func (db *Database) Exec(ctx context.Context, command string, args ...string) (string, error) {
+	conn, err := db.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	result, err := conn.Exec(ctx, command, args...)
+	db.release(conn, err)
+	return result, err
+}
+
+// Ping 健康检查：逐个检查空闲连接，丢弃失效的连接，再用一个连接确认数据库可用
+func (db *Database) Ping(ctx context.Context) error {
+	db.mu.Lock()
+	if !db.state.Is(DBConnected) {
+		db.mu.Unlock()
+		return ErrNotConnected
+	}
+	idle := db.idle
+	db.idle = nil
+	db.mu.Unlock()
+
+	var healthy []Conn
+	for _, conn := range idle {
+		if err := conn.Ping(ctx); err != nil {
+			conn.Close()
+			db.mu.Lock()
+			db.numOpen--
+			db.mu.Unlock()
+			continue
+		}
+		healthy = append(healthy, conn)
+	}
+
+	// 检查期间可能已经断开，此时把连接放回空闲列表就再也不会被关闭
+	db.mu.Lock()
+	if !db.state.Is(DBConnected) {
+		db.numOpen -= len(healthy)
+		db.mu.Unlock()
+		for _, conn := range healthy {
+			conn.Close()
+		}
+		return ErrNotConnected
+	}
+	db.idle = append(db.idle, healthy...)
+	db.mu.Unlock()
+
+	conn, err := db.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	err = conn.Ping(ctx)
+	db.release(conn, err)
+	return err
+}
+
+// acquire 取出一个空闲连接或新建连接，达到上限时等待或直到 ctx 结束
+func (db *Database) acquire(ctx context.Context) (Conn, error) {
+	if db.sem != nil {
+		select {
+		case db.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	db.mu.Lock()
+	if !db.state.Is(DBConnected) {
+		db.mu.Unlock()
+		db.releaseSlot()
+		return nil, ErrNotConnected
+	}
+	if n := len(db.idle); n > 0 {
+		conn := db.idle[n-1]
+		db.idle = db.idle[:n-1]
+		db.mu.Unlock()
+		return conn, nil
+	}
+	db.numOpen++
+	db.mu.Unlock()
+
+	conn, err := db.driver.Open(db.connectionString)
+	if err != nil {
+		db.mu.Lock()
+		db.numOpen--
+		db.mu.Unlock()
+		db.releaseSlot()
+		return nil, fmt.Errorf("打开连接失败: %w", err)
+	}
+	return conn, nil
+}
+
+// release 归还连接；连接损坏、数据库已断开或空闲数已满时关闭连接
+func (db *Database) release(conn Conn, err error) {
+	db.mu.Lock()
+	if errors.Is(err, ErrBadConn) || !db.state.Is(DBConnected) || len(db.idle) >= db.maxIdle {
+		db.numOpen--
+		db.mu.Unlock()
+		conn.Close()
+	} else {
+		db.idle = append(db.idle, conn)
+		db.mu.Unlock()
+	}
+	db.releaseSlot()
+}
+
+// releaseSlot 释放一个打开连接的名额
+func (db *Database) releaseSlot() {
+	if db.sem != nil {
+		<-db.sem
+	}
+}
+
+// String 数据库字符串表示
+func (db *Database) String() string {
+	status := "disconnected"
+	if db.IsConnected() {
+		status = "connected"
+	}
+	return fmt.Sprintf("Database{%s, %s}", db.connectionString, status)
+}
+
+// ===== 内存驱动 =====
+
+// MemoryDriver 内存键值驱动，同一驱动打开的连接共享数据
+//
+// 支持的命令：SET key value、GET key、DEL key、KEYS。
+type MemoryDriver struct {
+	mu     sync.RWMutex
+	data   map[string]string
+	opened int
+}
+
+// NewMemoryDriver 创建内存驱动
+func NewMemoryDriver() *MemoryDriver {
+	return &MemoryDriver{data: make(map[string]string)}
+}
+
+// Open 打开一个连接
+func (d *MemoryDriver) Open(dsn string) (Conn, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("连接字符串不能为空")
+	}
+	d.mu.Lock()
+	d.opened++
+	d.mu.Unlock()
+	return &memoryConn{driver: d}, nil
+}
+
+// Opened 返回累计打开的连接数
+func (d *MemoryDriver) Opened() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.opened
+}
+
+// memoryConn 内存驱动的连接
+type memoryConn struct {
+	driver *MemoryDriver
+	closed bool
+}
+
+// Exec 执行命令
+func (c *memoryConn) Exec(ctx context.Context, command string, args ...string) (string, error) {
+	if c.closed {
+		return "", ErrBadConn
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	d := c.driver
+	switch strings.ToUpper(command) {
+	case "SET":
+		if len(args) != 2 {
+			return "", fmt.Errorf("SET 需要2个参数，得到%d个", len(args))
+		}
+		d.mu.Lock()
+		d.data[args[0]] = args[1]
+		d.mu.Unlock()
+		return "OK", nil
+	case "GET":
+		if len(args) != 1 {
+			return "", fmt.Errorf("GET 需要1个参数，得到%d个", len(args))
+		}
+		d.mu.RLock()
+		value, ok := d.data[args[0]]
+		d.mu.RUnlock()
+		if !ok {
+			return "", fmt.Errorf("键不存在: %s", args[0])
+		}
+		return value, nil
+	case "DEL":
+		if len(args) != 1 {
+			return "", fmt.Errorf("DEL 需要1个参数，得到%d个", len(args))
+		}
+		d.mu.Lock()
+		delete(d.data, args[0])
+		d.mu.Unlock()
+		return "OK", nil
+	case "KEYS":
+		d.mu.RLock()
+		keys := make([]string, 0, len(d.data))
+		for k := range d.data {
+			keys = append(keys, k)
+		}
+		d.mu.RUnlock()
+		sort.Strings(keys)
+		return strings.Join(keys, ","), nil
+	default:
+		return "", fmt.Errorf("未知命令: %s", command)
+	}
+}
+
+// Ping 检查连接是否可用
+func (c *memoryConn) Ping(ctx context.Context) error {
+	if c.closed {
+		return ErrBadConn
+	}
+	return ctx.Err()
+}
+
+// Close 关闭连接
+func (c *memoryConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// demoSingletonPattern 演示单例模式
+func demoSingletonPattern() {
+	// 1. 获取数据库实例
+	db1 := GetDatabase()
+	fmt.Printf("第一个实例: %s\n", db1.String())
+
+	// 2. 再次获取实例（应该是同一个）
+	db2 := GetDatabase()
+	fmt.Printf("第二个实例: %s\n", db2.String())
+
+	// 3. 验证是同一个实例
+	fmt.Printf("是同一个实例: %t\n", db1 == db2)
+
+	// 4. 操作数据库
+	fmt.Println("\n数据库操作:")
+	if err := db1.Connect(); err != nil {
+		fmt.Printf("连接失败: %v\n", err)
+	}
+	fmt.Printf("db1连接状态: %t\n", db1.IsConnected())
+	fmt.Printf("db2连接状态: %t\n", db2.IsConnected())
+	fmt.Print(db1.StateDiagram())
+
+	// 重复连接会返回错误
+	if err := db2.Connect(); err != nil {
+		fmt.Printf("重复连接: %v\n", err)
+	}
+
+	ctx := context.Background()
+	db1.Exec(ctx, "SET", "language", "Go")
+	value, err := db2.Exec(ctx, "GET", "language")
+	fmt.Printf("通过db2读取db1写入的值: %s (错误: %v)\n", value, err)
+	fmt.Printf("健康检查: %v\n", db1.Ping(ctx))
+	fmt.Printf("连接池: %+v\n", db1.Stats())
+
+	if err := db2.Disconnect(); err != nil {
+		fmt.Printf("断开失败: %v\n", err)
+	}
+	fmt.Printf("db1连接状态: %t\n", db1.IsConnected())
+	fmt.Printf("db2连接状态: %t\n", db2.IsConnected())
+
+	if _, err := db1.Exec(ctx, "GET", "language"); err != nil {
+		fmt.Printf("断开后执行: %v\n", err)
+	}
+
+	// 5. 并发获取实例
+	const workers = 20
+	instances := make([]*Database, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			instances[i] = GetDatabase()
+		}(i)
+	}
+	wg.Wait()
+
+	fmt.Println("\n并发获取实例验证:")
+	allSame := true
+	for i := 1; i < len(instances); i++ {
+		if instances[i] != instances[0] {
+			allSame = false
+			break
+		}
+	}
+	fmt.Printf("所有实例都相同: %t\n", allSame)
+}
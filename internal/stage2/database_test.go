@@ -0,0 +1,319 @@
+package stage2
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyDriver 可以注入失败的测试驱动
+type flakyDriver struct {
+	*MemoryDriver
+	openErr error
+	pingErr error
+	conns   []*flakyConn
+	mu      sync.Mutex
+}
+
+type flakyConn struct {
+	Conn
+	broken bool
+	block  chan struct{}
+	onPing func() // 在 Ping 中调用，用于在检查过程中插入其他操作
+}
+
+func (d *flakyDriver) Open(dsn string) (Conn, error) {
+	if d.openErr != nil {
+		return nil, d.openErr
+	}
+	inner, err := d.MemoryDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	c := &flakyConn{Conn: inner}
+	d.mu.Lock()
+	d.conns = append(d.conns, c)
+	d.mu.Unlock()
+	return c, nil
+}
+
+func (c *flakyConn) Exec(ctx context.Context, command string, args ...string) (string, error) {
+	if c.block != nil {
+		<-c.block
+	}
+	if c.broken {
+		return "", ErrBadConn
+	}
+	return c.Conn.Exec(ctx, command, args...)
+}
+
+func (c *flakyConn) Ping(ctx context.Context) error {
+	if c.onPing != nil {
+		c.onPing()
+	}
+	if c.broken {
+		return ErrBadConn
+	}
+	return c.Conn.Ping(ctx)
+}
+
+func TestGetDatabaseConcurrent(t *testing.T) {
+	const workers = 50
+	results := make([]*Database, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = GetDatabase()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, db := range results {
+		if db == nil || db != results[0] {
+			t.Fatalf("instance %d differs", i)
+		}
+	}
+}
+
+func TestConnectDisconnect(t *testing.T) {
+	db := NewDatabase(NewMemoryDriver(), "mem")
+
+	if err := db.Disconnect(); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected, got %v", err)
+	}
+	if _, err := db.Exec(context.Background(), "KEYS"); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected, got %v", err)
+	}
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(); !errors.Is(err, ErrAlreadyConnected) {
+		t.Errorf("expected ErrAlreadyConnected, got %v", err)
+	}
+	if !db.IsConnected() || db.String() != "Database{mem, connected}" {
+		t.Errorf("unexpected state %s", db)
+	}
+	if err := db.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if db.IsConnected() || db.Stats().Open != 0 {
+		t.Errorf("expected closed pool, got %+v", db.Stats())
+	}
+	if d := db.StateDiagram(); !strings.Contains(d, `"disconnected" [style=filled`) {
+		t.Errorf("expected disconnected to be highlighted:\n%s", d)
+	}
+}
+
+func TestConnectErrors(t *testing.T) {
+	openErr := errors.New("network unreachable")
+	tests := []struct {
+		name   string
+		driver Driver
+		dsn    string
+		want   error
+	}{
+		{"open fails", &flakyDriver{MemoryDriver: NewMemoryDriver(), openErr: openErr}, "mem", openErr},
+		{"empty dsn", NewMemoryDriver(), "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := NewDatabase(tt.driver, tt.dsn)
+			err := db.Connect()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+			if db.IsConnected() {
+				t.Error("database should stay disconnected")
+			}
+		})
+	}
+}
+
+func TestExecSharesData(t *testing.T) {
+	db := NewDatabase(NewMemoryDriver(), "mem")
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := db.Exec(ctx, "SET", "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := db.Exec(ctx, "get", "k"); err != nil || got != "v" {
+		t.Errorf("expected v, got %q %v", got, err)
+	}
+	if _, err := db.Exec(ctx, "GET", "missing"); err == nil {
+		t.Error("expected error for missing key")
+	}
+	if _, err := db.Exec(ctx, "FLUSH"); err == nil {
+		t.Error("expected error for unknown command")
+	}
+}
+
+func TestPoolLimits(t *testing.T) {
+	driver := &flakyDriver{MemoryDriver: NewMemoryDriver()}
+	db := NewDatabase(driver, "mem", WithMaxOpenConns(3), WithMaxIdleConns(1))
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 并发执行大量命令，打开的连接数不能超过上限
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := db.Exec(context.Background(), "SET", "k", "v"); err != nil {
+				errs <- err
+			}
+			if s := db.Stats(); s.Open > 3 {
+				errs <- errors.New("too many open connections")
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	if s := db.Stats(); s.Idle > 1 || s.InUse != 0 {
+		t.Errorf("unexpected stats after load: %+v", s)
+	}
+}
+
+func TestPoolWaitRespectsContext(t *testing.T) {
+	driver := &flakyDriver{MemoryDriver: NewMemoryDriver()}
+	db := NewDatabase(driver, "mem", WithMaxOpenConns(1))
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 占住唯一的连接
+	block := make(chan struct{})
+	driver.mu.Lock()
+	driver.conns[0].block = block
+	driver.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		db.Exec(context.Background(), "KEYS")
+		close(done)
+	}()
+	for db.Stats().InUse != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := db.Exec(ctx, "KEYS"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	close(block)
+	<-done
+}
+
+func TestBadConnDiscardedAndHealthCheck(t *testing.T) {
+	driver := &flakyDriver{MemoryDriver: NewMemoryDriver()}
+	db := NewDatabase(driver, "mem", WithMaxIdleConns(2))
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// 损坏的连接在使用后被丢弃
+	driver.conns[0].broken = true
+	if _, err := db.Exec(ctx, "KEYS"); !errors.Is(err, ErrBadConn) {
+		t.Fatalf("expected ErrBadConn, got %v", err)
+	}
+	if s := db.Stats(); s.Open != 0 || s.Idle != 0 {
+		t.Errorf("expected broken connection to be discarded, got %+v", s)
+	}
+
+	// Ping 会清理空闲列表中失效的连接并打开新连接
+	if _, err := db.Exec(ctx, "KEYS"); err != nil {
+		t.Fatal(err)
+	}
+	driver.conns[1].broken = true
+	if err := db.Ping(ctx); err != nil {
+		t.Fatalf("expected healthy ping after eviction, got %v", err)
+	}
+	if s := db.Stats(); s.Open != 1 || s.Idle != 1 {
+		t.Errorf("unexpected stats after health check: %+v", s)
+	}
+	if driver.Opened() != 3 {
+		t.Errorf("expected 3 connections opened, got %d", driver.Opened())
+	}
+}
+
+func TestReconnectKeepsInUseCount(t *testing.T) {
+	driver := &flakyDriver{MemoryDriver: NewMemoryDriver()}
+	db := NewDatabase(driver, "mem", WithMaxIdleConns(1))
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 断开并重新连接时有一个连接仍在使用
+	block := make(chan struct{})
+	driver.mu.Lock()
+	driver.conns[0].block = block
+	driver.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		db.Exec(context.Background(), "KEYS")
+		close(done)
+	}()
+	for db.Stats().InUse != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := db.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if s := db.Stats(); s.Open != 2 || s.Idle != 1 || s.InUse != 1 {
+		t.Errorf("unexpected stats while old connection in use: %+v", s)
+	}
+
+	close(block)
+	<-done
+	if s := db.Stats(); s.Open != 1 || s.Idle != 1 || s.InUse != 0 {
+		t.Errorf("unexpected stats after release: %+v", s)
+	}
+}
+
+func TestPingRacingDisconnect(t *testing.T) {
+	driver := &flakyDriver{MemoryDriver: NewMemoryDriver()}
+	db := NewDatabase(driver, "mem")
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 检查空闲连接的过程中数据库被断开
+	conn := driver.conns[0]
+	conn.onPing = func() {
+		if err := db.Disconnect(); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := db.Ping(context.Background()); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("expected ErrNotConnected, got %v", err)
+	}
+	if s := db.Stats(); s.Open != 0 || s.Idle != 0 {
+		t.Errorf("expected checked connection to be closed, got %+v", s)
+	}
+	if !conn.Conn.(*memoryConn).closed {
+		t.Error("expected checked connection to be closed")
+	}
+}
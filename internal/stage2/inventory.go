@@ -0,0 +1,455 @@
+package stage2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var (
+	// ErrProductNotFound 库存中没有该产品
+	ErrProductNotFound = errors.New("产品不存在")
+	// ErrInsufficientStock 可用库存不足
+	ErrInsufficientStock = errors.New("库存不足")
+	// ErrReservationNotFound 预留记录不存在
+	ErrReservationNotFound = errors.New("预留不存在")
+)
+
+// StockItem 单个产品的库存
+type StockItem struct {
+	Product   *Product `json:"product"`
+	Quantity  int      `json:"quantity"`  // 实际库存
+	Reserved  int      `json:"reserved"`  // 已预留但未出库的数量
+	Threshold int      `json:"threshold"` // 可用库存低于该值时发出低库存提醒
+}
+
+// Available 可用库存 = 实际库存 - 已预留
+func (s StockItem) Available() int {
+	return s.Quantity - s.Reserved
+}
+
+// snapshot 复制库存及其产品，调用方修改副本不会影响库存
+func (s *StockItem) snapshot() StockItem {
+	c := *s
+	c.Product = cloneProduct(s.Product)
+	return c
+}
+
+// cloneProduct 复制产品，包括属性表
+func cloneProduct(p *Product) *Product {
+	c := *p
+	c.Attributes = maps.Clone(p.Attributes)
+	return &c
+}
+
+// Reservation 库存预留
+type Reservation struct {
+	ID        string `json:"id"`
+	ProductID int    `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// LowStockAlert 低库存提醒
+type LowStockAlert struct {
+	ProductID int
+	Name      string
+	Available int
+	Threshold int
+}
+
+// Inventory 库存服务，可并发使用
+type Inventory struct {
+	registry *ProductRegistry
+
+	mu           sync.Mutex
+	items        map[int]*StockItem
+	reservations map[string]*Reservation
+	nextID       int
+	nextResID    int
+	alerted      map[int]bool // 已经提醒过且尚未恢复的产品
+	onLowStock   []func(LowStockAlert)
+}
+
+// NewInventory 创建库存服务，registry 为 nil 时使用全局产品注册表
+func NewInventory(registry *ProductRegistry) *Inventory {
+	if registry == nil {
+		registry = defaultProducts
+	}
+	return &Inventory{
+		registry:     registry,
+		items:        make(map[int]*StockItem),
+		reservations: make(map[string]*Reservation),
+		alerted:      make(map[int]bool),
+		nextID:       1,
+		nextResID:    1,
+	}
+}
+
+// OnLowStock 注册低库存回调，可用库存从阈值以上降到阈值以下时触发一次
+func (inv *Inventory) OnLowStock(fn func(LowStockAlert)) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.onLowStock = append(inv.onLowStock, fn)
+}
+
+// Add 加入新产品及其初始库存，产品 ID 为 0 时自动分配，返回产品 ID
+//
+// 库存保存的是 p 的副本，之后修改 p 不会影响库存，p 本身也不会被修改。
+func (inv *Inventory) Add(p *Product, quantity, threshold int) (int, error) {
+	if quantity < 0 || threshold < 0 {
+		return 0, fmt.Errorf("库存和阈值不能为负数")
+	}
+	if _, ok := inv.registry.Lookup(p.Category); !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownProductType, p.Category)
+	}
+
+	p = cloneProduct(p)
+	inv.mu.Lock()
+	if p.ID == 0 {
+		p.ID = inv.nextID
+	}
+	if _, exists := inv.items[p.ID]; exists {
+		inv.mu.Unlock()
+		return 0, fmt.Errorf("产品ID %d 已存在", p.ID)
+	}
+	if p.ID >= inv.nextID {
+		inv.nextID = p.ID + 1
+	}
+	item := &StockItem{Product: p, Quantity: quantity, Threshold: threshold}
+	inv.items[p.ID] = item
+	alerts := inv.updateLocked(item)
+	inv.mu.Unlock()
+
+	inv.notify(alerts)
+	return p.ID, nil
+}
+
+// Restock 补货
+func (inv *Inventory) Restock(id, quantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("补货数量必须为正数")
+	}
+
+	inv.mu.Lock()
+	item, ok := inv.items[id]
+	if !ok {
+		inv.mu.Unlock()
+		return fmt.Errorf("%w: %d", ErrProductNotFound, id)
+	}
+	item.Quantity += quantity
+	alerts := inv.updateLocked(item)
+	inv.mu.Unlock()
+
+	inv.notify(alerts)
+	return nil
+}
+
+// Get 返回产品库存的副本
+func (inv *Inventory) Get(id int) (StockItem, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	item, ok := inv.items[id]
+	if !ok {
+		return StockItem{}, fmt.Errorf("%w: %d", ErrProductNotFound, id)
+	}
+	return item.snapshot(), nil
+}
+
+// Items 返回所有库存（按产品 ID 排序）
+func (inv *Inventory) Items() []StockItem {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	return inv.itemsLocked()
+}
+
+// itemsLocked 返回按产品 ID 排序的库存快照，调用方需持有锁
+func (inv *Inventory) itemsLocked() []StockItem {
+	items := make([]StockItem, 0, len(inv.items))
+	for _, item := range inv.items {
+		items = append(items, item.snapshot())
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Product.ID < items[j].Product.ID
+	})
+	return items
+}
+
+// LowStock 返回可用库存低于阈值的产品
+func (inv *Inventory) LowStock() []StockItem {
+	var low []StockItem
+	for _, item := range inv.Items() {
+		if item.Available() < item.Threshold {
+			low = append(low, item)
+		}
+	}
+	return low
+}
+
+// Reserve 预留库存，返回预留 ID
+func (inv *Inventory) Reserve(id, quantity int) (string, error) {
+	if quantity <= 0 {
+		return "", fmt.Errorf("预留数量必须为正数")
+	}
+
+	inv.mu.Lock()
+	item, ok := inv.items[id]
+	if !ok {
+		inv.mu.Unlock()
+		return "", fmt.Errorf("%w: %d", ErrProductNotFound, id)
+	}
+	if item.Available() < quantity {
+		available := item.Available()
+		inv.mu.Unlock()
+		return "", fmt.Errorf("%w: %s 可用%d，需要%d", ErrInsufficientStock, item.Product.Name, available, quantity)
+	}
+
+	item.Reserved += quantity
+	resID := "R" + strconv.Itoa(inv.nextResID)
+	inv.nextResID++
+	inv.reservations[resID] = &Reservation{ID: resID, ProductID: id, Quantity: quantity}
+	alerts := inv.updateLocked(item)
+	inv.mu.Unlock()
+
+	inv.notify(alerts)
+	return resID, nil
+}
+
+// Commit 确认预留，从实际库存中扣除
+func (inv *Inventory) Commit(resID string) error {
+	return inv.finish(resID, true)
+}
+
+// Cancel 取消预留，释放库存
+func (inv *Inventory) Cancel(resID string) error {
+	return inv.finish(resID, false)
+}
+
+// finish 结束预留，commit 为 true 时出库
+func (inv *Inventory) finish(resID string, commit bool) error {
+	inv.mu.Lock()
+	res, ok := inv.reservations[resID]
+	if !ok {
+		inv.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrReservationNotFound, resID)
+	}
+	delete(inv.reservations, resID)
+
+	item := inv.items[res.ProductID]
+	item.Reserved -= res.Quantity
+	if commit {
+		item.Quantity -= res.Quantity
+	}
+	alerts := inv.updateLocked(item)
+	inv.mu.Unlock()
+
+	inv.notify(alerts)
+	return nil
+}
+
+// Quote 按产品类型的定价规则报价
+func (inv *Inventory) Quote(id, quantity int) (Quote, error) {
+	if quantity <= 0 {
+		return Quote{}, fmt.Errorf("购买数量必须为正数")
+	}
+	item, err := inv.Get(id)
+	if err != nil {
+		return Quote{}, err
+	}
+	return inv.registry.Quote(item.Product, quantity)
+}
+
+// updateLocked 更新 InStock 标志并返回需要发出的低库存提醒，调用方需持有锁
+func (inv *Inventory) updateLocked(item *StockItem) []LowStockAlert {
+	item.Product.InStock = item.Available() > 0
+
+	id := item.Product.ID
+	low := item.Available() < item.Threshold
+	if !low {
+		delete(inv.alerted, id)
+		return nil
+	}
+	if inv.alerted[id] || len(inv.onLowStock) == 0 {
+		return nil
+	}
+	inv.alerted[id] = true
+	return []LowStockAlert{{
+		ProductID: id,
+		Name:      item.Product.Name,
+		Available: item.Available(),
+		Threshold: item.Threshold,
+	}}
+}
+
+// notify 在锁外调用回调，避免回调中再次访问库存时死锁
+func (inv *Inventory) notify(alerts []LowStockAlert) {
+	if len(alerts) == 0 {
+		return
+	}
+	inv.mu.Lock()
+	callbacks := make([]func(LowStockAlert), len(inv.onLowStock))
+	copy(callbacks, inv.onLowStock)
+	inv.mu.Unlock()
+
+	for _, alert := range alerts {
+		for _, fn := range callbacks {
+			fn(alert)
+		}
+	}
+}
+
+// inventoryFile 库存文件的 JSON 结构
+type inventoryFile struct {
+	NextID       int            `json:"next_id"`
+	NextResID    int            `json:"next_reservation_id"`
+	Items        []StockItem    `json:"items"`
+	Reservations []*Reservation `json:"reservations"`
+}
+
+// Save 将库存写入 JSON 文件（先写临时文件再重命名，避免写到一半的文件）
+func (inv *Inventory) Save(path string) error {
+	inv.mu.Lock()
+	file := inventoryFile{NextID: inv.nextID, NextResID: inv.nextResID, Items: inv.itemsLocked()}
+	for _, res := range inv.reservations {
+		file.Reservations = append(file.Reservations, res)
+	}
+	sort.Slice(file.Reservations, func(i, j int) bool {
+		return file.Reservations[i].ID < file.Reservations[j].ID
+	})
+	data, err := json.MarshalIndent(file, "", "  ")
+	inv.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".inventory-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadInventory 从 JSON 文件加载库存，产品类型必须已在 registry 中注册
+func LoadInventory(path string, registry *ProductRegistry) (*Inventory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file inventoryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析库存文件 %s 失败: %w", path, err)
+	}
+
+	inv := NewInventory(registry)
+	for i := range file.Items {
+		item := file.Items[i]
+		if item.Product == nil {
+			return nil, fmt.Errorf("库存文件 %s: 第%d项缺少产品", path, i+1)
+		}
+		if _, ok := inv.registry.Lookup(item.Product.Category); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProductType, item.Product.Category)
+		}
+		inv.items[item.Product.ID] = &item
+		if item.Available() < item.Threshold {
+			// 加载时已处于低库存的产品不再重复提醒
+			inv.alerted[item.Product.ID] = true
+		}
+	}
+	for _, res := range file.Reservations {
+		if _, ok := inv.items[res.ProductID]; !ok {
+			return nil, fmt.Errorf("库存文件 %s: 预留 %s 引用了不存在的产品 %d", path, res.ID, res.ProductID)
+		}
+		inv.reservations[res.ID] = res
+	}
+	if file.NextID > inv.nextID {
+		inv.nextID = file.NextID
+	}
+	if file.NextResID > inv.nextResID {
+		inv.nextResID = file.NextResID
+	}
+	return inv, nil
+}
+
+// demoFactoryFunctions 演示工厂函数
+func demoFactoryFunctions() {
+	// 1. 使用通用工厂函数
+	laptop := CreateProduct(Electronics, "Gaming Laptop", 1299.99)
+	laptop.ID = 1
+	fmt.Printf("电子产品: %s\n", laptop.String())
+
+	book := CreateProduct(Books, "Go Programming Guide", 49.99)
+	book.ID = 2
+	fmt.Printf("书籍产品: %s\n", book.String())
+
+	// 2. 使用专门的工厂函数
+	phone := CreateElectronics("Smartphone", 699.99)
+	phone.ID = 3
+	fmt.Printf("专门工厂(电子): %s\n", phone.String())
+
+	novel := CreateBook("Science Fiction Novel", 19.99)
+	novel.ID = 4
+	fmt.Printf("专门工厂(书籍): %s\n", novel.String())
+
+	// 3. 批量创建
+	products := []*Product{
+		CreateProduct(Clothing, "T-Shirt", 25.99),
+		CreateProduct(Food, "Organic Apple", 3.99),
+		CreateElectronics("Tablet", 399.99),
+		CreateBook("Cookbook", 29.99),
+	}
+
+	fmt.Println("\n批量创建的产品:")
+	for i, product := range products {
+		product.ID = i + 5
+		fmt.Printf("  %s\n", product.String())
+	}
+
+	// 4. 运行时注册新的产品类型，使用独立的注册表以免改动全局状态
+	registry := NewProductRegistry()
+	registry.Register(ProductKind{
+		Name:        "Furniture",
+		Description: "Furniture item",
+		Attributes:  map[string]string{"assembly": "required"},
+		Pricing:     PricingRule{TaxRate: 0.13, Discounts: []Discount{PercentDiscount{Percent: 10, MinQuantity: 2}}},
+	})
+	desk, _ := registry.Create("Furniture", "Standing Desk", 499)
+	fmt.Printf("\n运行时注册的类型: %s 属性: %v\n", desk.String(), desk.Attributes)
+	fmt.Printf("已注册类型: %v\n", registry.Kinds())
+
+	// 5. 库存服务
+	inv := NewInventory(registry)
+	inv.OnLowStock(func(alert LowStockAlert) {
+		fmt.Printf("  低库存提醒: %s 可用%d (阈值%d)\n", alert.Name, alert.Available, alert.Threshold)
+	})
+	deskID, _ := inv.Add(desk, 5, 3)
+
+	quote, _ := inv.Quote(deskID, 2)
+	fmt.Printf("购买2张书桌: 小计%.2f 折扣%.2f 税%.2f 合计%.2f\n",
+		quote.Subtotal, quote.Discount, quote.Tax, quote.Total)
+
+	resID, err := inv.Reserve(deskID, 3)
+	fmt.Printf("预留3张: %s (错误: %v)\n", resID, err)
+	if _, err := inv.Reserve(deskID, 5); err != nil {
+		fmt.Printf("再预留5张: %v\n", err)
+	}
+	inv.Commit(resID)
+	item, _ := inv.Get(deskID)
+	fmt.Printf("出库后: 库存%d 预留%d 可用%d\n", item.Quantity, item.Reserved, item.Available())
+}
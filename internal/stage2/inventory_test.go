@@ -0,0 +1,190 @@
+package stage2
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func newTestInventory(t *testing.T) (*Inventory, int) {
+	t.Helper()
+	inv := NewInventory(nil)
+	id, err := inv.Add(CreateBook("Go", 50), 10, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inv, id
+}
+
+func TestInventoryReserveCommitCancel(t *testing.T) {
+	inv, id := newTestInventory(t)
+
+	r1, err := inv.Reserve(id, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := inv.Reserve(id, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := inv.Reserve(id, 1); !errors.Is(err, ErrInsufficientStock) {
+		t.Errorf("expected ErrInsufficientStock, got %v", err)
+	}
+	if item, _ := inv.Get(id); item.Product.InStock {
+		t.Error("product should be out of stock when fully reserved")
+	}
+
+	if err := inv.Commit(r1); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Cancel(r2); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Cancel(r2); !errors.Is(err, ErrReservationNotFound) {
+		t.Errorf("expected ErrReservationNotFound, got %v", err)
+	}
+
+	item, _ := inv.Get(id)
+	if item.Quantity != 6 || item.Reserved != 0 || !item.Product.InStock {
+		t.Errorf("unexpected stock %+v", item)
+	}
+	if _, err := inv.Reserve(42, 1); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("expected ErrProductNotFound, got %v", err)
+	}
+}
+
+func TestInventoryAddValidation(t *testing.T) {
+	inv, id := newTestInventory(t)
+
+	if _, err := inv.Add(&Product{ID: id, Category: "Books"}, 1, 0); err == nil {
+		t.Error("expected duplicate id error")
+	}
+	if _, err := inv.Add(&Product{Category: "Toys"}, 1, 0); !errors.Is(err, ErrUnknownProductType) {
+		t.Errorf("expected ErrUnknownProductType, got %v", err)
+	}
+	if next, _ := inv.Add(CreateBook("Next", 1), 1, 0); next != id+1 {
+		t.Errorf("expected id %d, got %d", id+1, next)
+	}
+}
+
+func TestInventoryCopiesProducts(t *testing.T) {
+	inv := NewInventory(nil)
+	p := CreateBook("Go", 50)
+	p.Attributes = map[string]string{"author": "gopher"}
+	id, err := inv.Add(p, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 0 {
+		t.Errorf("Add modified its argument: %+v", p)
+	}
+
+	// 调用方和 Get 的结果都不与库存共享数据
+	p.Name = "changed"
+	p.Attributes["author"] = "changed"
+	item, _ := inv.Get(id)
+	item.Product.Attributes["author"] = "changed"
+	if item, _ := inv.Get(id); item.Product.Name != "Go" || item.Product.Attributes["author"] != "gopher" {
+		t.Errorf("stored product was modified: %+v", item.Product)
+	}
+}
+
+func TestInventoryLowStockAlerts(t *testing.T) {
+	inv, id := newTestInventory(t)
+	var alerts []LowStockAlert
+	inv.OnLowStock(func(a LowStockAlert) {
+		alerts = append(alerts, a)
+		// 回调中访问库存不能死锁
+		inv.LowStock()
+	})
+
+	r, _ := inv.Reserve(id, 8) // 可用2 < 3，提醒
+	inv.Reserve(id, 1)         // 仍低于阈值，不重复提醒
+	if len(alerts) != 1 || alerts[0].Available != 2 {
+		t.Fatalf("expected one alert, got %+v", alerts)
+	}
+	if low := inv.LowStock(); len(low) != 1 {
+		t.Errorf("expected one low stock item, got %d", len(low))
+	}
+
+	inv.Cancel(r)      // 恢复到阈值以上
+	inv.Reserve(id, 8) // 再次降到阈值以下
+	if len(alerts) != 2 {
+		t.Errorf("expected alert after recovery, got %d", len(alerts))
+	}
+}
+
+func TestInventoryQuote(t *testing.T) {
+	inv, id := newTestInventory(t)
+
+	q, err := inv.Quote(id, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 书籍每满3本减10元，税率9%
+	want := Quote{Quantity: 3, Subtotal: 150, Discount: 10, Tax: 12.6, Total: 152.6}
+	if q != want {
+		t.Errorf("expected %+v, got %+v", want, q)
+	}
+	if _, err := inv.Quote(id, 0); err == nil {
+		t.Error("expected error for zero quantity")
+	}
+}
+
+func TestInventorySaveLoad(t *testing.T) {
+	inv, id := newTestInventory(t)
+	res, _ := inv.Reserve(id, 2)
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	if err := inv.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadInventory(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := loaded.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Quantity != 10 || item.Reserved != 2 || item.Product.Name != "Go" {
+		t.Errorf("unexpected item after load %+v", item)
+	}
+	if err := loaded.Commit(res); err != nil {
+		t.Errorf("reservation not restored: %v", err)
+	}
+	if next, _ := loaded.Add(CreateBook("New", 1), 1, 0); next != id+1 {
+		t.Errorf("expected next id %d, got %d", id+1, next)
+	}
+
+	// 未注册的产品类型无法加载
+	if _, err := LoadInventory(path, NewProductRegistry()); !errors.Is(err, ErrUnknownProductType) {
+		t.Errorf("expected ErrUnknownProductType, got %v", err)
+	}
+}
+
+func TestInventoryConcurrentReserve(t *testing.T) {
+	inv, id := newTestInventory(t)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := inv.Reserve(id, 1); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+			inv.Items()
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 10 {
+		t.Errorf("expected exactly 10 reservations, got %d", succeeded)
+	}
+}
@@ -0,0 +1,565 @@
+package stage2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrBookNotFound 图书馆中没有该书
+	ErrBookNotFound = errors.New("书籍不存在")
+	// ErrUserNotFound 用户未注册
+	ErrUserNotFound = errors.New("用户不存在")
+	// ErrUserInactive 非激活用户不能借书
+	ErrUserInactive = errors.New("用户未激活")
+	// ErrBorrowLimit 超过借阅上限
+	ErrBorrowLimit = errors.New("超过借阅上限")
+	// ErrNoCopyAvailable 没有可借的副本
+	ErrNoCopyAvailable = errors.New("没有可借的副本")
+	// ErrLoanNotFound 借阅记录不存在或已归还
+	ErrLoanNotFound = errors.New("借阅记录不存在")
+	// ErrRenewLimit 超过续借次数
+	ErrRenewLimit = errors.New("超过续借次数")
+	// ErrHasReservations 有其他用户在排队，不能续借
+	ErrHasReservations = errors.New("该书已被预约")
+	// ErrAlreadyReserved 用户已在预约队列中
+	ErrAlreadyReserved = errors.New("已经预约过该书")
+)
+
+// Clock 时钟接口，测试时可以注入固定时间
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc 将函数适配为 Clock
+type ClockFunc func() time.Time
+
+// Now 返回当前时间
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock 使用系统时间的时钟
+var SystemClock Clock = ClockFunc(time.Now)
+
+// LendingPolicy 借阅规则
+type LendingPolicy struct {
+	LoanPeriod    time.Duration // 借期
+	MaxRenewals   int           // 最多续借次数
+	FinePerDay    float64       // 每逾期一天的罚金
+	MaxFine       float64       // 单次借阅的罚金上限，0 表示不设上限
+	ActiveLimit   int           // 激活用户的借阅上限
+	InactiveLimit int           // 非激活用户的借阅上限，0 表示不能借书
+	HoldPeriod    time.Duration // 为预约用户保留副本的期限，过期后转给下一位；0 表示一直保留
+}
+
+// DefaultLendingPolicy 默认借阅规则：借期14天，可续借2次，每天罚0.5元，最多罚20元，
+// 预约的副本保留3天
+var DefaultLendingPolicy = LendingPolicy{
+	LoanPeriod:  14 * 24 * time.Hour,
+	MaxRenewals: 2,
+	FinePerDay:  0.5,
+	MaxFine:     20,
+	ActiveLimit: 5,
+	HoldPeriod:  3 * 24 * time.Hour,
+}
+
+// Fine 计算在 at 时刻归还时的罚金，不足一天按一天计算
+func (p LendingPolicy) Fine(due, at time.Time) float64 {
+	if !at.After(due) {
+		return 0
+	}
+	days := math.Ceil(at.Sub(due).Hours() / 24)
+	fine := days * p.FinePerDay
+	if p.MaxFine > 0 && fine > p.MaxFine {
+		fine = p.MaxFine
+	}
+	return roundCents(fine)
+}
+
+// limit 根据用户状态返回借阅上限
+func (p LendingPolicy) limit(u *User) int {
+	if u.IsActive() {
+		return p.ActiveLimit
+	}
+	return p.InactiveLimit
+}
+
+// Loan 借阅记录
+type Loan struct {
+	ID         int
+	BookID     int
+	UserID     int
+	BorrowedAt time.Time
+	DueAt      time.Time
+	Renewals   int
+	ReturnedAt time.Time // 未归还时为零值
+	Fine       float64   // 归还时结算的罚金
+}
+
+// Returned 是否已归还
+func (l Loan) Returned() bool {
+	return !l.ReturnedAt.IsZero()
+}
+
+// String 借阅记录字符串表示
+func (l Loan) String() string {
+	return fmt.Sprintf("Loan{ID: %d, Book: %d, User: %d, Due: %s, Renewals: %d, Fine: %.2f}",
+		l.ID, l.BookID, l.UserID, l.DueAt.Format("2006-01-02"), l.Renewals, l.Fine)
+}
+
+// title 馆藏中的一种书及其副本情况
+type title struct {
+	book   *Book
+	copies int
+	onLoan int
+	queue  []int             // 等待中的预约用户
+	held   map[int]time.Time // 已为其保留副本的用户及保留的截止时间，零值表示不过期
+}
+
+// available 可以被任何人借走的副本数
+func (t *title) available() int {
+	return t.copies - t.onLoan - len(t.held)
+}
+
+// LibraryOption 图书馆配置选项
+type LibraryOption func(*Library)
+
+// WithClock 设置时钟
+func WithClock(c Clock) LibraryOption {
+	return func(l *Library) {
+		l.clock = c
+	}
+}
+
+// WithLendingPolicy 设置借阅规则
+func WithLendingPolicy(p LendingPolicy) LibraryOption {
+	return func(l *Library) {
+		l.policy = p
+	}
+}
+
+// Library 图书借阅服务，可并发使用
+//
+// 用户的激活状态在每次借书时读取，调用 Deactivate 后立即按非激活用户的上限处理。
+type Library struct {
+	clock  Clock
+	policy LendingPolicy
+
+	mu     sync.Mutex
+	titles map[int]*title
+	users  map[int]*User
+	loans  map[int]*Loan
+	nextID int
+}
+
+// NewLibrary 创建图书馆
+func NewLibrary(opts ...LibraryOption) *Library {
+	l := &Library{
+		clock:  SystemClock,
+		policy: DefaultLendingPolicy,
+		titles: make(map[int]*title),
+		users:  make(map[int]*User),
+		loans:  make(map[int]*Loan),
+		nextID: 1,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Policy 返回借阅规则
+func (l *Library) Policy() LendingPolicy {
+	return l.policy
+}
+
+// AddBook 入库 copies 本书，同一本书多次入库时累加副本数
+func (l *Library) AddBook(b *Book, copies int) error {
+	if b == nil || b.ID == 0 {
+		return fmt.Errorf("书籍必须有ID")
+	}
+	if copies <= 0 {
+		return fmt.Errorf("副本数必须为正数")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t, ok := l.titles[b.ID]
+	if !ok {
+		t = &title{book: b, held: make(map[int]time.Time)}
+		l.titles[b.ID] = t
+	}
+	t.copies += copies
+	l.promoteLocked(t)
+	return nil
+}
+
+// AddUser 注册用户
+func (l *Library) AddUser(u *User) error {
+	if u == nil || u.ID == 0 {
+		return fmt.Errorf("用户必须有ID")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, exists := l.users[u.ID]; exists {
+		return fmt.Errorf("用户ID %d 已存在", u.ID)
+	}
+	l.users[u.ID] = u
+	return nil
+}
+
+// Borrow 借书，返回借阅记录
+//
+// 有人排队时新到的副本会先保留给队首用户，其他用户只能借剩余的副本。
+func (l *Library) Borrow(userID, bookID int) (Loan, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, t, err := l.lookupLocked(userID, bookID)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	limit := l.policy.limit(u)
+	if limit == 0 && !u.IsActive() {
+		return Loan{}, fmt.Errorf("%w: %s", ErrUserInactive, u.Username)
+	}
+	if n := l.activeLoansLocked(userID); n >= limit {
+		return Loan{}, fmt.Errorf("%w: %s 已借%d本，上限%d本", ErrBorrowLimit, u.Username, n, limit)
+	}
+
+	_, held := t.held[userID]
+	switch {
+	case held:
+		delete(t.held, userID)
+	case t.available() > 0:
+	default:
+		return Loan{}, fmt.Errorf("%w: %s", ErrNoCopyAvailable, t.book.Title)
+	}
+
+	now := l.clock.Now()
+	loan := &Loan{
+		ID:         l.nextID,
+		BookID:     bookID,
+		UserID:     userID,
+		BorrowedAt: now,
+		DueAt:      now.Add(l.policy.LoanPeriod),
+	}
+	l.nextID++
+	l.loans[loan.ID] = loan
+	t.onLoan++
+	return *loan, nil
+}
+
+// Return 还书并结算罚金，返回已归还的借阅记录
+func (l *Library) Return(loanID int) (Loan, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	loan, err := l.openLoanLocked(loanID)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	loan.ReturnedAt = l.clock.Now()
+	loan.Fine = l.policy.Fine(loan.DueAt, loan.ReturnedAt)
+
+	t := l.titles[loan.BookID]
+	t.onLoan--
+	l.promoteLocked(t)
+	return *loan, nil
+}
+
+// Renew 续借，新的到期时间从当前时间起算
+//
+// 已逾期、超过续借次数或有人预约时不能续借。
+func (l *Library) Renew(loanID int) (Loan, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	loan, err := l.openLoanLocked(loanID)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	now := l.clock.Now()
+	if now.After(loan.DueAt) {
+		return Loan{}, fmt.Errorf("借阅 %d 已逾期，请先归还", loanID)
+	}
+	if loan.Renewals >= l.policy.MaxRenewals {
+		return Loan{}, fmt.Errorf("%w: 已续借%d次", ErrRenewLimit, loan.Renewals)
+	}
+	t := l.titles[loan.BookID]
+	l.promoteLocked(t)
+	if len(t.queue) > 0 {
+		return Loan{}, fmt.Errorf("%w: %d人在排队", ErrHasReservations, len(t.queue))
+	}
+
+	loan.Renewals++
+	loan.DueAt = now.Add(l.policy.LoanPeriod)
+	return *loan, nil
+}
+
+// Reserve 预约一本暂时没有副本的书，返回在队列中的位置（从1开始）
+func (l *Library) Reserve(userID, bookID int) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, t, err := l.lookupLocked(userID, bookID)
+	if err != nil {
+		return 0, err
+	}
+	if !u.IsActive() {
+		return 0, fmt.Errorf("%w: %s", ErrUserInactive, u.Username)
+	}
+	if t.available() > 0 {
+		return 0, fmt.Errorf("%s 有可借副本，无需预约", t.book.Title)
+	}
+	if _, held := t.held[userID]; held {
+		return 0, fmt.Errorf("%w: 副本已为 %s 保留", ErrAlreadyReserved, u.Username)
+	}
+	for _, id := range t.queue {
+		if id == userID {
+			return 0, fmt.Errorf("%w: %s", ErrAlreadyReserved, u.Username)
+		}
+	}
+
+	t.queue = append(t.queue, userID)
+	return len(t.queue), nil
+}
+
+// CancelReservation 取消预约，已保留的副本会转给下一位
+func (l *Library) CancelReservation(userID, bookID int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t, err := l.titleLocked(bookID)
+	if err != nil {
+		return err
+	}
+	if _, held := t.held[userID]; held {
+		delete(t.held, userID)
+		l.promoteLocked(t)
+		return nil
+	}
+	for i, id := range t.queue {
+		if id == userID {
+			t.queue = append(t.queue[:i], t.queue[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("用户 %d 没有预约书籍 %d", userID, bookID)
+}
+
+// Queue 返回书籍的预约队列（不含已保留副本的用户）
+func (l *Library) Queue(bookID int) []int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t, err := l.titleLocked(bookID)
+	if err != nil {
+		return nil
+	}
+	return append([]int(nil), t.queue...)
+}
+
+// HeldFor 返回已为其保留副本、可以直接借走的用户
+func (l *Library) HeldFor(bookID int) []int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t, err := l.titleLocked(bookID)
+	if err != nil {
+		return nil
+	}
+	users := make([]int, 0, len(t.held))
+	for id := range t.held {
+		users = append(users, id)
+	}
+	sort.Ints(users)
+	return users
+}
+
+// Available 返回书籍可借的副本数
+func (l *Library) Available(bookID int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t, err := l.titleLocked(bookID); err == nil {
+		return t.available()
+	}
+	return 0
+}
+
+// Loans 返回用户未归还的借阅（按到期时间排序）
+func (l *Library) Loans(userID int) []Loan {
+	return l.filterLoans(func(loan *Loan) bool {
+		return loan.UserID == userID && !loan.Returned()
+	})
+}
+
+// Overdue 返回当前所有逾期未还的借阅
+func (l *Library) Overdue() []Loan {
+	now := l.clock.Now()
+	return l.filterLoans(func(loan *Loan) bool {
+		return !loan.Returned() && now.After(loan.DueAt)
+	})
+}
+
+// FinesOwed 返回用户的罚金：已结算的罚金加上未还书籍截至目前的罚金
+func (l *Library) FinesOwed(userID int) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	total := 0.0
+	for _, loan := range l.loans {
+		if loan.UserID != userID {
+			continue
+		}
+		if loan.Returned() {
+			total += loan.Fine
+		} else {
+			total += l.policy.Fine(loan.DueAt, now)
+		}
+	}
+	return roundCents(total)
+}
+
+// filterLoans 返回满足条件的借阅副本
+func (l *Library) filterLoans(keep func(*Loan) bool) []Loan {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var loans []Loan
+	for _, loan := range l.loans {
+		if keep(loan) {
+			loans = append(loans, *loan)
+		}
+	}
+	sort.Slice(loans, func(i, j int) bool {
+		if !loans[i].DueAt.Equal(loans[j].DueAt) {
+			return loans[i].DueAt.Before(loans[j].DueAt)
+		}
+		return loans[i].ID < loans[j].ID
+	})
+	return loans
+}
+
+// lookupLocked 查找用户和书籍，调用方需持有锁
+func (l *Library) lookupLocked(userID, bookID int) (*User, *title, error) {
+	u, ok := l.users[userID]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %d", ErrUserNotFound, userID)
+	}
+	t, err := l.titleLocked(bookID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, t, nil
+}
+
+// titleLocked 查找书籍并更新其保留状态，调用方需持有锁
+func (l *Library) titleLocked(bookID int) (*title, error) {
+	t, ok := l.titles[bookID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrBookNotFound, bookID)
+	}
+	l.promoteLocked(t)
+	return t, nil
+}
+
+// openLoanLocked 查找未归还的借阅，调用方需持有锁
+func (l *Library) openLoanLocked(loanID int) (*Loan, error) {
+	loan, ok := l.loans[loanID]
+	if !ok || loan.Returned() {
+		return nil, fmt.Errorf("%w: %d", ErrLoanNotFound, loanID)
+	}
+	return loan, nil
+}
+
+// activeLoansLocked 统计用户未归还的借阅数，调用方需持有锁
+func (l *Library) activeLoansLocked(userID int) int {
+	n := 0
+	for _, loan := range l.loans {
+		if loan.UserID == userID && !loan.Returned() {
+			n++
+		}
+	}
+	return n
+}
+
+// promoteLocked 取消过期的保留，再把空闲副本依次保留给排队的用户，调用方需持有锁
+//
+// 保留过期的用户不再排队；已停用的用户轮到时被移出队列，重新激活后需要再次预约。
+func (l *Library) promoteLocked(t *title) {
+	now := l.clock.Now()
+	for id, deadline := range t.held {
+		if !deadline.IsZero() && !now.Before(deadline) {
+			delete(t.held, id)
+		}
+	}
+	for len(t.queue) > 0 && t.available() > 0 {
+		id := t.queue[0]
+		t.queue = t.queue[1:]
+		if u, ok := l.users[id]; !ok || !u.IsActive() {
+			continue
+		}
+		var deadline time.Time
+		if l.policy.HoldPeriod > 0 {
+			deadline = now.Add(l.policy.HoldPeriod)
+		}
+		t.held[id] = deadline
+	}
+}
+
+// demoLibrary 演示图书借阅
+func demoLibrary() {
+	// 使用可控的时钟模拟时间流逝
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	lib := NewLibrary(WithClock(ClockFunc(func() time.Time { return now })))
+
+	book := NewBookFull(1, "Go Patterns", "Bob Wilson", 250, 24.99)
+	lib.AddBook(book, 1)
+
+	alice := NewUser("alice", "alice@example.com")
+	alice.ID = 1
+	bob := NewUser("bob", "bob@example.com")
+	bob.ID = 2
+	lib.AddUser(alice)
+	lib.AddUser(bob)
+
+	loan, _ := lib.Borrow(alice.ID, book.ID)
+	fmt.Printf("alice 借书: %s\n", loan)
+
+	if _, err := lib.Borrow(bob.ID, book.ID); err != nil {
+		fmt.Printf("bob 借书失败: %v\n", err)
+	}
+	pos, _ := lib.Reserve(bob.ID, book.ID)
+	fmt.Printf("bob 预约排第%d位\n", pos)
+
+	if _, err := lib.Renew(loan.ID); err != nil {
+		fmt.Printf("alice 续借失败: %v\n", err)
+	}
+
+	// 逾期3天后归还
+	now = now.Add(17 * 24 * time.Hour)
+	fmt.Printf("逾期借阅: %d 笔, alice 当前罚金 %.2f\n", len(lib.Overdue()), lib.FinesOwed(alice.ID))
+	returned, _ := lib.Return(loan.ID)
+	fmt.Printf("alice 还书, 罚金 %.2f, 副本保留给用户 %v\n", returned.Fine, lib.HeldFor(book.ID))
+
+	loan, _ = lib.Borrow(bob.ID, book.ID)
+	fmt.Printf("bob 借到预约的书: %s\n", loan)
+
+	// 停用的用户不能借书
+	alice.Deactivate()
+	if _, err := lib.Borrow(alice.ID, book.ID); err != nil {
+		fmt.Printf("停用后 alice 借书: %v\n", err)
+	}
+}
@@ -0,0 +1,313 @@
+package stage2
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 可手动推进的测试时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+const day = 24 * time.Hour
+
+func newTestLibrary(t *testing.T, policy LendingPolicy, copies int) (*Library, *fakeClock, []*User) {
+	t.Helper()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	lib := NewLibrary(WithClock(clock), WithLendingPolicy(policy))
+	if err := lib.AddBook(NewBookFull(1, "Go", "Doe", 100, 10), copies); err != nil {
+		t.Fatal(err)
+	}
+	if err := lib.AddBook(NewBookFull(2, "Rust", "Roe", 100, 10), 5); err != nil {
+		t.Fatal(err)
+	}
+
+	var users []*User
+	for i, name := range []string{"alice", "bob", "carol"} {
+		u := NewUser(name, name+"@example.com")
+		u.ID = i + 1
+		if err := lib.AddUser(u); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, u)
+	}
+	return lib, clock, users
+}
+
+func TestLendingPolicyFine(t *testing.T) {
+	due := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	p := LendingPolicy{FinePerDay: 0.5, MaxFine: 3}
+	tests := []struct {
+		name string
+		at   time.Time
+		want float64
+	}{
+		{"early", due.Add(-time.Hour), 0},
+		{"exactly due", due, 0},
+		{"partial day rounds up", due.Add(time.Hour), 0.5},
+		{"two days", due.Add(2 * day), 1},
+		{"capped", due.Add(30 * day), 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Fine(due, tt.at); got != tt.want {
+				t.Errorf("expected %.2f, got %.2f", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLibraryBorrowReturn(t *testing.T) {
+	lib, clock, users := newTestLibrary(t, DefaultLendingPolicy, 2)
+	alice := users[0]
+
+	loan, err := lib.Borrow(alice.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.now.Add(14 * day); !loan.DueAt.Equal(want) {
+		t.Errorf("expected due %v, got %v", want, loan.DueAt)
+	}
+	if lib.Available(1) != 1 || len(lib.Loans(alice.ID)) != 1 {
+		t.Errorf("unexpected state after borrow")
+	}
+
+	clock.Advance(16 * day)
+	if overdue := lib.Overdue(); len(overdue) != 1 || overdue[0].ID != loan.ID {
+		t.Errorf("expected loan to be overdue, got %v", overdue)
+	}
+	if got := lib.FinesOwed(alice.ID); got != 1 {
+		t.Errorf("expected accrued fine 1, got %.2f", got)
+	}
+
+	returned, err := lib.Return(loan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !returned.Returned() || returned.Fine != 1 {
+		t.Errorf("unexpected returned loan %+v", returned)
+	}
+	if _, err := lib.Return(loan.ID); !errors.Is(err, ErrLoanNotFound) {
+		t.Errorf("expected ErrLoanNotFound, got %v", err)
+	}
+
+	// 已结算的罚金不再随时间增加
+	clock.Advance(10 * day)
+	if got := lib.FinesOwed(alice.ID); got != 1 {
+		t.Errorf("expected settled fine 1, got %.2f", got)
+	}
+	if lib.Available(1) != 2 || len(lib.Loans(alice.ID)) != 0 {
+		t.Errorf("unexpected state after return")
+	}
+}
+
+func TestLibraryBorrowErrors(t *testing.T) {
+	policy := DefaultLendingPolicy
+	policy.ActiveLimit = 2
+	lib, _, users := newTestLibrary(t, policy, 1)
+	alice, bob := users[0], users[1]
+
+	if _, err := lib.Borrow(99, 1); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+	if _, err := lib.Borrow(alice.ID, 99); !errors.Is(err, ErrBookNotFound) {
+		t.Errorf("expected ErrBookNotFound, got %v", err)
+	}
+
+	lib.Borrow(alice.ID, 1)
+	if _, err := lib.Borrow(bob.ID, 1); !errors.Is(err, ErrNoCopyAvailable) {
+		t.Errorf("expected ErrNoCopyAvailable, got %v", err)
+	}
+	lib.Borrow(alice.ID, 2)
+	if _, err := lib.Borrow(alice.ID, 2); !errors.Is(err, ErrBorrowLimit) {
+		t.Errorf("expected ErrBorrowLimit, got %v", err)
+	}
+}
+
+func TestLibraryLimitDependsOnActive(t *testing.T) {
+	policy := DefaultLendingPolicy
+	policy.ActiveLimit = 3
+	policy.InactiveLimit = 1
+	lib, _, users := newTestLibrary(t, policy, 1)
+	bob := users[1]
+
+	bob.Deactivate()
+	if _, err := lib.Borrow(bob.ID, 2); err != nil {
+		t.Fatalf("inactive user should borrow within InactiveLimit: %v", err)
+	}
+	if _, err := lib.Borrow(bob.ID, 2); !errors.Is(err, ErrBorrowLimit) {
+		t.Errorf("expected ErrBorrowLimit, got %v", err)
+	}
+
+	bob.Deactivate() // 重复停用不改变状态
+	if bob.State() != UserInactive {
+		t.Errorf("deactivating twice: expected %s, got %s", UserInactive, bob.State())
+	}
+	bob.Activate()
+	if _, err := lib.Borrow(bob.ID, 2); err != nil {
+		t.Errorf("activated user should use ActiveLimit: %v", err)
+	}
+
+	// 默认规则下非激活用户不能借书
+	lib2, _, users2 := newTestLibrary(t, DefaultLendingPolicy, 1)
+	users2[0].Deactivate()
+	if _, err := lib2.Borrow(users2[0].ID, 1); !errors.Is(err, ErrUserInactive) {
+		t.Errorf("expected ErrUserInactive, got %v", err)
+	}
+}
+
+func TestUserStateConcurrent(t *testing.T) {
+	var zero User
+	if zero.IsActive() || zero.State() != UserInactive {
+		t.Errorf("zero User should be inactive, got %s", zero.State())
+	}
+
+	u := NewUser("alice", "alice@example.com")
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			if i%2 == 0 {
+				u.Deactivate()
+			} else {
+				u.Activate()
+			}
+			_ = u.String()
+		})
+	}
+	wg.Wait()
+	if s := u.State(); s != UserActive && s != UserInactive {
+		t.Errorf("unexpected state %s", s)
+	}
+}
+
+func TestLibraryRenew(t *testing.T) {
+	policy := DefaultLendingPolicy
+	policy.MaxRenewals = 1
+	lib, clock, users := newTestLibrary(t, policy, 1)
+
+	loan, _ := lib.Borrow(users[0].ID, 1)
+	clock.Advance(10 * day)
+	renewed, err := lib.Renew(loan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.now.Add(14 * day); renewed.Renewals != 1 || !renewed.DueAt.Equal(want) {
+		t.Errorf("unexpected renewed loan %+v", renewed)
+	}
+	if _, err := lib.Renew(loan.ID); !errors.Is(err, ErrRenewLimit) {
+		t.Errorf("expected ErrRenewLimit, got %v", err)
+	}
+
+	// 逾期的借阅不能续借
+	loan2, _ := lib.Borrow(users[1].ID, 2)
+	clock.Advance(15 * day)
+	if _, err := lib.Renew(loan2.ID); err == nil {
+		t.Error("expected error renewing overdue loan")
+	}
+}
+
+func TestLibraryReservationQueue(t *testing.T) {
+	lib, _, users := newTestLibrary(t, DefaultLendingPolicy, 1)
+	alice, bob, carol := users[0], users[1], users[2]
+
+	if _, err := lib.Reserve(bob.ID, 1); err == nil {
+		t.Error("expected error reserving an available book")
+	}
+
+	loan, _ := lib.Borrow(alice.ID, 1)
+	if pos, err := lib.Reserve(bob.ID, 1); err != nil || pos != 1 {
+		t.Fatalf("expected position 1, got %d %v", pos, err)
+	}
+	if pos, _ := lib.Reserve(carol.ID, 1); pos != 2 {
+		t.Errorf("expected position 2, got %d", pos)
+	}
+	if _, err := lib.Reserve(bob.ID, 1); !errors.Is(err, ErrAlreadyReserved) {
+		t.Errorf("expected ErrAlreadyReserved, got %v", err)
+	}
+	if _, err := lib.Renew(loan.ID); !errors.Is(err, ErrHasReservations) {
+		t.Errorf("expected ErrHasReservations, got %v", err)
+	}
+
+	// 还书后副本保留给队首的 bob，carol 和 alice 都借不到
+	lib.Return(loan.ID)
+	if held := lib.HeldFor(1); len(held) != 1 || held[0] != bob.ID {
+		t.Fatalf("expected copy held for bob, got %v", held)
+	}
+	if _, err := lib.Borrow(carol.ID, 1); !errors.Is(err, ErrNoCopyAvailable) {
+		t.Errorf("expected ErrNoCopyAvailable for carol, got %v", err)
+	}
+
+	// bob 放弃后副本转给 carol
+	if err := lib.CancelReservation(bob.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if held := lib.HeldFor(1); len(held) != 1 || held[0] != carol.ID {
+		t.Fatalf("expected copy held for carol, got %v", held)
+	}
+	if _, err := lib.Borrow(carol.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(lib.Queue(1)) != 0 || len(lib.HeldFor(1)) != 0 {
+		t.Errorf("expected empty queue, got %v %v", lib.Queue(1), lib.HeldFor(1))
+	}
+}
+
+func TestLibraryHoldExpiresAndSkipsInactive(t *testing.T) {
+	lib, clock, users := newTestLibrary(t, DefaultLendingPolicy, 1)
+	alice, bob, carol := users[0], users[1], users[2]
+
+	loan, _ := lib.Borrow(alice.ID, 1)
+	lib.Reserve(bob.ID, 1)
+	lib.Reserve(carol.ID, 1)
+	lib.Return(loan.ID)
+	if held := lib.HeldFor(1); len(held) != 1 || held[0] != bob.ID {
+		t.Fatalf("expected copy held for bob, got %v", held)
+	}
+
+	// bob 没有在保留期内取书，副本转给 carol
+	clock.Advance(DefaultLendingPolicy.HoldPeriod)
+	if held := lib.HeldFor(1); len(held) != 1 || held[0] != carol.ID {
+		t.Fatalf("expected expired hold to pass to carol, got %v", held)
+	}
+	if _, err := lib.Borrow(bob.ID, 1); !errors.Is(err, ErrNoCopyAvailable) {
+		t.Errorf("expected ErrNoCopyAvailable after hold expired, got %v", err)
+	}
+
+	// 停用的用户轮到时被跳过
+	loan, _ = lib.Borrow(carol.ID, 1)
+	lib.Reserve(alice.ID, 1)
+	lib.Reserve(bob.ID, 1)
+	alice.Deactivate()
+	lib.Return(loan.ID)
+	if held := lib.HeldFor(1); len(held) != 1 || held[0] != bob.ID {
+		t.Errorf("expected inactive alice to be skipped, got %v", held)
+	}
+	if q := lib.Queue(1); len(q) != 0 {
+		t.Errorf("expected empty queue, got %v", q)
+	}
+}
+
+func TestLibraryAddBookPromotesQueue(t *testing.T) {
+	lib, _, users := newTestLibrary(t, DefaultLendingPolicy, 1)
+
+	lib.Borrow(users[0].ID, 1)
+	lib.Reserve(users[1].ID, 1)
+	if err := lib.AddBook(NewBookWithID(1, "Go", "Doe"), 1); err != nil {
+		t.Fatal(err)
+	}
+	if held := lib.HeldFor(1); len(held) != 1 || held[0] != users[1].ID {
+		t.Errorf("expected new copy held for bob, got %v", held)
+	}
+}
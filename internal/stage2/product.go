@@ -0,0 +1,292 @@
+package stage2
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownProductType 产品类型未注册
+var ErrUnknownProductType = errors.New("未注册的产品类型")
+
+// Product 产品结构体
+type Product struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Category    string            `json:"category"`
+	Price       float64           `json:"price"`
+	InStock     bool              `json:"in_stock"`
+	Description string            `json:"description"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+}
+
+// ProductType 产品类型
+type ProductType int
+
+const (
+	Electronics ProductType = iota
+	Books
+	Clothing
+	Food
+)
+
+// String 返回类型在注册表中的名称
+func (t ProductType) String() string {
+	switch t {
+	case Electronics:
+		return "Electronics"
+	case Books:
+		return "Books"
+	case Clothing:
+		return "Clothing"
+	case Food:
+		return "Food"
+	default:
+		return "Unknown"
+	}
+}
+
+// Discount 折扣规则，返回给定单价和数量下的折扣金额
+type Discount interface {
+	Amount(unitPrice float64, quantity int) float64
+}
+
+// PercentDiscount 购买数量达到 MinQuantity 时按百分比打折
+type PercentDiscount struct {
+	Percent     float64 // 例如 10 表示九折
+	MinQuantity int
+}
+
+// Amount 计算折扣金额
+func (d PercentDiscount) Amount(unitPrice float64, quantity int) float64 {
+	if quantity < d.MinQuantity {
+		return 0
+	}
+	return unitPrice * float64(quantity) * d.Percent / 100
+}
+
+// BulkDiscount 每满 Every 件减 Off 元
+type BulkDiscount struct {
+	Every int
+	Off   float64
+}
+
+// Amount 计算折扣金额
+func (d BulkDiscount) Amount(unitPrice float64, quantity int) float64 {
+	if d.Every <= 0 {
+		return 0
+	}
+	return float64(quantity/d.Every) * d.Off
+}
+
+// PricingRule 产品类型的定价规则
+type PricingRule struct {
+	TaxRate   float64    // 税率，例如 0.13
+	Discounts []Discount // 多个折扣时取金额最大的一个
+}
+
+// Quote 报价明细
+type Quote struct {
+	Quantity int
+	Subtotal float64
+	Discount float64
+	Tax      float64
+	Total    float64
+}
+
+// Quote 计算购买 quantity 件单价为 unitPrice 的产品的报价，税额按折后金额计算
+func (r PricingRule) Quote(unitPrice float64, quantity int) Quote {
+	q := Quote{Quantity: quantity, Subtotal: unitPrice * float64(quantity)}
+	for _, d := range r.Discounts {
+		q.Discount = math.Max(q.Discount, d.Amount(unitPrice, quantity))
+	}
+	q.Discount = math.Min(q.Discount, q.Subtotal)
+	q.Tax = roundCents((q.Subtotal - q.Discount) * r.TaxRate)
+	q.Subtotal = roundCents(q.Subtotal)
+	q.Discount = roundCents(q.Discount)
+	q.Total = roundCents(q.Subtotal - q.Discount + q.Tax)
+	return q
+}
+
+// roundCents 四舍五入到分
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// ProductKind 运行时注册的产品类型
+type ProductKind struct {
+	Name        string            // 类型名，同时作为产品的 Category
+	Description string            // 默认描述
+	Attributes  map[string]string // 默认属性，创建产品时复制
+	Pricing     PricingRule
+}
+
+// clone 复制类型，包括属性表和折扣列表，注册表内外的副本互不影响
+func (k ProductKind) clone() ProductKind {
+	attrs := make(map[string]string, len(k.Attributes))
+	maps.Copy(attrs, k.Attributes)
+	k.Attributes = attrs
+	k.Pricing.Discounts = slices.Clone(k.Pricing.Discounts)
+	return k
+}
+
+// ProductRegistry 产品类型注册表，可并发使用
+type ProductRegistry struct {
+	mu    sync.RWMutex
+	kinds map[string]ProductKind
+}
+
+// NewProductRegistry 创建空的注册表
+func NewProductRegistry() *ProductRegistry {
+	return &ProductRegistry{kinds: make(map[string]ProductKind)}
+}
+
+// Register 注册产品类型，同名类型会被覆盖
+func (r *ProductRegistry) Register(kind ProductKind) error {
+	if kind.Name == "" {
+		return fmt.Errorf("产品类型名不能为空")
+	}
+	if kind.Pricing.TaxRate < 0 {
+		return fmt.Errorf("产品类型 %s 的税率不能为负数", kind.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.kinds[kind.Name] = kind.clone()
+	return nil
+}
+
+// Lookup 查找产品类型，返回的是副本，修改它不会影响注册表
+func (r *ProductRegistry) Lookup(name string) (ProductKind, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	kind, ok := r.kinds[name]
+	if !ok {
+		return ProductKind{}, false
+	}
+	return kind.clone(), true
+}
+
+// Kinds 返回所有已注册的类型名（按字母排序）
+func (r *ProductRegistry) Kinds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.kinds))
+	for name := range r.kinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Create 按类型创建产品，复制类型的默认描述和属性
+func (r *ProductRegistry) Create(kindName, name string, price float64) (*Product, error) {
+	kind, ok := r.Lookup(kindName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProductType, kindName)
+	}
+	return &Product{
+		Name:        name,
+		Category:    kind.Name,
+		Price:       price,
+		InStock:     true,
+		Description: kind.Description,
+		Attributes:  kind.Attributes,
+	}, nil
+}
+
+// Quote 按产品所属类型的定价规则报价
+func (r *ProductRegistry) Quote(p *Product, quantity int) (Quote, error) {
+	kind, ok := r.Lookup(p.Category)
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: %s", ErrUnknownProductType, p.Category)
+	}
+	return kind.Pricing.Quote(p.Price, quantity), nil
+}
+
+// defaultProducts 内置产品类型的注册表
+var defaultProducts = newDefaultProductRegistry()
+
+// newDefaultProductRegistry 注册内置的四种产品类型
+func newDefaultProductRegistry() *ProductRegistry {
+	r := NewProductRegistry()
+	builtins := []ProductKind{
+		{
+			Name:        Electronics.String(),
+			Description: "Electronic device",
+			Attributes:  map[string]string{"warranty": "1 year"},
+			Pricing:     PricingRule{TaxRate: 0.13, Discounts: []Discount{PercentDiscount{Percent: 5, MinQuantity: 3}}},
+		},
+		{
+			Name:        Books.String(),
+			Description: "Book or publication",
+			Pricing:     PricingRule{TaxRate: 0.09, Discounts: []Discount{BulkDiscount{Every: 3, Off: 10}}},
+		},
+		{
+			Name:        Clothing.String(),
+			Description: "Clothing item",
+			Attributes:  map[string]string{"size": "M"},
+			Pricing:     PricingRule{TaxRate: 0.13},
+		},
+		{
+			Name:        Food.String(),
+			Description: "Food item",
+			Attributes:  map[string]string{"perishable": "true"},
+			Pricing:     PricingRule{TaxRate: 0.09},
+		},
+	}
+	for _, kind := range builtins {
+		r.Register(kind)
+	}
+	return r
+}
+
+// DefaultProductRegistry 返回 CreateProduct 使用的全局注册表
+func DefaultProductRegistry() *ProductRegistry {
+	return defaultProducts
+}
+
+// RegisterProductType 在全局注册表中注册新的产品类型
+func RegisterProductType(kind ProductKind) error {
+	return defaultProducts.Register(kind)
+}
+
+// CreateProduct 工厂函数 - 根据类型创建产品
+func CreateProduct(productType ProductType, name string, price float64) *Product {
+	product, err := defaultProducts.Create(productType.String(), name, price)
+	if err != nil {
+		// 未知类型保留原有的兜底行为
+		return &Product{
+			Name:        name,
+			Price:       price,
+			InStock:     true,
+			Category:    "Unknown",
+			Description: "Unknown product type",
+		}
+	}
+	return product
+}
+
+// CreateElectronics 创建电子产品
+func CreateElectronics(name string, price float64) *Product {
+	return CreateProduct(Electronics, name, price)
+}
+
+// CreateBook 创建书籍产品
+func CreateBook(name string, price float64) *Product {
+	return CreateProduct(Books, name, price)
+}
+
+// String 产品字符串表示
+func (p *Product) String() string {
+	stock := "In Stock"
+	if !p.InStock {
+		stock = "Out of Stock"
+	}
+	return fmt.Sprintf("Product{ID: %d, Name: %s, Category: %s, Price: %.2f, %s}",
+		p.ID, p.Name, p.Category, p.Price, stock)
+}
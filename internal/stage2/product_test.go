@@ -0,0 +1,110 @@
+package stage2
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestPricingRuleQuote(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  PricingRule
+		price float64
+		qty   int
+		want  Quote
+	}{
+		{"no discount", PricingRule{TaxRate: 0.1}, 10, 2, Quote{Quantity: 2, Subtotal: 20, Tax: 2, Total: 22}},
+		{
+			"percent below minimum",
+			PricingRule{Discounts: []Discount{PercentDiscount{Percent: 10, MinQuantity: 3}}},
+			10, 2, Quote{Quantity: 2, Subtotal: 20, Total: 20},
+		},
+		{
+			"tax after discount",
+			PricingRule{TaxRate: 0.1, Discounts: []Discount{PercentDiscount{Percent: 10, MinQuantity: 3}}},
+			10, 3, Quote{Quantity: 3, Subtotal: 30, Discount: 3, Tax: 2.7, Total: 29.7},
+		},
+		{
+			"largest discount wins",
+			PricingRule{Discounts: []Discount{PercentDiscount{Percent: 5}, BulkDiscount{Every: 2, Off: 4}}},
+			10, 4, Quote{Quantity: 4, Subtotal: 40, Discount: 8, Total: 32},
+		},
+		{
+			"discount capped at subtotal",
+			PricingRule{Discounts: []Discount{BulkDiscount{Every: 1, Off: 100}}},
+			10, 1, Quote{Quantity: 1, Subtotal: 10, Discount: 10, Total: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Quote(tt.price, tt.qty); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestProductRegistry(t *testing.T) {
+	r := NewProductRegistry()
+	if _, err := r.Create("Toys", "Robot", 10); !errors.Is(err, ErrUnknownProductType) {
+		t.Fatalf("expected ErrUnknownProductType, got %v", err)
+	}
+	if err := r.Register(ProductKind{}); err == nil {
+		t.Error("expected error for empty kind name")
+	}
+
+	attrs := map[string]string{"age": "3+"}
+	if err := r.Register(ProductKind{Name: "Toys", Description: "Toy", Attributes: attrs}); err != nil {
+		t.Fatal(err)
+	}
+	attrs["age"] = "changed"
+
+	p, err := r.Create("Toys", "Robot", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Category != "Toys" || p.Description != "Toy" || p.Attributes["age"] != "3+" {
+		t.Errorf("unexpected product %+v", p)
+	}
+
+	// 修改产品属性不能影响类型的默认属性
+	p.Attributes["age"] = "5+"
+	q, _ := r.Create("Toys", "Ball", 2)
+	if q.Attributes["age"] != "3+" {
+		t.Errorf("kind attributes leaked: %v", q.Attributes)
+	}
+
+	// Register 和 Lookup 都复制属性表和折扣列表
+	discounts := []Discount{PercentDiscount{Percent: 10}}
+	if err := r.Register(ProductKind{Name: "Games", Pricing: PricingRule{Discounts: discounts}}); err != nil {
+		t.Fatal(err)
+	}
+	discounts[0] = BulkDiscount{Every: 1, Off: 100}
+	kind, _ := r.Lookup("Games")
+	kind.Attributes["platform"] = "pc"
+	kind.Pricing.Discounts[0] = BulkDiscount{Every: 1, Off: 100}
+	again, _ := r.Lookup("Games")
+	if len(again.Attributes) != 0 || again.Pricing.Discounts[0] != (PercentDiscount{Percent: 10}) {
+		t.Errorf("registry modified through a copy: %+v", again)
+	}
+}
+
+func TestDemoKeepsDefaultRegistry(t *testing.T) {
+	before := DefaultProductRegistry().Kinds()
+	demoFactoryFunctions()
+	if after := DefaultProductRegistry().Kinds(); !slices.Equal(before, after) {
+		t.Errorf("demo changed the default registry: %v -> %v", before, after)
+	}
+}
+
+func TestCreateProductDefaults(t *testing.T) {
+	p := CreateElectronics("Phone", 100)
+	if p.Category != "Electronics" || p.Attributes["warranty"] != "1 year" || !p.InStock {
+		t.Errorf("unexpected product %+v", p)
+	}
+	if u := CreateProduct(ProductType(99), "Mystery", 1); u.Category != "Unknown" {
+		t.Errorf("expected Unknown category, got %s", u.Category)
+	}
+}
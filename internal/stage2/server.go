@@ -0,0 +1,397 @@
+package stage2
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Server 服务器结构体，封装 http.Server
+type Server struct {
+	Host    string
+	Port    int
+	Timeout int // 默认超时（秒），未单独设置的读、写、空闲超时使用该值
+	SSL     bool
+	Debug   bool
+
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+
+	// ClientTimeout Client 返回的客户端的整体超时，未设置时为读取与写入超时之和
+	ClientTimeout time.Duration
+
+	handler http.Handler
+
+	mu         sync.Mutex
+	httpServer *http.Server
+	listener   net.Listener
+	certPool   *x509.CertPool
+	done       chan error
+}
+
+// ServerOption 服务器选项函数类型
+type ServerOption func(*Server)
+
+// WithPort 设置端口选项，0 表示由系统分配
+func WithPort(port int) ServerOption {
+	return func(s *Server) {
+		s.Port = port
+	}
+}
+
+// WithTimeout 设置超时选项（秒）
+func WithTimeout(timeout int) ServerOption {
+	return func(s *Server) {
+		s.Timeout = timeout
+	}
+}
+
+// WithReadTimeout 单独设置读取超时
+func WithReadTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.ReadTimeout = d
+	}
+}
+
+// WithWriteTimeout 单独设置写入超时
+func WithWriteTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.WriteTimeout = d
+	}
+}
+
+// WithIdleTimeout 单独设置空闲连接超时
+func WithIdleTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.IdleTimeout = d
+	}
+}
+
+// WithClientTimeout 设置 Client 返回的客户端的整体超时
+func WithClientTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		s.ClientTimeout = d
+	}
+}
+
+// WithSSL 启用SSL选项，启动时生成自签名证书
+func WithSSL() ServerOption {
+	return func(s *Server) {
+		s.SSL = true
+	}
+}
+
+// WithDebug 启用调试选项，在 /debug/pprof/ 下挂载 pprof
+func WithDebug() ServerOption {
+	return func(s *Server) {
+		s.Debug = true
+	}
+}
+
+// WithHandler 设置业务处理器，默认提供 / 和 /healthz
+func WithHandler(h http.Handler) ServerOption {
+	return func(s *Server) {
+		s.handler = h
+	}
+}
+
+// NewServer 创建服务器（选项模式）
+func NewServer(host string, options ...ServerOption) *Server {
+	// 默认配置
+	server := &Server{
+		Host:    host,
+		Port:    8080,
+		Timeout: 30,
+		SSL:     false,
+		Debug:   false,
+	}
+
+	// 应用选项
+	for _, option := range options {
+		option(server)
+	}
+
+	// 未单独设置的超时使用默认超时
+	timeout := time.Duration(server.Timeout) * time.Second
+	if server.ReadTimeout == 0 {
+		server.ReadTimeout = timeout
+	}
+	if server.WriteTimeout == 0 {
+		server.WriteTimeout = timeout
+	}
+	if server.IdleTimeout == 0 {
+		server.IdleTimeout = timeout
+	}
+	// 客户端等待的是完整的往返：服务器读取请求加上写出响应
+	if server.ClientTimeout == 0 {
+		server.ClientTimeout = server.ReadTimeout + server.WriteTimeout
+	}
+
+	return server
+}
+
+// String 服务器字符串表示
+func (s *Server) String() string {
+	return fmt.Sprintf("Server{Host: %s, Port: %d, Timeout: %ds, SSL: %t, Debug: %t}",
+		s.Host, s.Port, s.Timeout, s.SSL, s.Debug)
+}
+
+// Handler 返回服务器使用的完整处理器（业务路由加上可选的调试路由）
+func (s *Server) Handler() http.Handler {
+	app := s.handler
+	if app == nil {
+		app = s.defaultHandler()
+	}
+	if !s.Debug {
+		return app
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/", app)
+	return mux
+}
+
+// defaultHandler 默认业务路由
+func (s *Server) defaultHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Hello from %s\n", s.Host)
+	})
+	return mux
+}
+
+// Start 监听端口并在后台开始服务，监听失败时返回错误
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.httpServer != nil {
+		return fmt.Errorf("服务器已启动: %s", s.listener.Addr())
+	}
+
+	ln, err := net.Listen("tcp", net.JoinHostPort(s.Host, strconv.Itoa(s.Port)))
+	if err != nil {
+		return fmt.Errorf("监听失败: %w", err)
+	}
+
+	srv := &http.Server{
+		Handler:      s.Handler(),
+		ReadTimeout:  s.ReadTimeout,
+		WriteTimeout: s.WriteTimeout,
+		IdleTimeout:  s.IdleTimeout,
+	}
+
+	if s.SSL {
+		cert, pool, err := selfSignedCert(s.Host)
+		if err != nil {
+			ln.Close()
+			return fmt.Errorf("生成自签名证书失败: %w", err)
+		}
+		srv.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+		ln = tls.NewListener(ln, srv.TLSConfig)
+		s.certPool = pool
+	}
+
+	s.httpServer = srv
+	s.listener = ln
+	s.done = make(chan error, 1)
+
+	go func(done chan<- error) {
+		err := srv.Serve(ln)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		done <- err
+	}(s.done)
+
+	return nil
+}
+
+// Addr 返回实际监听地址，未启动时返回空字符串
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// URL 返回服务器的基础 URL
+func (s *Server) URL() string {
+	scheme := "http"
+	if s.SSL {
+		scheme = "https"
+	}
+	return scheme + "://" + s.Addr()
+}
+
+// CertPool 返回信任自签名证书的证书池，供客户端使用；未启用SSL时为 nil
+func (s *Server) CertPool() *x509.CertPool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.certPool
+}
+
+// Client 返回一个信任本服务器证书的 HTTP 客户端
+func (s *Server) Client() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if pool := s.CertPool(); pool != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: transport, Timeout: s.ClientTimeout}
+}
+
+// Shutdown 停止接受新连接并等待进行中的请求完成
+//
+// ctx 先结束时强制关闭剩余连接，并返回 ctx 的错误。
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv, done := s.httpServer, s.done
+	s.httpServer, s.listener, s.done = nil, nil, nil
+	s.mu.Unlock()
+
+	if srv == nil {
+		return fmt.Errorf("服务器未启动")
+	}
+
+	if err := srv.Shutdown(ctx); err != nil {
+		srv.Close()
+		return err
+	}
+	return <-done
+}
+
+// selfSignedCert 为 host 生成一张自签名证书，同时返回信任它的证书池
+func selfSignedCert(host string) (tls.Certificate, *x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"go.study"}, CommonName: host},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	switch ip := net.ParseIP(host); {
+	case ip != nil:
+		template.IPAddresses = []net.IP{ip}
+	case host == "" || host == "localhost":
+		template.DNSNames = []string{"localhost"}
+		template.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	default:
+		template.DNSNames = []string{host}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+	return cert, pool, nil
+}
+
+// demoConstructorOptions 演示构造函数选项模式
+func demoConstructorOptions() {
+	// 1. 使用默认配置
+	server1 := NewServer("localhost")
+	fmt.Printf("默认配置: %s\n", server1.String())
+
+	// 2. 使用部分选项
+	server2 := NewServer("example.com", WithPort(443), WithSSL())
+	fmt.Printf("部分选项: %s\n", server2.String())
+
+	// 3. 使用所有选项
+	server3 := NewServer("api.example.com",
+		WithPort(9000),
+		WithTimeout(60),
+		WithSSL(),
+		WithDebug())
+	fmt.Printf("所有选项: %s\n", server3.String())
+
+	// 4. 动态选项
+	options := []ServerOption{WithPort(8443)}
+	if true { // 某种条件
+		options = append(options, WithSSL(), WithDebug())
+	}
+
+	server4 := NewServer("dynamic.example.com", options...)
+	fmt.Printf("动态选项: %s\n", server4.String())
+
+	// 5. 启动真实的服务器（端口 0 表示由系统分配）并优雅关闭
+	fmt.Println("\n启动服务器:")
+	for _, server := range []*Server{
+		NewServer("localhost", WithPort(0), WithTimeout(5)),
+		NewServer("localhost", WithPort(0), WithTimeout(5), WithSSL(), WithDebug()),
+	} {
+		if err := server.Start(); err != nil {
+			fmt.Printf("启动失败: %v\n", err)
+			continue
+		}
+		fmt.Printf("已启动: %s -> %s\n", server.String(), server.URL())
+
+		resp, err := server.Client().Get(server.URL() + "/healthz")
+		if err == nil {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			fmt.Printf("GET /healthz: %d %s\n", resp.StatusCode, body)
+		} else {
+			fmt.Printf("请求失败: %v\n", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := server.Shutdown(ctx); err != nil {
+			fmt.Printf("关闭失败: %v\n", err)
+		} else {
+			fmt.Println("服务器已优雅关闭")
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,203 @@
+package stage2
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewServerTimeouts(t *testing.T) {
+	tests := []struct {
+		name                      string
+		options                   []ServerOption
+		read, write, idle, client time.Duration
+		wantTimeoutSeconds        int
+	}{
+		{"defaults", nil, 30 * time.Second, 30 * time.Second, 30 * time.Second, time.Minute, 30},
+		{"timeout option", []ServerOption{WithTimeout(5)}, 5 * time.Second, 5 * time.Second, 5 * time.Second, 10 * time.Second, 5},
+		{
+			"individual overrides",
+			[]ServerOption{WithTimeout(5), WithReadTimeout(time.Second), WithIdleTimeout(time.Minute)},
+			time.Second, 5 * time.Second, time.Minute, 6 * time.Second, 5,
+		},
+		{
+			"client timeout",
+			[]ServerOption{WithTimeout(5), WithClientTimeout(time.Second)},
+			5 * time.Second, 5 * time.Second, 5 * time.Second, time.Second, 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer("localhost", tt.options...)
+			if s.ReadTimeout != tt.read || s.WriteTimeout != tt.write || s.IdleTimeout != tt.idle {
+				t.Errorf("expected %v/%v/%v, got %v/%v/%v",
+					tt.read, tt.write, tt.idle, s.ReadTimeout, s.WriteTimeout, s.IdleTimeout)
+			}
+			if got := s.Client().Timeout; got != tt.client {
+				t.Errorf("expected client timeout %v, got %v", tt.client, got)
+			}
+			if s.Timeout != tt.wantTimeoutSeconds {
+				t.Errorf("expected Timeout %d, got %d", tt.wantTimeoutSeconds, s.Timeout)
+			}
+		})
+	}
+}
+
+func TestServerHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []ServerOption
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"healthz", nil, "/healthz", http.StatusOK, "ok"},
+		{"root", nil, "/", http.StatusOK, "Hello from localhost"},
+		{"pprof disabled", nil, "/debug/pprof/", http.StatusOK, "Hello from localhost"},
+		{"pprof enabled", []ServerOption{WithDebug()}, "/debug/pprof/", http.StatusOK, "goroutine"},
+		{"app behind debug mux", []ServerOption{WithDebug()}, "/healthz", http.StatusOK, "ok"},
+		{
+			"custom handler",
+			[]ServerOption{WithHandler(http.NotFoundHandler())},
+			"/healthz", http.StatusNotFound, "404",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer("localhost", tt.options...)
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("expected body containing %q, got %q", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerStartTLS(t *testing.T) {
+	s := NewServer("localhost", WithPort(0), WithSSL(), WithTimeout(5))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Shutdown(context.Background())
+
+	if !strings.HasPrefix(s.URL(), "https://") {
+		t.Errorf("expected https URL, got %s", s.URL())
+	}
+	if err := s.Start(); err == nil {
+		t.Error("expected error when starting twice")
+	}
+
+	resp, err := s.Client().Get(s.URL() + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Errorf("unexpected response %d %q", resp.StatusCode, body)
+	}
+	if resp.TLS == nil {
+		t.Error("expected a TLS connection")
+	}
+
+	// 不信任自签名证书的客户端应当失败
+	if _, err := http.Get(s.URL() + "/healthz"); err == nil {
+		t.Error("expected certificate error from default client")
+	}
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	s := NewServer("127.0.0.1", WithPort(0), WithTimeout(5), WithHandler(slow))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	url := s.URL()
+	client := s.Client()
+
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := client.Get(url)
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		results <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- s.Shutdown(context.Background())
+	}()
+
+	// 进行中的请求未完成前 Shutdown 不能返回
+	select {
+	case err := <-shutdownDone:
+		t.Fatalf("Shutdown returned before in-flight request finished: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if r := <-results; r.err != nil || r.body != "done" {
+		t.Errorf("in-flight request failed: %q %v", r.body, r.err)
+	}
+	if err := <-shutdownDone; err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+
+	// 关闭后不再接受新请求
+	if _, err := client.Get(url); err == nil {
+		t.Error("expected request after shutdown to fail")
+	}
+	if err := s.Shutdown(context.Background()); err == nil {
+		t.Error("expected error when shutting down a stopped server")
+	}
+}
+
+func TestServerShutdownDeadline(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	stuck := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	s := NewServer("127.0.0.1", WithPort(0), WithTimeout(5), WithHandler(stuck))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	go s.Client().Get(s.URL())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
@@ -1,7 +1,19 @@
 package stage2
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"sync"
+
+	"github.com/howard/go.study/pkg/diff"
+	"github.com/howard/go.study/pkg/fsm"
+	"github.com/howard/go.study/pkg/geometry"
+	"github.com/howard/go.study/pkg/matrix"
+	"github.com/howard/go.study/pkg/sorting"
+	"github.com/howard/go.study/pkg/sqlmap"
+	"github.com/howard/go.study/pkg/stats"
+	"github.com/howard/go.study/pkg/validator"
 )
 
 // DemoArrays 演示数组
@@ -214,6 +226,45 @@ func demoMultiDimensionalArrays() {
 			fmt.Printf("  行 %d: %v\n", j, row)
 		}
 	}
+
+	// 3. 从固定数组到矩阵类型
+	demoMatrix()
+}
+
+// demoMatrix 演示 matrix 包：乘法、转置、行列式、求逆和解方程组
+func demoMatrix() {
+	a, _ := matrix.FromRows([][]float64{
+		{2, 1, 1},
+		{1, 3, 2},
+		{1, 0, 0},
+	})
+	fmt.Printf("矩阵A:\n%s", a)
+	fmt.Printf("A的转置:\n%s", a.T())
+
+	product, _ := a.Mul(a.T())
+	fmt.Printf("A·Aᵀ:\n%s", product)
+
+	det, _ := a.Det()
+	fmt.Printf("det(A) = %.3f\n", det)
+
+	if inv, err := a.Inverse(); err == nil {
+		fmt.Printf("A的逆矩阵:\n%s", inv)
+	}
+
+	// 求解 A·x = b
+	b := []float64{4, 5, 6}
+	if x, err := a.Solve(b); err == nil {
+		fmt.Printf("A·x = %v 的解: [%.3f %.3f %.3f]\n", b, x[0], x[1], x[2])
+	}
+
+	// 稀疏矩阵
+	sparse, _ := matrix.NewCSR(4, 4, []matrix.Triplet{
+		{Row: 0, Col: 0, Value: 1},
+		{Row: 1, Col: 2, Value: 2},
+		{Row: 3, Col: 3, Value: 3},
+	})
+	y, _ := sparse.MulVec([]float64{1, 1, 1, 1})
+	fmt.Printf("稀疏矩阵(非零元素%d个)乘以全1向量: %v\n", sparse.NNZ(), y)
 }
 
 // demoArrayParameters 演示数组作为函数参数
@@ -386,10 +437,10 @@ func demoSliceOperations() {
 	reverseSlice(slice)
 	fmt.Printf("反转后: %v\n", slice)
 
-	// 6. 切片排序（简单冒泡排序）
+	// 6. 切片排序（内省排序）
 	slice = []int{5, 2, 8, 1, 9}
 	fmt.Printf("排序前: %v\n", slice)
-	bubbleSort(slice)
+	sorting.Intro(slice, cmp.Compare[int])
 	fmt.Printf("排序后: %v\n", slice)
 }
 
@@ -400,18 +451,6 @@ func reverseSlice(slice []int) {
 	}
 }
 
-// bubbleSort 冒泡排序
-func bubbleSort(slice []int) {
-	n := len(slice)
-	for i := 0; i < n-1; i++ {
-		for j := 0; j < n-i-1; j++ {
-			if slice[j] > slice[j+1] {
-				slice[j], slice[j+1] = slice[j+1], slice[j]
-			}
-		}
-	}
-}
-
 // demoSliceInternals 演示切片内部结构
 func demoSliceInternals() {
 	// 1. 切片的底层数组
@@ -731,6 +770,33 @@ func demoMapOperations() {
 
 	fmt.Printf("map1 == map2: %t\n", mapsEqual(map1, map2))
 	fmt.Printf("map1 == map3: %t\n", mapsEqual(map1, map3))
+
+	// 6. 结构化比较：列出嵌套映射之间的具体差异并生成 JSON Patch
+	before := map[string]any{
+		"name":    "Alice",
+		"tags":    []string{"go", "rust"},
+		"address": map[string]string{"city": "Beijing"},
+	}
+	after := map[string]any{
+		"name":    "Alice",
+		"tags":    []string{"go"},
+		"address": map[string]string{"city": "Shanghai", "zip": "200000"},
+		"age":     20,
+	}
+	changes, err := diff.Diff(before, after)
+	if err != nil {
+		fmt.Printf("比较失败: %v\n", err)
+		return
+	}
+	for _, c := range changes {
+		fmt.Printf("  %s\n", c)
+	}
+	patch, err := diff.ToPatch(changes)
+	if err != nil {
+		fmt.Printf("生成补丁失败: %v\n", err)
+		return
+	}
+	fmt.Printf("JSON Patch: %s\n", patch)
 }
 
 // mapsEqual 比较两个映射是否相等
@@ -944,7 +1010,7 @@ func DemoStringOperations() {
 
 	// 2. 字符串查找和替换
 	fmt.Println("\n2. 字符串查找和替换：")
-	fmt.Println("字符串查找替换演示已实现")
+	demoStringSearch()
 
 	// 3. 字符串分割和连接
 	fmt.Println("\n3. 字符串分割和连接：")
@@ -994,17 +1060,15 @@ func DemoStructs() {
 
 // Student 学生结构体
 type Student struct {
-	ID       int
-	Name     string
-	Age      int
-	Grade    string
-	Subjects []string
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Age      int      `json:"age"`
+	Grade    string   `json:"grade"`
+	Subjects []string `json:"subjects,omitempty"`
 }
 
-// Point 点结构体
-type Point struct {
-	X, Y float64
-}
+// Point 点结构体，与 geometry 包共用同一类型
+type Point = geometry.Point
 
 // Rectangle 矩形结构体
 type Rectangle struct {
@@ -1012,6 +1076,42 @@ type Rectangle struct {
 	BottomRight Point
 }
 
+// Geometry 转换为几何矩形
+func (r Rectangle) Geometry() geometry.Rect {
+	return geometry.NewRect(r.TopLeft, r.BottomRight)
+}
+
+// Width 矩形宽度
+func (r Rectangle) Width() float64 {
+	return r.Geometry().Width()
+}
+
+// Height 矩形高度
+func (r Rectangle) Height() float64 {
+	return r.Geometry().Height()
+}
+
+// Area 计算矩形面积（实现Shape接口）
+func (r Rectangle) Area() float64 {
+	return r.Geometry().Area()
+}
+
+// Perimeter 计算矩形周长（实现Shape接口）
+func (r Rectangle) Perimeter() float64 {
+	return r.Geometry().Perimeter()
+}
+
+// Contains 点是否在矩形内
+func (r Rectangle) Contains(p Point) bool {
+	return r.Geometry().Contains(p)
+}
+
+// Transform 仿射变换，旋转或错切后的矩形变为多边形
+func (r Rectangle) Transform(t geometry.Transform) geometry.Shape {
+	s, _ := t.Shape(r.Geometry()) // 矩形总是可以变换
+	return s
+}
+
 // demoBasicStructs 演示结构体基础
 func demoBasicStructs() {
 	// 1. 声明和初始化结构体
@@ -1184,16 +1284,11 @@ func calculateAverageAge(students []Student) float64 {
 	return float64(total) / float64(len(students))
 }
 
-// sortStudentsByAge 按年龄排序学生
+// sortStudentsByAge 按年龄排序学生（稳定排序，同龄学生保持原有顺序）
 func sortStudentsByAge(students []Student) {
-	n := len(students)
-	for i := 0; i < n-1; i++ {
-		for j := 0; j < n-i-1; j++ {
-			if students[j].Age > students[j+1].Age {
-				students[j], students[j+1] = students[j+1], students[j]
-			}
-		}
-	}
+	sorting.Merge(students, func(a, b Student) int {
+		return cmp.Compare(a.Age, b.Age)
+	})
 }
 
 // demoNestedStructs 演示嵌套结构体
@@ -1296,55 +1391,55 @@ func demoAnonymousStructs() {
 	fmt.Printf("结果: 共%d项 - %v\n", result.Count, result.Items)
 
 	// 4. 函数返回匿名结构体
-	stats := getStats([]int{1, 2, 3, 4, 5})
-	fmt.Printf("统计信息: %+v\n", stats)
+	summary := getStats([]int{1, 2, 3, 4, 5})
+	fmt.Printf("统计信息: %+v\n", summary)
+
+	// 5. 流式统计：延迟数据逐个累加，无需保存整个切片
+	latencies := []float64{12, 15, 11, 230, 14, 18, 13, 95, 16, 12}
+	var acc stats.Summary
+	digest := stats.NewTDigest(0)
+	hist, _ := stats.NewHistogram(stats.ExponentialBuckets(10, 2, 5)...)
+	for _, ms := range latencies {
+		acc.Add(ms)
+		digest.Add(ms)
+		hist.Observe(ms)
+	}
+	fmt.Printf("延迟(ms): %v\n", &acc)
+	fmt.Printf("p50=%.1f p90=%.1f p99=%.1f\n", digest.Quantile(0.5), digest.Quantile(0.9), digest.Quantile(0.99))
+	fmt.Print(hist)
 }
 
-// getStats 返回统计信息的匿名结构体
+// getStats 返回统计信息的匿名结构体，数值由 stats.Summary 流式累加得到
 func getStats(numbers []int) struct {
-	Count int
-	Sum   int
-	Avg   float64
-	Min   int
-	Max   int
+	Count  int
+	Sum    int
+	Avg    float64
+	StdDev float64
+	Min    int
+	Max    int
 } {
-	if len(numbers) == 0 {
-		return struct {
-			Count int
-			Sum   int
-			Avg   float64
-			Min   int
-			Max   int
-		}{}
-	}
-
+	var s stats.Summary
 	sum := 0
-	min := numbers[0]
-	max := numbers[0]
-
 	for _, num := range numbers {
+		s.Add(float64(num))
 		sum += num
-		if num < min {
-			min = num
-		}
-		if num > max {
-			max = num
-		}
 	}
 
-	return struct {
-		Count int
-		Sum   int
-		Avg   float64
-		Min   int
-		Max   int
-	}{
-		Count: len(numbers),
-		Sum:   sum,
-		Avg:   float64(sum) / float64(len(numbers)),
-		Min:   min,
-		Max:   max,
+	result := struct {
+		Count  int
+		Sum    int
+		Avg    float64
+		StdDev float64
+		Min    int
+		Max    int
+	}{Count: s.Count(), Sum: sum}
+	if s.Count() > 0 {
+		result.Avg = s.Mean()
+		result.StdDev = s.StdDev()
+		result.Min = int(s.Min())
+		result.Max = int(s.Max())
 	}
+	return result
 }
 
 // demoStructTags 演示结构体标签
@@ -1372,13 +1467,34 @@ func demoStructTags() {
 	jsonStr := structToJSON(user)
 	fmt.Printf("JSON表示: %s\n", jsonStr)
 
-	// 3. 模拟数据库字段映射
+	// 3. 数据库字段映射
 	dbFields := getDBFields(user)
 	fmt.Printf("数据库字段: %v\n", dbFields)
 
-	// 4. 模拟验证规则
+	mapper := sqlmap.New()
+	if query, args, err := mapper.Insert("users", user); err == nil {
+		fmt.Printf("INSERT语句: %s %v\n", query, args)
+	}
+	if query, err := mapper.Select("users", user, "user_id"); err == nil {
+		fmt.Printf("SELECT语句: %s\n", query)
+	}
+
+	// 4. 验证规则
 	validationRules := getValidationRules(user)
 	fmt.Printf("验证规则: %v\n", validationRules)
+
+	// 5. 执行验证
+	v := validator.New()
+	fmt.Printf("合法用户验证: %v\n", v.Struct(user))
+
+	invalid := user
+	invalid.Username = "jo"
+	invalid.Email = "not-an-email"
+	if errs, ok := v.Struct(invalid).(validator.Errors); ok {
+		for _, e := range errs {
+			fmt.Printf("  %s / %s\n", e.Message(validator.Chinese), e.Message(validator.English))
+		}
+	}
 }
 
 // structToJSON 简化的JSON序列化
@@ -1393,31 +1509,21 @@ func structToJSON(user interface{}) string {
 	}
 }
 
-// getDBFields 获取数据库字段映射
+// getDBFields 获取数据库字段映射（读取 db 标签）
 func getDBFields(user interface{}) map[string]interface{} {
-	// 简化实现，实际应该使用reflect包解析标签
-	result := make(map[string]interface{})
-
-	// 模拟从结构体标签中提取db字段名
-	result["user_id"] = 1
-	result["username"] = "john_doe"
-	result["email"] = "john@example.com"
-	result["password_hash"] = "secret123"
-	result["is_active"] = true
-
-	return result
+	fields, err := sqlmap.New().Values(user)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	return fields
 }
 
-// getValidationRules 获取验证规则
+// getValidationRules 获取验证规则（读取 validate 标签）
 func getValidationRules(user interface{}) map[string][]string {
-	// 简化实现，实际应该使用reflect包解析标签
-	rules := make(map[string][]string)
-
-	rules["ID"] = []string{"required"}
-	rules["Username"] = []string{"required", "min=3"}
-	rules["Email"] = []string{"required", "email"}
-	rules["Password"] = []string{"required", "min=8"}
-
+	rules, err := validator.New().Rules(user)
+	if err != nil {
+		return map[string][]string{}
+	}
 	return rules
 }
 
@@ -1452,14 +1558,35 @@ type Circle struct {
 	Center Point
 }
 
+// Geometry 转换为几何圆（值接收者）
+func (c Circle) Geometry() geometry.Circle {
+	return geometry.Circle{Center: c.Center, Radius: c.Radius}
+}
+
 // Area 计算圆的面积（值接收者）
 func (c Circle) Area() float64 {
-	return 3.14159 * c.Radius * c.Radius
+	return c.Geometry().Area()
 }
 
 // Circumference 计算圆的周长（值接收者）
 func (c Circle) Circumference() float64 {
-	return 2 * 3.14159 * c.Radius
+	return c.Geometry().Perimeter()
+}
+
+// Contains 点是否在圆内（值接收者）
+func (c Circle) Contains(p Point) bool {
+	return c.Geometry().Contains(p)
+}
+
+// Intersects 两个圆是否相交（值接收者）
+func (c Circle) Intersects(other Circle) bool {
+	return geometry.Intersects(c.Geometry(), other.Geometry())
+}
+
+// Transform 仿射变换（值接收者），非均匀缩放或错切后的圆变为多边形
+func (c Circle) Transform(t geometry.Transform) geometry.Shape {
+	s, _ := t.Shape(c.Geometry()) // 圆总是可以变换
+	return s
 }
 
 // Perimeter 计算圆的周长（实现Shape接口）
@@ -1474,8 +1601,7 @@ func (c *Circle) Scale(factor float64) {
 
 // Move 移动圆心（指针接收者）
 func (c *Circle) Move(dx, dy float64) {
-	c.Center.X += dx
-	c.Center.Y += dy
+	c.Center = c.Center.Add(geometry.Vec(dx, dy))
 }
 
 // String 字符串表示（值接收者）
@@ -1520,6 +1646,16 @@ func demoBasicMethods() {
 	fmt.Printf("零值圆形: %s\n", zeroCircle.String())
 	fmt.Printf("零值圆形面积: %.2f\n", zeroCircle.Area())
 	fmt.Printf("零值圆形是否有效: %t\n", zeroCircle.IsValid())
+
+	// 5. 几何运算（委托给 geometry 包）
+	other := Circle{Radius: 2.0, Center: Point{X: 6, Y: 0}}
+	fmt.Printf("包含点(3, 4): %t\n", circle.Contains(Point{X: 3, Y: 4}))
+	fmt.Printf("与 %s 相交: %t\n", other.String(), circle.Intersects(other))
+
+	// 6. 仿射变换：均匀缩放后仍是圆，非均匀缩放后变为多边形
+	fmt.Printf("均匀缩放: %v\n", circle.Transform(geometry.Scale(2, 2)))
+	stretched := circle.Transform(geometry.Scale(2, 1))
+	fmt.Printf("非均匀缩放: %T 面积=%.2f\n", stretched, stretched.Area())
 }
 
 // demoReceiverTypes 演示值接收者vs指针接收者
@@ -1847,6 +1983,10 @@ func DemoConstructor() {
 	// 5. 单例模式
 	fmt.Println("\n5. 单例模式：")
 	demoSingletonPattern()
+
+	// 6. 图书借阅
+	fmt.Println("\n6. 图书借阅：")
+	demoLibrary()
 }
 
 // DemoEmbedding 演示嵌入
@@ -1951,13 +2091,42 @@ func demoBasicConstructors() {
 	}
 }
 
+// UserState 用户状态
+type UserState string
+
+// UserEvent 用户状态事件
+type UserEvent string
+
+const (
+	UserActive   UserState = "active"
+	UserInactive UserState = "inactive"
+
+	UserActivate   UserEvent = "activate"
+	UserDeactivate UserEvent = "deactivate"
+)
+
+// userLifecycle 用户状态机：激活和停用只能交替进行
+var userLifecycle = fsm.MustDefine(fsm.Spec[UserState, UserEvent]{
+	States: []UserState{UserActive, UserInactive},
+	Events: []UserEvent{UserActivate, UserDeactivate},
+	Transitions: []fsm.Transition[UserState, UserEvent]{
+		{From: UserInactive, Event: UserActivate, To: UserActive},
+		{From: UserActive, Event: UserDeactivate, To: UserInactive},
+	},
+})
+
 // User 用户结构体
+//
+// 用户状态由状态机管理并受锁保护，可以被多个 goroutine 同时读取和修改；
+// 零值 User 视为未激活。
 type User struct {
 	ID       int
 	Username string
 	Email    string
 	Age      int
-	Active   bool
+
+	mu    sync.Mutex
+	state *fsm.Machine[UserState, UserEvent] // 由 mu 保护，只在构造函数和 fire 中创建
 }
 
 // NewUser 基本用户构造函数
@@ -1965,7 +2134,7 @@ func NewUser(username, email string) *User {
 	return &User{
 		Username: username,
 		Email:    email,
-		Active:   true, // 默认激活
+		state:    userLifecycle.MustNew(UserActive), // 默认激活
 	}
 }
 
@@ -1979,28 +2148,56 @@ func NewUserWithAge(username, email string, age int) *User {
 // NewInactiveUser 创建非激活用户
 func NewInactiveUser(username, email string) *User {
 	user := NewUser(username, email)
-	user.Active = false
+	user.state = userLifecycle.MustNew(UserInactive)
 	return user
 }
 
 // String 用户字符串表示
 func (u *User) String() string {
 	status := "Active"
-	if !u.Active {
+	if !u.IsActive() {
 		status = "Inactive"
 	}
 	return fmt.Sprintf("User{ID: %d, Username: %s, Email: %s, Age: %d, Status: %s}",
 		u.ID, u.Username, u.Email, u.Age, status)
 }
 
-// Activate 激活用户
+// State 用户当前状态
+func (u *User) State() UserState {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.state == nil {
+		return UserInactive
+	}
+	return u.state.Current()
+}
+
+// IsActive 用户是否已激活
+func (u *User) IsActive() bool {
+	return u.State() == UserActive
+}
+
+// Activate 激活用户，已激活时什么也不做
 func (u *User) Activate() {
-	u.Active = true
+	u.fire(UserActivate)
 }
 
-// Deactivate 停用用户
+// Deactivate 停用用户，已停用时什么也不做
 func (u *User) Deactivate() {
-	u.Active = false
+	u.fire(UserDeactivate)
+}
+
+// fire 触发状态事件，当前状态不能响应时忽略；
+// 用户状态机没有守卫和钩子，能响应的事件不会失败
+func (u *User) fire(e UserEvent) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.state == nil {
+		u.state = userLifecycle.MustNew(UserInactive)
+	}
+	if u.state.Can(e) {
+		u.state.Fire(context.Background(), e)
+	}
 }
 
 // demoParameterizedConstructors 演示带参数的构造函数
@@ -2028,310 +2225,11 @@ func demoParameterizedConstructors() {
 
 	user1.Deactivate()
 	fmt.Printf("停用后: %s\n", user1.String())
-}
-
-// Product 产品结构体
-type Product struct {
-	ID          int
-	Name        string
-	Category    string
-	Price       float64
-	InStock     bool
-	Description string
-}
-
-// ProductType 产品类型
-type ProductType int
-
-const (
-	Electronics ProductType = iota
-	Books
-	Clothing
-	Food
-)
-
-// CreateProduct 工厂函数 - 根据类型创建产品
-func CreateProduct(productType ProductType, name string, price float64) *Product {
-	product := &Product{
-		Name:    name,
-		Price:   price,
-		InStock: true,
-	}
-
-	switch productType {
-	case Electronics:
-		product.Category = "Electronics"
-		product.Description = "Electronic device"
-	case Books:
-		product.Category = "Books"
-		product.Description = "Book or publication"
-	case Clothing:
-		product.Category = "Clothing"
-		product.Description = "Clothing item"
-	case Food:
-		product.Category = "Food"
-		product.Description = "Food item"
-	default:
-		product.Category = "Unknown"
-		product.Description = "Unknown product type"
-	}
-
-	return product
-}
-
-// CreateElectronics 创建电子产品
-func CreateElectronics(name string, price float64) *Product {
-	return CreateProduct(Electronics, name, price)
-}
-
-// CreateBook 创建书籍产品
-func CreateBook(name string, price float64) *Product {
-	return CreateProduct(Books, name, price)
-}
-
-// String 产品字符串表示
-func (p *Product) String() string {
-	stock := "In Stock"
-	if !p.InStock {
-		stock = "Out of Stock"
-	}
-	return fmt.Sprintf("Product{ID: %d, Name: %s, Category: %s, Price: %.2f, %s}",
-		p.ID, p.Name, p.Category, p.Price, stock)
-}
-
-// demoFactoryFunctions 演示工厂函数
-func demoFactoryFunctions() {
-	// 1. 使用通用工厂函数
-	laptop := CreateProduct(Electronics, "Gaming Laptop", 1299.99)
-	laptop.ID = 1
-	fmt.Printf("电子产品: %s\n", laptop.String())
-
-	book := CreateProduct(Books, "Go Programming Guide", 49.99)
-	book.ID = 2
-	fmt.Printf("书籍产品: %s\n", book.String())
-
-	// 2. 使用专门的工厂函数
-	phone := CreateElectronics("Smartphone", 699.99)
-	phone.ID = 3
-	fmt.Printf("专门工厂(电子): %s\n", phone.String())
-
-	novel := CreateBook("Science Fiction Novel", 19.99)
-	novel.ID = 4
-	fmt.Printf("专门工厂(书籍): %s\n", novel.String())
-
-	// 3. 批量创建
-	products := []*Product{
-		CreateProduct(Clothing, "T-Shirt", 25.99),
-		CreateProduct(Food, "Organic Apple", 3.99),
-		CreateElectronics("Tablet", 399.99),
-		CreateBook("Cookbook", 29.99),
-	}
-
-	fmt.Println("\n批量创建的产品:")
-	for i, product := range products {
-		product.ID = i + 5
-		fmt.Printf("  %s\n", product.String())
-	}
-}
-
-// Server 服务器结构体
-type Server struct {
-	Host    string
-	Port    int
-	Timeout int
-	SSL     bool
-	Debug   bool
-}
-
-// ServerOption 服务器选项函数类型
-type ServerOption func(*Server)
-
-// WithPort 设置端口选项
-func WithPort(port int) ServerOption {
-	return func(s *Server) {
-		s.Port = port
-	}
-}
-
-// WithTimeout 设置超时选项
-func WithTimeout(timeout int) ServerOption {
-	return func(s *Server) {
-		s.Timeout = timeout
-	}
-}
-
-// WithSSL 启用SSL选项
-func WithSSL() ServerOption {
-	return func(s *Server) {
-		s.SSL = true
-	}
-}
-
-// WithDebug 启用调试选项
-func WithDebug() ServerOption {
-	return func(s *Server) {
-		s.Debug = true
-	}
-}
-
-// NewServer 创建服务器（选项模式）
-func NewServer(host string, options ...ServerOption) *Server {
-	// 默认配置
-	server := &Server{
-		Host:    host,
-		Port:    8080,
-		Timeout: 30,
-		SSL:     false,
-		Debug:   false,
-	}
-
-	// 应用选项
-	for _, option := range options {
-		option(server)
-	}
-
-	return server
-}
-
-// String 服务器字符串表示
-func (s *Server) String() string {
-	return fmt.Sprintf("Server{Host: %s, Port: %d, Timeout: %ds, SSL: %t, Debug: %t}",
-		s.Host, s.Port, s.Timeout, s.SSL, s.Debug)
-}
-
-// Start 启动服务器
-func (s *Server) Start() {
-	fmt.Printf("启动服务器: %s\n", s.String())
-}
-
-// demoConstructorOptions 演示构造函数选项模式
-func demoConstructorOptions() {
-	// 1. 使用默认配置
-	server1 := NewServer("localhost")
-	fmt.Printf("默认配置: %s\n", server1.String())
-
-	// 2. 使用部分选项
-	server2 := NewServer("example.com", WithPort(443), WithSSL())
-	fmt.Printf("部分选项: %s\n", server2.String())
-
-	// 3. 使用所有选项
-	server3 := NewServer("api.example.com",
-		WithPort(9000),
-		WithTimeout(60),
-		WithSSL(),
-		WithDebug())
-	fmt.Printf("所有选项: %s\n", server3.String())
-
-	// 4. 动态选项
-	options := []ServerOption{WithPort(8443)}
-	if true { // 某种条件
-		options = append(options, WithSSL(), WithDebug())
-	}
 
-	server4 := NewServer("dynamic.example.com", options...)
-	fmt.Printf("动态选项: %s\n", server4.String())
-
-	// 5. 启动服务器
-	fmt.Println("\n启动服务器:")
-	server1.Start()
-	server2.Start()
-}
-
-// Database 数据库单例
-type Database struct {
-	connectionString string
-	connected        bool
-}
-
-var (
-	dbInstance *Database
-	dbOnce     bool // 简化的once实现
-)
-
-// GetDatabase 获取数据库单例
-func GetDatabase() *Database {
-	if !dbOnce {
-		dbInstance = &Database{
-			connectionString: "localhost:5432/myapp",
-			connected:        false,
-		}
-		dbOnce = true
-	}
-	return dbInstance
-}
-
-// Connect 连接数据库
-func (db *Database) Connect() {
-	if !db.connected {
-		db.connected = true
-		fmt.Printf("连接到数据库: %s\n", db.connectionString)
-	} else {
-		fmt.Println("数据库已连接")
-	}
-}
-
-// Disconnect 断开数据库连接
-func (db *Database) Disconnect() {
-	if db.connected {
-		db.connected = false
-		fmt.Println("断开数据库连接")
-	} else {
-		fmt.Println("数据库未连接")
-	}
-}
-
-// IsConnected 检查连接状态
-func (db *Database) IsConnected() bool {
-	return db.connected
-}
-
-// String 数据库字符串表示
-func (db *Database) String() string {
-	status := "disconnected"
-	if db.connected {
-		status = "connected"
-	}
-	return fmt.Sprintf("Database{%s, %s}", db.connectionString, status)
-}
-
-// demoSingletonPattern 演示单例模式
-func demoSingletonPattern() {
-	// 1. 获取数据库实例
-	db1 := GetDatabase()
-	fmt.Printf("第一个实例: %s\n", db1.String())
-
-	// 2. 再次获取实例（应该是同一个）
-	db2 := GetDatabase()
-	fmt.Printf("第二个实例: %s\n", db2.String())
-
-	// 3. 验证是同一个实例
-	fmt.Printf("是同一个实例: %t\n", db1 == db2)
-
-	// 4. 操作数据库
-	fmt.Println("\n数据库操作:")
-	db1.Connect()
-	fmt.Printf("db1连接状态: %t\n", db1.IsConnected())
-	fmt.Printf("db2连接状态: %t\n", db2.IsConnected())
-
-	db2.Disconnect()
-	fmt.Printf("db1连接状态: %t\n", db1.IsConnected())
-	fmt.Printf("db2连接状态: %t\n", db2.IsConnected())
-
-	// 5. 多次获取实例
-	instances := make([]*Database, 5)
-	for i := 0; i < 5; i++ {
-		instances[i] = GetDatabase()
-	}
-
-	fmt.Println("\n多个实例验证:")
-	allSame := true
-	for i := 1; i < len(instances); i++ {
-		if instances[i] != instances[0] {
-			allSame = false
-			break
-		}
-	}
-	fmt.Printf("所有实例都相同: %t\n", allSame)
+	// 重复停用不会改变状态
+	user1.Deactivate()
+	fmt.Printf("重复停用: %s\n", user1.State())
+	fmt.Print(userLifecycle.DOT("user"))
 }
 
 // Animal 动物基础结构体
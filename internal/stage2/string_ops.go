@@ -2,8 +2,37 @@ package stage2
 
 import (
 	"fmt"
+
+	"github.com/howard/go.study/pkg/search"
 )
 
+// demoStringSearch 演示子串查找算法
+func demoStringSearch() {
+	text := "她卖海螺，海边卖海螺，卖的海螺是海螺"
+
+	// 按 rune 查找，下标是字符位置而不是字节位置
+	runes := []rune(text)
+	pattern := []rune("海螺")
+	fmt.Printf("文本: %s\n", text)
+	fmt.Printf("KMP 查找 %q: %v\n", string(pattern), search.NewKMP(pattern).IndexAll(runes))
+	fmt.Printf("Horspool 查找 %q: %d\n", string(pattern), search.NewHorspool(pattern).Index(runes))
+	fmt.Printf("Rabin-Karp 查找 %q: %v\n", "卖", search.NewRabinKarp([]rune("卖")).IndexAll(runes))
+
+	// 多模式查找：一次扫描找出所有关键词
+	keywords := []string{"海", "海螺", "海边", "卖"}
+	patterns := make([][]rune, len(keywords))
+	for i, k := range keywords {
+		patterns[i] = []rune(k)
+	}
+	ac := search.NewAhoCorasick(patterns...)
+	counts := make(map[string]int)
+	for _, m := range ac.FindAll(runes) {
+		counts[keywords[m.Pattern]]++
+	}
+	fmt.Printf("Aho-Corasick 关键词计数: %v\n", counts)
+	fmt.Printf("字节下标: search.Index = %d\n", search.Index(text, "海边"))
+}
+
 // demoStringConversion 演示字符串转换
 func demoStringConversion() {
 	// 1. 字符串和字节切片转换
@@ -0,0 +1,133 @@
+package stage3
+
+import (
+	"fmt"
+
+	"github.com/howard/go.study/internal/stage2"
+)
+
+// ===== 适配器：连接 stage2 与 stage3 的接口 =====
+
+// 编译期检查：适配器和两边的形状都满足对方的接口
+var (
+	_ Animal       = PetAdapter{}
+	_ Animal       = AnimalFunc{}
+	_ Shape        = Stage2Shape{}
+	_ Shape        = stage2.Circle{} // stage2 的圆自带 String，无需适配
+	_ stage2.Shape = Shape(nil)      // stage3 的形状方法集是 stage2 的超集
+	_ stage2.Pet   = AnimalPet{}
+	_ SortStrategy = SortFunc{}
+)
+
+// WalkingSpeaker stage2 中既会说话又会行走的类型，stage2.Pet 满足此接口
+type WalkingSpeaker interface {
+	stage2.Speaker
+	stage2.Walker
+}
+
+// PetAdapter 把 stage2 的动物适配为 Animal，Move 转调 Walk
+type PetAdapter struct {
+	WalkingSpeaker
+}
+
+// Move 移动
+func (p PetAdapter) Move() string {
+	return p.Walk()
+}
+
+// AdaptPets 把一组 stage2 的宠物适配为 Animal
+func AdaptPets[P WalkingSpeaker](pets ...P) []Animal {
+	return AdaptAll(pets, func(p P) Animal { return PetAdapter{p} })
+}
+
+// AnimalPet 把 Animal 适配为 stage2.Pet，Walk 转调 Move
+type AnimalPet struct {
+	Animal
+}
+
+// Walk 行走
+func (a AnimalPet) Walk() string {
+	return a.Move()
+}
+
+// Play 玩耍，stage3 的动物没有对应方法，用说话代替
+func (a AnimalPet) Play() string {
+	return "plays and " + a.Speak()
+}
+
+// Stage2Shape 把 stage2 的形状适配为 Shape，补上 String 方法
+type Stage2Shape struct {
+	stage2.Shape
+}
+
+// String 形状的字符串表示，原类型实现了 fmt.Stringer 时直接使用
+func (s Stage2Shape) String() string {
+	if str, ok := s.Shape.(fmt.Stringer); ok {
+		return str.String()
+	}
+	return fmt.Sprintf("%T{Area: %.2f}", s.Shape, s.Area())
+}
+
+// FromStage2 把 stage2 的形状转换为 Shape，已经满足 Shape 的不再包装
+func FromStage2(s stage2.Shape) Shape {
+	if shape, ok := s.(Shape); ok {
+		return shape
+	}
+	return Stage2Shape{s}
+}
+
+// ToStage2 把 Shape 转换为 stage2 的形状；方法集是超集，直接赋值即可，
+// 已被 Stage2Shape 包装的会还原为原值
+func ToStage2(s Shape) stage2.Shape {
+	if a, ok := s.(Stage2Shape); ok {
+		return a.Shape
+	}
+	return s
+}
+
+// ===== 函数适配器：把普通函数转换为接口 =====
+
+// AnimalFunc 用两个函数实现 Animal
+type AnimalFunc struct {
+	SpeakFunc func() string
+	MoveFunc  func() string
+}
+
+// Speak 说话
+func (f AnimalFunc) Speak() string { return f.SpeakFunc() }
+
+// Move 移动
+func (f AnimalFunc) Move() string { return f.MoveFunc() }
+
+// SortFunc 用比较函数实现 SortStrategy
+type SortFunc struct {
+	Label string
+	Less  func(a, b Shape) bool
+}
+
+// Sort 稳定排序，返回新切片
+func (s SortFunc) Sort(shapes []Shape) []Shape {
+	return sortShapes(shapes, func(a, b Shape) int {
+		switch {
+		case s.Less(a, b):
+			return -1
+		case s.Less(b, a):
+			return 1
+		}
+		return 0
+	})
+}
+
+// Name 策略名称
+func (s SortFunc) Name() string {
+	return s.Label
+}
+
+// AdaptAll 逐个转换切片元素，用于把一组值批量适配为另一种接口
+func AdaptAll[From, To any](items []From, adapt func(From) To) []To {
+	out := make([]To, len(items))
+	for i, item := range items {
+		out[i] = adapt(item)
+	}
+	return out
+}
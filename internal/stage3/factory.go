@@ -0,0 +1,327 @@
+package stage3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrUnknownShape 未注册的形状种类
+	ErrUnknownShape = errors.New("stage3: 未知的形状")
+	// ErrShapeParam 形状参数缺失、多余或取值不合法
+	ErrShapeParam = errors.New("stage3: 形状参数错误")
+	// ErrShapeSpec 形状描述的格式错误
+	ErrShapeSpec = errors.New("stage3: 形状描述格式错误")
+	// ErrDuplicateShape 重复注册同名形状
+	ErrDuplicateShape = errors.New("stage3: 形状已注册")
+)
+
+// ShapeParam 形状的一个参数
+type ShapeParam struct {
+	Name     string   // 参数名，例如 radius
+	Aliases  []string // 简写，例如 r
+	Optional bool     // 可省略，省略时取 Default
+	Default  float64
+}
+
+// ShapeKind 一种形状的构造方式
+//
+// New 收到的参数与 Params 一一对应，已经补齐了可省略参数的默认值，
+// 并保证都是有限的数。
+type ShapeKind struct {
+	Name   string
+	Params []ShapeParam
+	New    func(args []float64) (Shape, error)
+}
+
+// lookup 按名称或简写查找参数的下标
+func (k ShapeKind) lookup(name string) (int, bool) {
+	for i, p := range k.Params {
+		if strings.EqualFold(p.Name, name) || slices.ContainsFunc(p.Aliases, func(a string) bool { return strings.EqualFold(a, name) }) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// build 检查参数并构造形状，set[i] 表示第 i 个参数是否给出
+func (k ShapeKind) build(args []float64, set []bool) (Shape, error) {
+	for i, p := range k.Params {
+		if !set[i] {
+			if !p.Optional {
+				return nil, fmt.Errorf("%w: %s 缺少参数 %s", ErrShapeParam, k.Name, p.Name)
+			}
+			args[i] = p.Default
+		}
+		if math.IsNaN(args[i]) || math.IsInf(args[i], 0) {
+			return nil, fmt.Errorf("%w: %s 的参数 %s 不是有限的数", ErrShapeParam, k.Name, p.Name)
+		}
+	}
+	return k.New(args)
+}
+
+// positive 检查参数为正数，供内置形状使用
+func positive(kind string, names []string, args []float64) error {
+	for i, name := range names {
+		if args[i] <= 0 {
+			return fmt.Errorf("%w: %s 的 %s 必须为正数，实际为 %g", ErrShapeParam, kind, name, args[i])
+		}
+	}
+	return nil
+}
+
+// builtinShapes 内置形状，顺序与 ShapeType 常量一致
+var builtinShapes = []ShapeKind{
+	RectangleType: {
+		Name:   "rectangle",
+		Params: []ShapeParam{{Name: "width", Aliases: []string{"w"}}, {Name: "height", Aliases: []string{"h"}}},
+		New: func(args []float64) (Shape, error) {
+			if err := positive("rectangle", []string{"width", "height"}, args); err != nil {
+				return nil, err
+			}
+			return Rectangle{Width: args[0], Height: args[1]}, nil
+		},
+	},
+	CircleType: {
+		Name:   "circle",
+		Params: []ShapeParam{{Name: "radius", Aliases: []string{"r"}}},
+		New: func(args []float64) (Shape, error) {
+			if err := positive("circle", []string{"radius"}, args); err != nil {
+				return nil, err
+			}
+			return Circle{Radius: args[0]}, nil
+		},
+	},
+	TriangleType: {
+		Name: "triangle",
+		Params: []ShapeParam{
+			{Name: "base", Aliases: []string{"b"}},
+			{Name: "height", Aliases: []string{"h"}},
+			{Name: "offset", Aliases: []string{"o"}, Optional: true},
+		},
+		New: func(args []float64) (Shape, error) {
+			if err := positive("triangle", []string{"base", "height"}, args); err != nil {
+				return nil, err
+			}
+			return Triangle{Base: args[0], Height: args[1], Offset: args[2]}, nil
+		},
+	},
+}
+
+// ShapeFactory 形状工厂
+//
+// 零值即可使用，已包含矩形、圆和三角形；可以在运行时用 Register 注册新的形状。
+// 工厂可以被并发使用，但不能被复制。
+type ShapeFactory struct {
+	mu     sync.Mutex
+	kinds  []ShapeKind
+	byName map[string]ShapeType
+}
+
+// NewShapeFactory 创建形状工厂
+func NewShapeFactory() *ShapeFactory {
+	return &ShapeFactory{}
+}
+
+// init 首次使用时注册内置形状，调用方持有锁
+func (sf *ShapeFactory) init() {
+	if sf.byName != nil {
+		return
+	}
+	sf.byName = make(map[string]ShapeType)
+	for _, k := range builtinShapes {
+		sf.byName[k.Name] = ShapeType(len(sf.kinds))
+		sf.kinds = append(sf.kinds, k)
+	}
+}
+
+// Register 注册新的形状种类，返回分配给它的 ShapeType
+func (sf *ShapeFactory) Register(kind ShapeKind) (ShapeType, error) {
+	name := strings.ToLower(kind.Name)
+	if name == "" || strings.ContainsAny(name, " \t=") || kind.New == nil {
+		return 0, fmt.Errorf("%w: 形状需要不含空白的名称和构造函数", ErrShapeSpec)
+	}
+	seen := make(map[string]bool)
+	for _, p := range kind.Params {
+		for _, n := range append([]string{p.Name}, p.Aliases...) {
+			if seen[strings.ToLower(n)] {
+				return 0, fmt.Errorf("%w: %s 的参数名 %s 重复", ErrShapeSpec, name, n)
+			}
+			seen[strings.ToLower(n)] = true
+		}
+	}
+
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sf.init()
+	if _, ok := sf.byName[name]; ok {
+		return 0, fmt.Errorf("%w: %s", ErrDuplicateShape, name)
+	}
+	kind.Name = name
+	t := ShapeType(len(sf.kinds))
+	sf.kinds = append(sf.kinds, kind)
+	sf.byName[name] = t
+	return t, nil
+}
+
+// Kinds 已注册的形状名称，按注册顺序
+func (sf *ShapeFactory) Kinds() []string {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sf.init()
+	return sf.names()
+}
+
+// kind 按类型查找形状种类
+func (sf *ShapeFactory) kind(t ShapeType) (ShapeKind, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sf.init()
+	if t < 0 || int(t) >= len(sf.kinds) {
+		return ShapeKind{}, fmt.Errorf("%w: 类型 %d", ErrUnknownShape, t)
+	}
+	return sf.kinds[t], nil
+}
+
+// kindByName 按名称查找形状种类
+func (sf *ShapeFactory) kindByName(name string) (ShapeKind, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sf.init()
+	t, ok := sf.byName[strings.ToLower(name)]
+	if !ok {
+		return ShapeKind{}, fmt.Errorf("%w: %q（可用: %s）", ErrUnknownShape, name, strings.Join(sf.names(), ", "))
+	}
+	return sf.kinds[t], nil
+}
+
+func (sf *ShapeFactory) names() []string {
+	names := make([]string, len(sf.kinds))
+	for i, k := range sf.kinds {
+		names[i] = k.Name
+	}
+	return names
+}
+
+// CreateShape 按位置参数创建形状，可省略的参数可以不给
+func (sf *ShapeFactory) CreateShape(shapeType ShapeType, params ...float64) (Shape, error) {
+	k, err := sf.kind(shapeType)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) > len(k.Params) {
+		return nil, fmt.Errorf("%w: %s 最多 %d 个参数，实际 %d 个", ErrShapeParam, k.Name, len(k.Params), len(params))
+	}
+	args := make([]float64, len(k.Params))
+	set := make([]bool, len(k.Params))
+	for i, v := range params {
+		args[i], set[i] = v, true
+	}
+	return k.build(args, set)
+}
+
+// Create 按名称和命名参数创建形状，参数名可以使用简写
+func (sf *ShapeFactory) Create(name string, params map[string]float64) (Shape, error) {
+	k, err := sf.kindByName(name)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]float64, len(k.Params))
+	set := make([]bool, len(k.Params))
+	for key, v := range params {
+		i, ok := k.lookup(key)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s 没有参数 %s", ErrShapeParam, k.Name, key)
+		}
+		if set[i] {
+			return nil, fmt.Errorf("%w: %s 的参数 %s 重复", ErrShapeParam, k.Name, k.Params[i].Name)
+		}
+		args[i], set[i] = v, true
+	}
+	return k.build(args, set)
+}
+
+// Parse 解析形状描述，支持两种格式：
+//
+//	circle r=3
+//	rectangle 4 6                        （位置参数）
+//	triangle base=5 height=4 offset=1
+//	{"type": "triangle", "base": 5, "height": 4}
+func (sf *ShapeFactory) Parse(spec string) (Shape, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "{") {
+		return sf.parseJSON(spec)
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: 空的描述", ErrShapeSpec)
+	}
+	k, err := sf.kindByName(fields[0])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]float64, len(k.Params))
+	set := make([]bool, len(k.Params))
+	named := false
+	for pos, field := range fields[1:] {
+		key, value, isNamed := strings.Cut(field, "=")
+		if !isNamed {
+			value = key
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q 不是数字", ErrShapeSpec, value)
+		}
+
+		var i int
+		switch {
+		case isNamed:
+			var ok bool
+			if i, ok = k.lookup(key); !ok {
+				return nil, fmt.Errorf("%w: %s 没有参数 %s", ErrShapeParam, k.Name, key)
+			}
+			named = true
+		case named:
+			return nil, fmt.Errorf("%w: 位置参数 %q 不能出现在命名参数之后", ErrShapeSpec, field)
+		case pos >= len(k.Params):
+			return nil, fmt.Errorf("%w: %s 最多 %d 个参数", ErrShapeParam, k.Name, len(k.Params))
+		default:
+			i = pos
+		}
+		if set[i] {
+			return nil, fmt.Errorf("%w: %s 的参数 %s 重复", ErrShapeParam, k.Name, k.Params[i].Name)
+		}
+		args[i], set[i] = v, true
+	}
+	return k.build(args, set)
+}
+
+// parseJSON 解析 {"type": ..., 参数...} 形式的描述
+func (sf *ShapeFactory) parseJSON(spec string) (Shape, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(spec), &fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrShapeSpec, err)
+	}
+	var name string
+	if err := json.Unmarshal(fields["type"], &name); err != nil || name == "" {
+		return nil, fmt.Errorf("%w: 缺少字符串字段 type", ErrShapeSpec)
+	}
+	delete(fields, "type")
+
+	params := make(map[string]float64, len(fields))
+	for key, raw := range fields {
+		var v float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("%w: 字段 %s 不是数字", ErrShapeSpec, key)
+		}
+		params[key] = v
+	}
+	return sf.Create(name, params)
+}
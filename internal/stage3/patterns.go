@@ -0,0 +1,300 @@
+package stage3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/howard/go.study/internal/stage2"
+	"github.com/howard/go.study/pkg/chain"
+	"github.com/howard/go.study/pkg/editor"
+	"github.com/howard/go.study/pkg/event"
+	"github.com/howard/go.study/pkg/stream"
+)
+
+// OrderPlaced 下单事件
+type OrderPlaced struct {
+	ID     int
+	Amount float64
+}
+
+// demoObserverPattern 演示基于泛型事件总线的观察者模式
+func demoObserverPattern() {
+	bus := event.New[OrderPlaced]()
+	ctx := context.Background()
+
+	// 1. 优先级：风控最先执行，金额过大时阻止后续处理
+	bus.Subscribe(func(_ context.Context, o OrderPlaced) error {
+		if o.Amount > 10000 {
+			fmt.Printf("  [风控] 订单 %d 金额异常，拦截\n", o.ID)
+			return event.ErrStopPropagation
+		}
+		return nil
+	}, event.WithPriority(100), event.WithName("风控"))
+
+	// 2. 普通订阅者，错误会被收集
+	bus.Subscribe(func(_ context.Context, o OrderPlaced) error {
+		if o.ID%2 == 0 {
+			return errors.New("库存不足")
+		}
+		fmt.Printf("  [库存] 订单 %d 扣减库存\n", o.ID)
+		return nil
+	}, event.WithName("库存"))
+	bus.Subscribe(func(_ context.Context, o OrderPlaced) error {
+		fmt.Printf("  [通知] 订单 %d 已发送确认邮件\n", o.ID)
+		return nil
+	}, event.WithName("通知"))
+
+	// 3. 只接收一次的订阅者
+	bus.Subscribe(func(_ context.Context, o OrderPlaced) error {
+		fmt.Printf("  [营销] 首单 %d 赠送优惠券\n", o.ID)
+		return nil
+	}, event.Once())
+
+	// 4. 处理到一定数量后自行退订
+	audited := 0
+	bus.Subscribe(func(_ context.Context, o OrderPlaced) error {
+		audited++
+		fmt.Printf("  [审计] 抽查订单 %d\n", o.ID)
+		if audited == 2 {
+			return event.ErrUnsubscribe
+		}
+		return nil
+	}, event.WithPriority(-1))
+
+	for _, o := range []OrderPlaced{{1, 99}, {2, 450}, {3, 20000}, {5, 35}} {
+		fmt.Printf("发布订单 %d（%.2f 元）:\n", o.ID, o.Amount)
+		if err := bus.Publish(ctx, o); err != nil {
+			fmt.Printf("  处理失败: %v\n", err)
+		}
+	}
+	fmt.Printf("剩余订阅者: %d\n", bus.Len())
+
+	// 5. 异步投递
+	done := bus.PublishAsync(ctx, OrderPlaced{ID: 7, Amount: 12})
+	if err := <-done; err != nil {
+		fmt.Printf("异步处理失败: %v\n", err)
+	}
+	bus.Close()
+	fmt.Printf("关闭后发布: %v\n", bus.Publish(ctx, OrderPlaced{ID: 8}))
+}
+
+// demoDecoratorPattern 演示 io 流装饰器的叠加
+func demoDecoratorPattern() {
+	logs := strings.Repeat("GET /api/orders 200 12ms\n", 50)
+
+	// 1. 写入方向：加前缀 -> 统计原文 -> 哈希原文 -> 压缩 -> 统计压缩后
+	var archive bytes.Buffer
+	var plain, packed stream.Counter
+	digest := stream.NewHash()
+	w := stream.ChainWriter(&archive,
+		stream.PrefixLines("[web-1] "),
+		stream.CountWriter(&plain),
+		stream.HashWriter(digest),
+		stream.GzipWriter(gzip.BestCompression),
+		stream.CountWriter(&packed),
+	)
+	io.WriteString(w, logs)
+	w.Close()
+	fmt.Printf("原文 %d 字节，压缩后 %d 字节，SHA-256 %s...\n", plain.N(), packed.N(), digest.Hex()[:16])
+
+	// 2. 读取方向：限速 -> 进度 -> 解压 -> 校验
+	check := stream.NewHash()
+	limiter, err := stream.NewLimiter(1<<20, 64<<10)
+	if err != nil {
+		fmt.Printf("创建限速器失败: %v\n", err)
+		return
+	}
+	r := stream.ChainReader(&archive,
+		stream.HashReader(check),
+		stream.GunzipReader(),
+		stream.ProgressReader(int64(archive.Len()), func(done, total int64) {
+			fmt.Printf("  已读取 %d/%d 字节\n", done, total)
+		}),
+		stream.RateLimitReader(limiter),
+	)
+	restored, err := io.ReadAll(r)
+	if err != nil {
+		fmt.Printf("读取失败: %v\n", err)
+		return
+	}
+	fmt.Printf("解压后 %d 字节，摘要一致: %t\n", len(restored), check.Hex() == digest.Hex())
+
+	// 3. 顺序不同，结果不同：先压缩再加前缀会破坏 gzip 数据
+	var broken bytes.Buffer
+	w = stream.ChainWriter(&broken, stream.GzipWriter(gzip.BestSpeed), stream.PrefixLines("[web-1] "))
+	io.WriteString(w, "hello\n")
+	w.Close()
+	_, err = io.ReadAll(stream.ChainReader(&broken, stream.GunzipReader()))
+	fmt.Printf("先压缩后加前缀再解压: %v\n", err)
+
+	// 4. 直接装饰标准输出
+	out := stream.ChainWriter(os.Stdout, stream.PrefixLines("  | "))
+	fmt.Fprint(out, "装饰后的标准输出\n第二行\n")
+	out.Close()
+}
+
+// demoCommandPattern 演示可撤销的编辑命令
+func demoCommandPattern() {
+	history := editor.NewHistory(editor.NewGapBuffer(""))
+	show := func(action string) {
+		fmt.Printf("  %-12s %q\n", action, history.Buffer().String())
+	}
+
+	// 1. 逐字输入会合并为一条命令，换行开始新的一条
+	pos := 0
+	for _, r := range "Hello, 世界\nGo" {
+		history.Do(&editor.Insert{Pos: pos, Text: string(r)})
+		pos++
+	}
+	show("输入")
+
+	// 2. 宏命令：替换作为一个整体撤销
+	history.Do(editor.Replace(7, 2, "Gopher"))
+	show("替换")
+
+	// 3. 撤销与重做
+	for history.CanUndo() {
+		history.Undo()
+		show("撤销")
+	}
+	history.Redo()
+	show("重做")
+	if err := history.Redo(); err == nil {
+		show("重做")
+	}
+
+	// 4. 行编辑器：同样的命令机制按行操作
+	var out strings.Builder
+	ed := editor.NewLineEditor("package main\n\nfunc main() {\n}\n", &out)
+	for _, cmd := range []string{"a 3 \tprintln(\"hi\")", "s 4 hi 你好", "d 2", "u", "p"} {
+		if err := ed.Exec(cmd); err != nil {
+			fmt.Printf("  %s: %v\n", cmd, err)
+		}
+	}
+	fmt.Print(out.String())
+}
+
+// demoChainOfResponsibility 演示请求处理链
+func demoChainOfResponsibility() {
+	tokens := chain.NewTokenTable(map[string]string{"secret": "alice"})
+	var audit []chain.AuditEntry
+	handlers := []chain.Handler{
+		chain.Audit(func(e chain.AuditEntry) { audit = append(audit, e) }),
+		chain.Auth(tokens),
+		chain.Validate(chain.AllowMethods(http.MethodGet, http.MethodPost), chain.MaxBodySize(16)),
+		chain.Cache(time.Minute),
+	}
+
+	// 1. 进程内使用：末端是普通函数
+	c := chain.New(func(req *chain.Request) (*chain.Response, error) {
+		user, _ := chain.User(req)
+		return chain.NewResponse(http.StatusOK, []byte("你好，"+user)), nil
+	}, handlers...)
+
+	requests := []struct {
+		method, token, body string
+	}{
+		{http.MethodGet, "secret", ""},
+		{http.MethodGet, "secret", ""},
+		{http.MethodGet, "wrong", ""},
+		{http.MethodDelete, "secret", ""},
+		{http.MethodPost, "secret", strings.Repeat("x", 20)},
+	}
+	for _, r := range requests {
+		req := chain.NewRequest(context.Background(), r.method, "/hello", []byte(r.body))
+		req.Header.Set("Authorization", "Bearer "+r.token)
+		resp, err := c.Serve(req)
+		if err != nil {
+			fmt.Printf("  %-6s 短路: %v\n", r.method, err)
+			continue
+		}
+		fmt.Printf("  %-6s %d %s (X-Cache: %s)\n", r.method, resp.Status, resp.Body, resp.Header.Get("X-Cache"))
+	}
+
+	// 2. 同样的处理器作为 net/http 中间件（Cache 是同一个实例，会命中上面缓存的响应）
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		req, _ := chain.FromContext(r.Context())
+		user, _ := chain.User(req)
+		fmt.Fprintf(w, "HTTP 你好，%s", user)
+	})
+	h := chain.Middleware(handlers...)(mux)
+	for _, token := range []string{"secret", ""} {
+		r := httptest.NewRequest(http.MethodGet, "/hello", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		fmt.Printf("  HTTP   %d %s", w.Code, w.Body)
+		if w.Body.Len() > 0 && !strings.HasSuffix(w.Body.String(), "\n") {
+			fmt.Println()
+		}
+	}
+
+	// 3. 审计处理器在链首，记录了包括被拒绝在内的所有请求
+	fmt.Println("  审计记录:")
+	for _, e := range audit {
+		user := e.User
+		if user == "" {
+			user = "-"
+		}
+		fmt.Printf("    %-5s %-6s %s %d\n", user, e.Method, e.Path, e.Status)
+	}
+}
+
+// demoAdapterPattern 演示适配器模式
+func demoAdapterPattern() {
+	// 1. stage2 的宠物作为 stage3 的 Animal
+	animals := append(
+		AdaptPets[stage2.Pet](
+			&stage2.Dog{Animal: stage2.Animal{Name: "Rex"}},
+			&stage2.Robot{Name: "R2"},
+		),
+		Bird{Name: "Tweety"},
+		AnimalFunc{
+			SpeakFunc: func() string { return "Nemo says: Blub!" },
+			MoveFunc:  func() string { return "Nemo swims" },
+		},
+	)
+	for _, a := range animals {
+		fmt.Printf("  %-28s %s\n", a.Speak(), a.Move())
+	}
+
+	// 2. 反向：stage3 的动物作为 stage2 的 Pet
+	var pet stage2.Pet = AnimalPet{Cat{Name: "Tom"}}
+	fmt.Printf("  %s, %s\n", pet.Walk(), pet.Play())
+
+	// 3. 两个包的形状混在一起
+	shapes := []Shape{
+		Rectangle{Width: 2, Height: 3},
+		FromStage2(stage2.Rectangle{TopLeft: stage2.Point{X: 0, Y: 4}, BottomRight: stage2.Point{X: 5, Y: 0}}),
+		FromStage2(stage2.Circle{Radius: 1}),
+	}
+	byArea := SortFunc{Label: "按面积", Less: func(a, b Shape) bool { return a.Area() < b.Area() }}
+	fmt.Printf("  %s排序:\n", byArea.Name())
+	for _, s := range byArea.Sort(shapes) {
+		fmt.Printf("    %-40s 面积 %6.2f\n", s, s.Area())
+	}
+	var total float64
+	for _, s := range AdaptAll(shapes, ToStage2) {
+		total += s.Perimeter()
+	}
+	fmt.Printf("  作为 stage2.Shape 的总周长: %.2f\n", total)
+
+	// 4. 函数适配为处理器
+	runes := ProcessorFunc[string, int](func(_ context.Context, s string) (int, error) {
+		return len([]rune(s)), nil
+	})
+	n, _ := runes.Process(context.Background(), "你好")
+	fmt.Printf("  %s: %d\n", runes.Name(), n)
+}
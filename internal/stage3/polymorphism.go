@@ -1,6 +1,15 @@
 package stage3
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/howard/go.study/internal/stage2"
+	"github.com/howard/go.study/pkg/geometry"
+)
 
 // ===== 多态演示 =====
 
@@ -85,40 +94,47 @@ func demoBasicPolymorphism() {
 
 // demoPolymorphicSlice 演示接口切片多态
 func demoPolymorphicSlice() {
-	// 1. 混合类型的切片
+	// 1. 混合类型的切片，包括一个适配过来的 stage2 形状
 	shapes := []Shape{
 		Rectangle{Width: 5, Height: 3},
 		Circle{Radius: 4},
 		Rectangle{Width: 2, Height: 8},
 		Circle{Radius: 2.5},
+		Triangle{Base: 6, Height: 4},
+		FromStage2(stage2.Rectangle{TopLeft: stage2.Point{X: 0, Y: 2}, BottomRight: stage2.Point{X: 3, Y: 0}}),
 	}
-	
-	// 2. 统一处理
+
+	// 2. 逐个访问
 	fmt.Println("形状统计:")
-	totalArea := 0.0
-	totalPerimeter := 0.0
-	
-	for i, shape := range shapes {
-		area := shape.Area()
-		perimeter := shape.Perimeter()
-		
-		fmt.Printf("形状 %d: %s\n", i+1, shape.String())
-		fmt.Printf("  面积: %.2f, 周长: %.2f\n", area, perimeter)
-		
-		totalArea += area
-		totalPerimeter += perimeter
-	}
-	
-	fmt.Printf("\n总面积: %.2f\n", totalArea)
-	fmt.Printf("总周长: %.2f\n", totalPerimeter)
-	
-	// 3. 过滤和分类
+	i := 0
+	Walk(ShapeFuncVisitor(func(shape Shape) {
+		i++
+		fmt.Printf("形状 %d: %s\n", i, shape.String())
+		fmt.Printf("  面积: %.2f, 周长: %.2f\n", shape.Area(), shape.Perimeter())
+	}), shapes...)
+
+	// 3. 可复用的访问者：每种分析一个访问者，形状类型无需改动
+	total := Walk(&TotalArea{}, shapes...)
+	fmt.Printf("\n总面积: %.2f\n", total.Area)
+	fmt.Printf("总周长: %.2f\n", total.Perimeter)
+
+	fmt.Println("\n类型分布:")
+	fmt.Print(Walk(&TypeHistogram{}, shapes...))
+
+	largest := Walk(&Largest{}, shapes...)
+	widest := Walk(&Largest{By: ByPerimeter}, shapes...)
+	fmt.Printf("\n面积最大: %s\n周长最大: %s\n", largest.Shape, widest.Shape)
+
+	data, _ := json.Marshal(Walk(&JSONVisitor{}, shapes...))
+	fmt.Printf("\nJSON: %s\n", data)
+
+	// 4. 过滤
 	fmt.Println("\n大面积形状 (面积 > 20):")
-	for _, shape := range shapes {
+	Walk(ShapeFuncVisitor(func(shape Shape) {
 		if shape.Area() > 20 {
 			fmt.Printf("  %s, 面积: %.2f\n", shape.String(), shape.Area())
 		}
-	}
+	}), shapes...)
 }
 
 // ShapeType 形状类型
@@ -131,86 +147,117 @@ const (
 )
 
 // Triangle 三角形
+//
+// 底边从 (0, 0) 到 (Base, 0)，顶点位于 (Base/2+Offset, Height)。
+// Offset 为零时是等腰三角形。
 type Triangle struct {
 	Base   float64
 	Height float64
+	Offset float64 // 顶点相对底边中点的水平偏移
+}
+
+// NewTriangle 由三个顶点创建三角形，以 a、b 为底边
+func NewTriangle(a, b, c geometry.Point) Triangle {
+	base := b.Sub(a)
+	length := base.Len()
+	if length == 0 {
+		return Triangle{}
+	}
+	ac := c.Sub(a)
+	return Triangle{
+		Base:   length,
+		Height: math.Abs(base.Cross(ac)) / length,
+		Offset: base.Dot(ac)/length - length/2,
+	}
+}
+
+// Polygon 三角形的三个顶点
+func (t Triangle) Polygon() geometry.Polygon {
+	return geometry.Polygon{
+		geometry.Pt(0, 0),
+		geometry.Pt(t.Base, 0),
+		geometry.Pt(t.Base/2+t.Offset, t.Height),
+	}
+}
+
+// Transform 仿射变换，结果为多边形
+func (t Triangle) Transform(tr geometry.Transform) geometry.Shape {
+	return tr.Polygon(t.Polygon())
 }
 
 // Area 计算三角形面积
 func (t Triangle) Area() float64 {
-	return 0.5 * t.Base * t.Height
+	return t.Polygon().Area()
 }
 
-// Perimeter 计算三角形周长（简化为等腰三角形）
+// Perimeter 计算三角形周长
 func (t Triangle) Perimeter() float64 {
-	side := (t.Base*t.Base + t.Height*t.Height) / (2 * t.Base)
-	return t.Base + 2*side
+	return t.Polygon().Perimeter()
 }
 
 // String 三角形字符串表示
 func (t Triangle) String() string {
-	return fmt.Sprintf("Triangle{Base: %.2f, Height: %.2f}", t.Base, t.Height)
-}
-
-// ShapeFactory 形状工厂
-type ShapeFactory struct{}
-
-// CreateShape 创建形状
-func (sf ShapeFactory) CreateShape(shapeType ShapeType, params ...float64) Shape {
-	switch shapeType {
-	case RectangleType:
-		if len(params) >= 2 {
-			return Rectangle{Width: params[0], Height: params[1]}
-		}
-		return Rectangle{Width: 1, Height: 1}
-	case CircleType:
-		if len(params) >= 1 {
-			return Circle{Radius: params[0]}
-		}
-		return Circle{Radius: 1}
-	case TriangleType:
-		if len(params) >= 2 {
-			return Triangle{Base: params[0], Height: params[1]}
-		}
-		return Triangle{Base: 1, Height: 1}
-	default:
-		return Rectangle{Width: 1, Height: 1}
+	if t.Offset != 0 {
+		return fmt.Sprintf("Triangle{Base: %.2f, Height: %.2f, Offset: %.2f}", t.Base, t.Height, t.Offset)
 	}
+	return fmt.Sprintf("Triangle{Base: %.2f, Height: %.2f}", t.Base, t.Height)
 }
 
 // demoPolymorphicFactory 演示多态工厂模式
 func demoPolymorphicFactory() {
-	factory := ShapeFactory{}
-	
-	// 1. 使用工厂创建不同形状
-	shapes := []Shape{
-		factory.CreateShape(RectangleType, 4, 6),
-		factory.CreateShape(CircleType, 3),
-		factory.CreateShape(TriangleType, 5, 4),
-		factory.CreateShape(RectangleType, 2, 2),
-	}
-	
+	factory := NewShapeFactory()
+
+	// 1. 按类型和位置参数创建
 	fmt.Println("工厂创建的形状:")
-	for i, shape := range shapes {
-		fmt.Printf("形状 %d: %s\n", i+1, shape.String())
-		fmt.Printf("  面积: %.2f, 周长: %.2f\n", shape.Area(), shape.Perimeter())
-	}
-	
-	// 2. 批量创建
-	shapeConfigs := []struct {
+	for _, c := range []struct {
 		shapeType ShapeType
 		params    []float64
 	}{
-		{RectangleType, []float64{3, 5}},
-		{CircleType, []float64{2.5}},
-		{TriangleType, []float64{6, 3}},
+		{RectangleType, []float64{4, 6}},
+		{CircleType, []float64{3}},
+		{TriangleType, []float64{5, 4}},
+		{CircleType, nil},
+	} {
+		shape, err := factory.CreateShape(c.shapeType, c.params...)
+		if err != nil {
+			fmt.Printf("  创建失败: %v\n", err)
+			continue
+		}
+		fmt.Printf("  %s, 面积: %.2f, 周长: %.2f\n", shape, shape.Area(), shape.Perimeter())
 	}
-	
-	fmt.Println("\n批量创建形状:")
-	for i, config := range shapeConfigs {
-		shape := factory.CreateShape(config.shapeType, config.params...)
-		fmt.Printf("批量形状 %d: %s, 面积: %.2f\n", 
-			i+1, shape.String(), shape.Area())
+
+	// 2. 运行时注册新的形状
+	factory.Register(ShapeKind{
+		Name:   "square",
+		Params: []ShapeParam{{Name: "side", Aliases: []string{"s"}}},
+		New: func(args []float64) (Shape, error) {
+			if args[0] <= 0 {
+				return nil, fmt.Errorf("%w: 边长必须为正数", ErrShapeParam)
+			}
+			return Rectangle{Width: args[0], Height: args[0]}, nil
+		},
+	})
+	fmt.Printf("\n已注册的形状: %s\n", strings.Join(factory.Kinds(), ", "))
+
+	// 3. 解析文本和 JSON 描述
+	fmt.Println("\n解析形状描述:")
+	for _, spec := range []string{
+		"circle r=3",
+		"rectangle 3 5",
+		"triangle base=6 h=3 offset=-1",
+		`{"type": "triangle", "base": 5, "height": 4}`,
+		"square s=2",
+		"hexagon side=1",
+		"circle r=-1",
+		"rectangle w=2",
+		`{"type": "circle", "radius": "big"}`,
+	} {
+		shape, err := factory.Parse(spec)
+		if err != nil {
+			fmt.Printf("  %-46s 错误: %v\n", spec, err)
+			continue
+		}
+		fmt.Printf("  %-46s %s, 面积: %.2f\n", spec, shape, shape.Area())
 	}
 }
 
@@ -225,19 +272,7 @@ type AreaSortStrategy struct{}
 
 // Sort 按面积排序
 func (ass AreaSortStrategy) Sort(shapes []Shape) []Shape {
-	// 简单的冒泡排序
-	result := make([]Shape, len(shapes))
-	copy(result, shapes)
-	
-	for i := 0; i < len(result); i++ {
-		for j := 0; j < len(result)-1-i; j++ {
-			if result[j].Area() > result[j+1].Area() {
-				result[j], result[j+1] = result[j+1], result[j]
-			}
-		}
-	}
-	
-	return result
+	return sortShapes(shapes, ByArea)
 }
 
 // Name 策略名称
@@ -250,18 +285,7 @@ type PerimeterSortStrategy struct{}
 
 // Sort 按周长排序
 func (pss PerimeterSortStrategy) Sort(shapes []Shape) []Shape {
-	result := make([]Shape, len(shapes))
-	copy(result, shapes)
-	
-	for i := 0; i < len(result); i++ {
-		for j := 0; j < len(result)-1-i; j++ {
-			if result[j].Perimeter() > result[j+1].Perimeter() {
-				result[j], result[j+1] = result[j+1], result[j]
-			}
-		}
-	}
-	
-	return result
+	return sortShapes(shapes, ByPerimeter)
 }
 
 // Name 策略名称
@@ -322,122 +346,134 @@ func demoStrategyPattern() {
 				i+1, shape.String(), shape.Area(), shape.Perimeter())
 		}
 	}
-}
-
-// Processor 处理器接口
-type Processor interface {
-	Process(data interface{}) interface{}
-	Name() string
-}
-
-// StringProcessor 字符串处理器
-type StringProcessor struct{}
 
-// Process 处理字符串
-func (sp StringProcessor) Process(data interface{}) interface{} {
-	if str, ok := data.(string); ok {
-		return fmt.Sprintf("处理后的字符串: %s", str)
+	// 3. 组合多个排序键：面积升序，面积相同时周长降序，再按类型名
+	composite := CompareStrategy{
+		Label:   "面积升序、周长降序、类型名",
+		Compare: ByArea.Then(ByPerimeter.Reverse()).Then(ByTypeName),
 	}
-	return "无法处理非字符串数据"
-}
-
-// Name 处理器名称
-func (sp StringProcessor) Name() string {
-	return "字符串处理器"
-}
-
-// NumberProcessor 数字处理器
-type NumberProcessor struct{}
-
-// Process 处理数字
-func (np NumberProcessor) Process(data interface{}) interface{} {
-	switch v := data.(type) {
-	case int:
-		return v * 2
-	case float64:
-		return v * 2.0
-	default:
-		return "无法处理非数字数据"
+	sorter.SetStrategy(composite)
+	fmt.Printf("\n%s:\n", composite.Name())
+	for i, shape := range sorter.Sort(shapes) {
+		fmt.Printf("%d. %s (面积: %.2f, 周长: %.2f)\n",
+			i+1, shape.String(), shape.Area(), shape.Perimeter())
 	}
-}
-
-// Name 处理器名称
-func (np NumberProcessor) Name() string {
-	return "数字处理器"
-}
 
-// ShapeProcessor 形状处理器
-type ShapeProcessor struct{}
-
-// Process 处理形状
-func (sp ShapeProcessor) Process(data interface{}) interface{} {
-	if shape, ok := data.(Shape); ok {
-		return fmt.Sprintf("形状信息: %s, 面积: %.2f", 
-			shape.String(), shape.Area())
+	// 4. 从配置中按名称选择策略
+	for _, spec := range []string{"type,-area", "volume"} {
+		if err := sorter.SetStrategyByName(spec); err != nil {
+			fmt.Printf("\n配置 %q: %v\n", spec, err)
+			continue
+		}
+		fmt.Printf("\n配置 %q:\n", spec)
+		for i, shape := range sorter.Sort(shapes) {
+			fmt.Printf("%d. %s\n", i+1, shape.String())
+		}
 	}
-	return "无法处理非形状数据"
-}
-
-// Name 处理器名称
-func (sp ShapeProcessor) Name() string {
-	return "形状处理器"
 }
 
 // demoPolymorphismInPractice 演示多态的实际应用
 func demoPolymorphismInPractice() {
-	// 1. 创建处理器
-	processors := []Processor{
-		StringProcessor{},
-		NumberProcessor{},
-		ShapeProcessor{},
+	ctx := context.Background()
+
+	// 1. 注册处理器，注册表按名称保存不同输入输出类型的处理器
+	registry := NewProcessorRegistry()
+	for _, err := range []error{
+		Register(registry, Processor[string, string](StringProcessor{})),
+		Register(registry, Processor[int, int](NumberProcessor[int]{})),
+		Register(registry, Processor[float64, float64](NumberProcessor[float64]{})),
+		Register(registry, Processor[Shape, string](ShapeProcessor{})),
+	} {
+		if err != nil {
+			fmt.Printf("注册处理器失败: %v\n", err)
+			return
+		}
 	}
-	
-	// 2. 创建不同类型的数据
-	data := []interface{}{
+
+	// 2. 不同类型的数据自动选择处理器，不支持的类型返回错误而不是字符串
+	data := []any{
 		"Hello, World!",
 		42,
 		3.14,
 		Rectangle{Width: 5, Height: 3},
 		"Go语言",
 		Circle{Radius: 2},
+		[]int{1, 2},
 	}
-	
 	fmt.Println("多态数据处理:")
-	
-	// 3. 使用多态处理数据
-	for i, item := range data {
-		fmt.Printf("\n数据 %d: %v (类型: %T)\n", i+1, item, item)
-		
-		for _, processor := range processors {
-			result := processor.Process(item)
-			fmt.Printf("  %s: %v\n", processor.Name(), result)
+	for _, item := range data {
+		name, result, err := registry.Dispatch(ctx, item)
+		if err != nil {
+			fmt.Printf("  %v (%T): %v\n", item, item, err)
+			continue
 		}
+		fmt.Printf("  %v (%T) -> %s: %v\n", item, item, name, result)
 	}
-	
-	// 4. 智能处理器选择
-	fmt.Println("\n智能处理器选择:")
-	
-	smartProcess := func(data interface{}) {
-		fmt.Printf("处理数据: %v (类型: %T)\n", data, data)
-		
-		for _, processor := range processors {
-			result := processor.Process(data)
-			if resultStr, ok := result.(string); ok {
-				if resultStr != "无法处理非字符串数据" && 
-				   resultStr != "无法处理非数字数据" && 
-				   resultStr != "无法处理非形状数据" {
-					fmt.Printf("  使用 %s: %v\n", processor.Name(), result)
-					return
-				}
-			} else {
-				fmt.Printf("  使用 %s: %v\n", processor.Name(), result)
-				return
-			}
-		}
-		fmt.Println("  没有合适的处理器")
+
+	// 3. 类型安全的管道：编译期检查每一步的输入输出类型
+	shapes, _ := Lookup[Shape, string](registry, "形状处理器")
+	describe := Then(shapes, NewProcessor("长度", func(_ context.Context, s string) (int, error) {
+		return len([]rune(s)), nil
+	}))
+	n, _ := describe.Process(ctx, Triangle{Base: 3, Height: 4})
+	fmt.Printf("\n管道 %s: %d\n", describe.Name(), n)
+
+	double, _ := Lookup[int, int](registry, "数字处理器[int]")
+	quadruple := Pipeline(double, double)
+	if _, err := Lookup[string, int](registry, "数字处理器[int]"); err != nil {
+		fmt.Printf("类型不符: %v\n", err)
 	}
-	
-	for _, item := range data {
-		smartProcess(item)
+
+	// 4. 批量并发处理，结果顺序与输入一致
+	results, _ := ProcessAll(ctx, quadruple, []int{1, 2, 3, 4, 5}, Concurrent(3))
+	fmt.Printf("并发执行 %s: %v\n", quadruple.Name(), results)
+}
+
+// demoGeometry 演示基于 geometry 包的形状运算
+func demoGeometry() {
+	// 1. 任意三角形（不再假设等腰）
+	right := NewTriangle(geometry.Pt(0, 0), geometry.Pt(3, 0), geometry.Pt(0, 4))
+	fmt.Printf("直角三角形: %s 面积=%.2f 周长=%.2f\n", right.String(), right.Area(), right.Perimeter())
+
+	// 2. 任意多边形
+	lShape := geometry.Polygon{
+		geometry.Pt(0, 0), geometry.Pt(4, 0), geometry.Pt(4, 1),
+		geometry.Pt(1, 1), geometry.Pt(1, 3), geometry.Pt(0, 3),
+	}
+	fmt.Printf("L形多边形: 面积=%.2f 周长=%.2f 凸=%t\n", lShape.Area(), lShape.Perimeter(), lShape.IsConvex())
+	fmt.Printf("包含(2, 2): %t, 包含(0.5, 2): %t\n", lShape.Contains(geometry.Pt(2, 2)), lShape.Contains(geometry.Pt(0.5, 2)))
+
+	// 3. 包围盒与相交测试
+	rect := Rectangle{Width: 2, Height: 2}.Geometry()
+	circle := geometry.Circle{Center: geometry.Pt(2.5, 2.5), Radius: 1}
+	fmt.Printf("包围盒: %s\n", geometry.BoundingBox(rect, circle, lShape))
+	fmt.Printf("矩形与圆相交: %t, L形与圆相交: %t\n",
+		geometry.Intersects(rect, circle), geometry.Intersects(lShape, circle))
+
+	// 4. 仿射变换：失去闭合形式的形状变为多边形
+	transforms := []struct {
+		name string
+		t    geometry.Transform
+	}{
+		{"平移+均匀缩放", geometry.Compose(geometry.Scale(2, 2), geometry.Translate(1, 1))},
+		{"旋转45°", geometry.Rotate(math.Pi / 4)},
+		{"错切", geometry.Shear(0.5, 0)},
+	}
+	shapes := []interface {
+		Shape
+		Transform(geometry.Transform) geometry.Shape
+	}{
+		Rectangle{Width: 4, Height: 2},
+		Circle{Radius: 1},
+		Triangle{Base: 3, Height: 2},
 	}
-}
\ No newline at end of file
+	for _, tr := range transforms {
+		fmt.Printf("%s %s:\n", tr.name, tr.t)
+		for _, s := range shapes {
+			out := s.Transform(tr.t)
+			fmt.Printf("  %s -> %T 面积=%.2f\n", s.String(), out, out.Area())
+		}
+	}
+	inv, _ := transforms[0].t.Inverse()
+	fmt.Printf("逆变换: %s, 还原点: %s\n", inv, inv.Apply(transforms[0].t.Apply(geometry.Pt(3, 4))))
+}
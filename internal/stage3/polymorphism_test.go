@@ -0,0 +1,480 @@
+package stage3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/howard/go.study/internal/stage2"
+	"github.com/howard/go.study/pkg/geometry"
+)
+
+func TestTrianglePerimeter(t *testing.T) {
+	tests := []struct {
+		name      string
+		tri       Triangle
+		area      float64
+		perimeter float64
+	}{
+		{"isosceles", Triangle{Base: 6, Height: 4}, 12, 16},
+		{"right", Triangle{Base: 3, Height: 4, Offset: -1.5}, 6, 12},
+		{"obtuse", Triangle{Base: 4, Height: 3, Offset: 3}, 6, 4 + math.Sqrt(9+25) + math.Sqrt(9+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tri.Area(); math.Abs(got-tt.area) > 1e-9 {
+				t.Errorf("expected area %v, got %v", tt.area, got)
+			}
+			if got := tt.tri.Perimeter(); math.Abs(got-tt.perimeter) > 1e-9 {
+				t.Errorf("expected perimeter %v, got %v", tt.perimeter, got)
+			}
+		})
+	}
+}
+
+func TestNewTriangle(t *testing.T) {
+	a, b, c := geometry.Pt(1, 1), geometry.Pt(4, 5), geometry.Pt(-2, 5)
+	tri := NewTriangle(a, b, c)
+	want := geometry.Polygon{a, b, c}
+	if math.Abs(tri.Area()-want.Area()) > 1e-9 || math.Abs(tri.Perimeter()-want.Perimeter()) > 1e-9 {
+		t.Errorf("expected area %v perimeter %v, got %v %v",
+			want.Area(), want.Perimeter(), tri.Area(), tri.Perimeter())
+	}
+}
+
+func TestShapesAdoptGeometry(t *testing.T) {
+	if got := (Circle{Radius: 1}).Area(); got != math.Pi {
+		t.Errorf("expected π, got %v", got)
+	}
+	rect := Rectangle{Width: 2, Height: 3}
+	if !rect.Polygon().IsConvex() || rect.Polygon().Area() != rect.Area() {
+		t.Error("rectangle polygon mismatch")
+	}
+}
+
+func TestAdapters(t *testing.T) {
+	dog := &stage2.Dog{Animal: stage2.Animal{Name: "Rex"}}
+	var a Animal = PetAdapter{dog}
+	if a.Move() != dog.Walk() || a.Speak() != dog.Speak() {
+		t.Errorf("PetAdapter: unexpected %q / %q", a.Speak(), a.Move())
+	}
+	if p := (AnimalPet{Cat{Name: "Tom"}}); p.Walk() != (Cat{Name: "Tom"}).Move() {
+		t.Errorf("AnimalPet: unexpected %q", p.Walk())
+	}
+
+	rect := stage2.Rectangle{TopLeft: stage2.Point{X: 0, Y: 2}, BottomRight: stage2.Point{X: 3, Y: 0}}
+	tests := []struct {
+		name    string
+		in      stage2.Shape
+		wrapped bool
+		str     string
+	}{
+		{"without String", rect, true, "stage2.Rectangle{Area: 6.00}"},
+		{"with String", stage2.Circle{Radius: 1}, false, "Circle{Radius: 1.00, Center: (0.00, 0.00)}"},
+		{"stage3 shape", Rectangle{Width: 1, Height: 2}, false, "Rectangle{Width: 1.00, Height: 2.00}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := FromStage2(tt.in)
+			if _, ok := s.(Stage2Shape); ok != tt.wrapped {
+				t.Errorf("expected wrapped=%v, got %T", tt.wrapped, s)
+			}
+			if s.String() != tt.str || s.Area() != tt.in.Area() {
+				t.Errorf("unexpected %q area %v", s.String(), s.Area())
+			}
+			if back := ToStage2(s); back != tt.in {
+				t.Errorf("round trip: expected %v, got %v", tt.in, back)
+			}
+		})
+	}
+
+}
+
+func TestShapeFactoryParse(t *testing.T) {
+	var factory ShapeFactory
+	tests := []struct {
+		spec string
+		want Shape
+		err  error
+	}{
+		{"circle r=3", Circle{Radius: 3}, nil},
+		{"Circle radius=3", Circle{Radius: 3}, nil},
+		{"rectangle 4 6", Rectangle{Width: 4, Height: 6}, nil},
+		{"rectangle 4 h=6", Rectangle{Width: 4, Height: 6}, nil},
+		{"triangle b=5 h=4 o=1", Triangle{Base: 5, Height: 4, Offset: 1}, nil},
+		{`{"type":"triangle","base":5,"height":4}`, Triangle{Base: 5, Height: 4}, nil},
+		{`{"type":"circle","r":2}`, Circle{Radius: 2}, nil},
+		{"", nil, ErrShapeSpec},
+		{"hexagon 1", nil, ErrUnknownShape},
+		{`{"type":"hexagon"}`, nil, ErrUnknownShape},
+		{"circle", nil, ErrShapeParam},
+		{"circle r=0", nil, ErrShapeParam},
+		{"circle r=NaN", nil, ErrShapeParam},
+		{"circle 1 2", nil, ErrShapeParam},
+		{"circle r=1 radius=2", nil, ErrShapeParam},
+		{"circle d=1", nil, ErrShapeParam},
+		{"circle r=x", nil, ErrShapeSpec},
+		{"rectangle w=4 6", nil, ErrShapeSpec},
+		{`{"base":5}`, nil, ErrShapeSpec},
+		{`{"type":"circle","r":"3"}`, nil, ErrShapeSpec},
+		{`{"type":"circle"`, nil, ErrShapeSpec},
+	}
+	for _, tt := range tests {
+		got, err := factory.Parse(tt.spec)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%q: expected error %v, got %v", tt.spec, tt.err, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %v, got %v", tt.spec, tt.want, got)
+		}
+	}
+}
+
+func TestShapeFactoryRegister(t *testing.T) {
+	factory := NewShapeFactory()
+	square := ShapeKind{
+		Name:   "square",
+		Params: []ShapeParam{{Name: "side", Aliases: []string{"s"}, Optional: true, Default: 1}},
+		New: func(args []float64) (Shape, error) {
+			return Rectangle{Width: args[0], Height: args[0]}, nil
+		},
+	}
+	typ, err := factory.Register(square)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != TriangleType+1 {
+		t.Errorf("expected type %d, got %d", TriangleType+1, typ)
+	}
+	if _, err := factory.Register(square); !errors.Is(err, ErrDuplicateShape) {
+		t.Errorf("expected ErrDuplicateShape, got %v", err)
+	}
+	if _, err := factory.Register(ShapeKind{Name: "bad", Params: []ShapeParam{{Name: "a"}, {Name: "b", Aliases: []string{"A"}}}, New: square.New}); !errors.Is(err, ErrShapeSpec) {
+		t.Errorf("expected ErrShapeSpec for duplicate parameter names, got %v", err)
+	}
+
+	for spec, want := range map[string]Shape{"square s=2": Rectangle{Width: 2, Height: 2}, "square": Rectangle{Width: 1, Height: 1}} {
+		if got, err := factory.Parse(spec); err != nil || got != want {
+			t.Errorf("%q: expected %v, got %v (%v)", spec, want, got, err)
+		}
+	}
+	if got, err := factory.CreateShape(typ, 3); err != nil || got != (Rectangle{Width: 3, Height: 3}) {
+		t.Errorf("CreateShape: unexpected %v (%v)", got, err)
+	}
+	if _, err := factory.CreateShape(typ + 1); !errors.Is(err, ErrUnknownShape) {
+		t.Errorf("expected ErrUnknownShape, got %v", err)
+	}
+	// 注册只影响当前工厂
+	if _, err := new(ShapeFactory).Parse("square"); !errors.Is(err, ErrUnknownShape) {
+		t.Errorf("expected ErrUnknownShape from a fresh factory, got %v", err)
+	}
+}
+
+func TestSortStrategies(t *testing.T) {
+	shapes := []Shape{
+		Rectangle{Width: 4, Height: 3}, // 面积 12，周长 14
+		Circle{Radius: 2},
+		Rectangle{Width: 2, Height: 6}, // 面积 12，周长 16
+		Triangle{Base: 6, Height: 4},   // 面积 12，周长 16
+		Rectangle{Width: 1, Height: 1},
+	}
+	tests := []struct {
+		spec string
+		want []int // shapes 的下标
+	}{
+		{"area", []int{4, 0, 2, 3, 1}}, // 稳定：面积相同保持原顺序
+		{"-area", []int{1, 0, 2, 3, 4}},
+		{"area,-perimeter,type", []int{4, 2, 3, 0, 1}},
+		{"area, -perimeter, -type", []int{4, 3, 2, 0, 1}},
+		{"type", []int{1, 0, 2, 4, 3}},
+		{"PERIMETER", []int{4, 1, 0, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			var sorter ShapeSorter
+			if err := sorter.SetStrategyByName(tt.spec); err != nil {
+				t.Fatal(err)
+			}
+			got := sorter.Sort(shapes)
+			for i, idx := range tt.want {
+				if got[i] != shapes[idx] {
+					t.Fatalf("position %d: expected %v, got %v", i, shapes[idx], got[i])
+				}
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "area,", "volume", "--area"} {
+		if _, err := ParseSortStrategy(spec); !errors.Is(err, ErrUnknownSortKey) {
+			t.Errorf("%q: expected ErrUnknownSortKey, got %v", spec, err)
+		}
+	}
+
+	// 内置策略与组合比较器结果一致，且不修改输入
+	orig := slices.Clone(shapes)
+	if got, want := (AreaSortStrategy{}).Sort(shapes), sortShapes(shapes, Compose(ByArea)); !slices.Equal(got, want) {
+		t.Errorf("AreaSortStrategy: expected %v, got %v", want, got)
+	}
+	if !slices.Equal(shapes, orig) {
+		t.Error("input slice was modified")
+	}
+}
+
+func benchmarkShapes(n int) []Shape {
+	rng := rand.New(rand.NewSource(1))
+	shapes := make([]Shape, n)
+	for i := range shapes {
+		// 取整让面积经常相等，使后续的排序键参与比较
+		switch a := float64(rng.Intn(20) + 1); i % 3 {
+		case 0:
+			shapes[i] = Rectangle{Width: a, Height: float64(rng.Intn(5) + 1)}
+		case 1:
+			shapes[i] = Circle{Radius: a}
+		default:
+			shapes[i] = Triangle{Base: a, Height: 2}
+		}
+	}
+	return shapes
+}
+
+func BenchmarkSortStrategy(b *testing.B) {
+	composite, _ := ParseSortStrategy("area,-perimeter,type")
+	strategies := []SortStrategy{AreaSortStrategy{}, composite}
+	for _, n := range []int{100, 10000} {
+		shapes := benchmarkShapes(n)
+		for _, s := range strategies {
+			b.Run(fmt.Sprintf("%s/%d", s.Name(), n), func(b *testing.B) {
+				for b.Loop() {
+					s.Sort(shapes)
+				}
+			})
+		}
+	}
+}
+
+func TestProcessorPipeline(t *testing.T) {
+	ctx := context.Background()
+	inc := NewProcessor("inc", func(_ context.Context, n int) (int, error) { return n + 1, nil })
+	fail := NewProcessor("fail", func(_ context.Context, n int) (int, error) {
+		if n > 2 {
+			return 0, errTooBig
+		}
+		return n, nil
+	})
+	toString := ProcessorFunc[int, string](func(_ context.Context, n int) (string, error) { return fmt.Sprint(n), nil })
+
+	p := Then(Pipeline(inc, NumberProcessor[int]{}), toString)
+	if got, err := p.Process(ctx, 2); err != nil || got != "6" {
+		t.Errorf("expected \"6\", got %q (%v)", got, err)
+	}
+	if want := "inc → 数字处理器[int] → int -> string"; p.Name() != want {
+		t.Errorf("expected name %q, got %q", want, p.Name())
+	}
+	if _, err := Pipeline(inc, fail, inc).Process(ctx, 2); !errors.Is(err, errTooBig) || !strings.HasPrefix(err.Error(), "fail: ") {
+		t.Errorf("expected wrapped errTooBig, got %v", err)
+	}
+	if got, _ := Pipeline[int]().Process(ctx, 7); got != 7 {
+		t.Errorf("empty pipeline: expected 7, got %d", got)
+	}
+}
+
+var errTooBig = errors.New("too big")
+
+func TestProcessorRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := NewProcessorRegistry()
+	if err := Register(r, Processor[string, string](StringProcessor{})); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range []error{
+		Register(r, Processor[int, int](NumberProcessor[int]{})),
+		Register(r, Processor[float64, float64](NumberProcessor[float64]{})),
+		Register(r, Processor[Shape, string](ShapeProcessor{})),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Register(r, Processor[string, string](StringProcessor{})); !errors.Is(err, ErrDuplicateProcessor) {
+		t.Errorf("expected ErrDuplicateProcessor, got %v", err)
+	}
+
+	tests := []struct {
+		data any
+		name string
+		want any
+		err  error
+	}{
+		{"go", "字符串处理器", "处理后的字符串: go", nil},
+		{21, "数字处理器[int]", 42, nil},
+		{3.5, "数字处理器[float64]", 7.0, nil},
+		{Circle{Radius: 1}, "形状处理器", "形状信息: Circle{Radius: 1.00}, 面积: 3.14", nil},
+		{int8(1), "", nil, ErrUnsupported},
+	}
+	for _, tt := range tests {
+		name, got, err := r.Dispatch(ctx, tt.data)
+		if !errors.Is(err, tt.err) || name != tt.name || got != tt.want {
+			t.Errorf("%v: expected %s %v (%v), got %s %v (%v)", tt.data, tt.name, tt.want, tt.err, name, got, err)
+		}
+	}
+
+	if _, err := r.Process(ctx, "数字处理器[int]", "x"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+	if _, err := r.Process(ctx, "nope", 1); !errors.Is(err, ErrUnknownProcessor) {
+		t.Errorf("expected ErrUnknownProcessor, got %v", err)
+	}
+	if _, err := Lookup[int, string](r, "数字处理器[int]"); !errors.Is(err, ErrProcessorType) {
+		t.Errorf("expected ErrProcessorType, got %v", err)
+	}
+	if p, err := Lookup[int, int](r, "数字处理器[int]"); err != nil || p.Name() != "数字处理器[int]" {
+		t.Errorf("Lookup: unexpected %v (%v)", p, err)
+	}
+	if got := strings.Join(r.Names(), ","); got != "字符串处理器,数字处理器[int],数字处理器[float64],形状处理器" {
+		t.Errorf("unexpected names %q", got)
+	}
+
+	// 选中的处理器失败时直接返回错误，即使错误包装了 ErrUnsupported 也不会再交给下一个
+	r = NewProcessorRegistry()
+	calls := 0
+	Register(r, NewProcessor("first", func(context.Context, int) (int, error) {
+		calls++
+		return 0, fmt.Errorf("%w: 内部失败", ErrUnsupported)
+	}))
+	Register(r, NewProcessor("second", func(_ context.Context, n int) (int, error) {
+		calls++
+		return n, nil
+	}))
+	if name, _, err := r.Dispatch(ctx, 1); name != "first" || !errors.Is(err, ErrUnsupported) || calls != 1 {
+		t.Errorf("expected only first to run and fail, got %s (%v) after %d calls", name, err, calls)
+	}
+}
+
+func TestProcessAll(t *testing.T) {
+	ctx := context.Background()
+	inputs := make([]int, 100)
+	for i := range inputs {
+		inputs[i] = i
+	}
+	square := NewProcessor("square", func(_ context.Context, n int) (int, error) { return n * n, nil })
+	for _, workers := range []int{0, 1, 4, 200} {
+		got, err := ProcessAll(ctx, square, inputs, Concurrent(workers))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range got {
+			if v != i*i {
+				t.Fatalf("workers=%d: position %d: expected %d, got %d", workers, i, i*i, v)
+			}
+		}
+	}
+
+	// 出错时取消其余处理
+	var calls atomic.Int32
+	failing := NewProcessor("failing", func(ctx context.Context, n int) (int, error) {
+		calls.Add(1)
+		switch {
+		case n < 10:
+			return n, nil
+		case n == 10:
+			return 0, errTooBig
+		}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	_, err := ProcessAll(ctx, failing, inputs, Concurrent(4))
+	if !errors.Is(err, errTooBig) || !strings.Contains(err.Error(), "第 10 项") {
+		t.Errorf("expected errTooBig at index 10, got %v", err)
+	}
+	if n := calls.Load(); n > 16 {
+		t.Errorf("expected processing to stop early, got %d calls", n)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := ProcessAll(canceled, square, inputs); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// hexagon 没有 Accept 方法的形状
+type hexagon struct{ side float64 }
+
+func (h hexagon) Area() float64      { return 3 * math.Sqrt(3) / 2 * h.side * h.side }
+func (h hexagon) Perimeter() float64 { return 6 * h.side }
+func (h hexagon) String() string     { return fmt.Sprintf("hexagon(%g)", h.side) }
+
+func TestVisitors(t *testing.T) {
+	shapes := []Shape{
+		Rectangle{Width: 2, Height: 3},
+		Circle{Radius: 1},
+		Triangle{Base: 4, Height: 3, Offset: 1},
+		hexagon{side: 2},
+		Rectangle{Width: 1, Height: 1},
+	}
+
+	total := Walk(&TotalArea{}, shapes...)
+	var wantArea, wantPerimeter float64
+	for _, s := range shapes {
+		wantArea += s.Area()
+		wantPerimeter += s.Perimeter()
+	}
+	if total.Count != 5 || math.Abs(total.Area-wantArea) > 1e-9 || math.Abs(total.Perimeter-wantPerimeter) > 1e-9 {
+		t.Errorf("TotalArea: unexpected %+v", *total)
+	}
+
+	hist := Walk(&TypeHistogram{}, shapes...)
+	if hist.Count("rectangle") != 2 || hist.Count("stage3.hexagon") != 1 || len(hist.Counts()) != 4 {
+		t.Errorf("TypeHistogram: unexpected %v", hist.Counts())
+	}
+	if first, _, _ := strings.Cut(hist.String(), "\n"); !strings.HasPrefix(first, "rectangle") {
+		t.Errorf("TypeHistogram: expected rectangle first, got %q", first)
+	}
+
+	if l := Walk(&Largest{}, shapes...); l.Shape != (hexagon{side: 2}) {
+		t.Errorf("Largest: expected hexagon, got %v", l.Shape)
+	}
+	if l := Walk(&Largest{By: ByTypeName}, shapes...); l.Shape != (hexagon{side: 2}) {
+		t.Errorf("Largest by type name: expected hexagon, got %v", l.Shape)
+	}
+	if l := Walk(&Largest{By: ByArea.Reverse()}, shapes...); l.Shape != shapes[4] {
+		t.Errorf("Largest by reversed area: expected unit square, got %v", l.Shape)
+	}
+	if l := Walk(&Largest{}); l.Shape != nil {
+		t.Errorf("Largest of nothing: expected nil, got %v", l.Shape)
+	}
+
+	data, err := json.Marshal(Walk(&JSONVisitor{}, shapes...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil || len(items) != len(shapes) {
+		t.Fatalf("unexpected JSON %s (%v)", data, err)
+	}
+	// 内置形状的 JSON 可以由 ShapeFactory 解析回原值
+	var factory ShapeFactory
+	for i, item := range items {
+		got, err := factory.Parse(string(item))
+		if i == 3 {
+			if !errors.Is(err, ErrUnknownShape) {
+				t.Errorf("hexagon: expected ErrUnknownShape, got %v", err)
+			}
+			continue
+		}
+		if err != nil || got != shapes[i] {
+			t.Errorf("round trip %s: got %v (%v)", item, got, err)
+		}
+	}
+	if data, _ := json.Marshal(&JSONVisitor{}); string(data) != "[]" {
+		t.Errorf("empty JSONVisitor: expected [], got %s", data)
+	}
+}
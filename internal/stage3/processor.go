@@ -0,0 +1,336 @@
+package stage3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrUnsupported 处理器不接受此类型的输入
+	ErrUnsupported = errors.New("stage3: 处理器不支持此类型的数据")
+	// ErrUnknownProcessor 注册表中没有此名称的处理器
+	ErrUnknownProcessor = errors.New("stage3: 未知的处理器")
+	// ErrDuplicateProcessor 重复注册同名处理器
+	ErrDuplicateProcessor = errors.New("stage3: 处理器已注册")
+	// ErrProcessorType 注册的处理器与请求的输入输出类型不符
+	ErrProcessorType = errors.New("stage3: 处理器类型不匹配")
+)
+
+// Processor 把 In 处理为 Out 的处理器
+type Processor[In, Out any] interface {
+	Process(ctx context.Context, in In) (Out, error)
+	Name() string
+}
+
+// ProcessorFunc 把函数适配为 Processor，类似 http.HandlerFunc，名称取自输入输出类型
+type ProcessorFunc[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// Process 调用 f
+func (f ProcessorFunc[In, Out]) Process(ctx context.Context, in In) (Out, error) {
+	return f(ctx, in)
+}
+
+// Name 处理器名称，例如 "string -> int"
+func (f ProcessorFunc[In, Out]) Name() string {
+	return reflect.TypeFor[In]().String() + " -> " + reflect.TypeFor[Out]().String()
+}
+
+// namedProcessor 带名称的函数处理器
+type namedProcessor[In, Out any] struct {
+	name string
+	fn   func(ctx context.Context, in In) (Out, error)
+}
+
+func (p namedProcessor[In, Out]) Process(ctx context.Context, in In) (Out, error) {
+	return p.fn(ctx, in)
+}
+
+func (p namedProcessor[In, Out]) Name() string { return p.name }
+
+// NewProcessor 用函数创建指定名称的处理器
+func NewProcessor[In, Out any](name string, fn func(ctx context.Context, in In) (Out, error)) Processor[In, Out] {
+	return namedProcessor[In, Out]{name: name, fn: fn}
+}
+
+// ===== 具体处理器 =====
+
+// StringProcessor 字符串处理器
+type StringProcessor struct{}
+
+// Process 处理字符串
+func (sp StringProcessor) Process(_ context.Context, s string) (string, error) {
+	return "处理后的字符串: " + s, nil
+}
+
+// Name 处理器名称
+func (sp StringProcessor) Name() string {
+	return "字符串处理器"
+}
+
+// Number 数字处理器接受的类型
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~float32 | ~float64
+}
+
+// NumberProcessor 数字处理器，把数字加倍
+type NumberProcessor[N Number] struct{}
+
+// Process 处理数字
+func (np NumberProcessor[N]) Process(_ context.Context, n N) (N, error) {
+	return n * 2, nil
+}
+
+// Name 处理器名称，带上数字类型，例如 数字处理器[int]，使不同实例可以同时注册
+func (np NumberProcessor[N]) Name() string {
+	return "数字处理器[" + reflect.TypeFor[N]().String() + "]"
+}
+
+// ShapeProcessor 形状处理器
+type ShapeProcessor struct{}
+
+// Process 处理形状
+func (sp ShapeProcessor) Process(_ context.Context, shape Shape) (string, error) {
+	if shape == nil {
+		return "", fmt.Errorf("%w: nil 形状", ErrUnsupported)
+	}
+	return fmt.Sprintf("形状信息: %s, 面积: %.2f", shape.String(), shape.Area()), nil
+}
+
+// Name 处理器名称
+func (sp ShapeProcessor) Name() string {
+	return "形状处理器"
+}
+
+// ===== 组合 =====
+
+// Then 把 p 的输出作为 q 的输入，组合为 A -> C 的处理器，类型在编译期检查
+func Then[A, B, C any](p Processor[A, B], q Processor[B, C]) Processor[A, C] {
+	return NewProcessor(p.Name()+" → "+q.Name(), func(ctx context.Context, a A) (C, error) {
+		var zero C
+		b, err := p.Process(ctx, a)
+		if err != nil {
+			return zero, err
+		}
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
+		return q.Process(ctx, b)
+	})
+}
+
+// Pipeline 依次执行输入输出类型相同的处理器，没有处理器时原样返回
+func Pipeline[T any](ps ...Processor[T, T]) Processor[T, T] {
+	names := make([]string, len(ps))
+	for i, p := range ps {
+		names[i] = p.Name()
+	}
+	return NewProcessor(strings.Join(names, " → "), func(ctx context.Context, v T) (T, error) {
+		for _, p := range ps {
+			if err := ctx.Err(); err != nil {
+				return v, err
+			}
+			var err error
+			if v, err = p.Process(ctx, v); err != nil {
+				return v, fmt.Errorf("%s: %w", p.Name(), err)
+			}
+		}
+		return v, nil
+	})
+}
+
+// Erase 擦除类型参数，输入不是 In 时返回 ErrUnsupported，
+// 用于需要在运行时按类型挑选处理器的场合
+func Erase[In, Out any](p Processor[In, Out]) Processor[any, any] {
+	return NewProcessor(p.Name(), func(ctx context.Context, v any) (any, error) {
+		in, ok := v.(In)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s 需要 %s，实际为 %T", ErrUnsupported, p.Name(), reflect.TypeFor[In](), v)
+		}
+		return p.Process(ctx, in)
+	})
+}
+
+// ===== 批量与并发 =====
+
+// ProcessOption 批量处理选项
+type ProcessOption func(*processConfig)
+
+type processConfig struct {
+	workers int
+}
+
+// Concurrent 用 n 个 goroutine 并发处理，n <= 1 时顺序处理
+func Concurrent(n int) ProcessOption {
+	return func(c *processConfig) {
+		c.workers = n
+	}
+}
+
+// ProcessAll 处理一组输入，结果与输入顺序一致
+//
+// 任一输入失败时取消其余处理并返回第一个错误，错误中带有输入的下标。
+func ProcessAll[In, Out any](ctx context.Context, p Processor[In, Out], inputs []In, opts ...ProcessOption) ([]Out, error) {
+	cfg := processConfig{workers: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	out := make([]Out, len(inputs))
+
+	if cfg.workers <= 1 {
+		for i, in := range inputs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			v, err := p.Process(ctx, in)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 项: %w", i, err)
+			}
+			out[i] = v
+		}
+		return out, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	jobs := make(chan int)
+	for range min(cfg.workers, len(inputs)) {
+		wg.Go(func() {
+			for i := range jobs {
+				v, err := p.Process(ctx, inputs[i])
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("第 %d 项: %w", i, err)
+						cancel()
+					})
+					continue
+				}
+				out[i] = v
+			}
+		})
+	}
+feed:
+	for i := range inputs {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ===== 注册表 =====
+
+// ProcessorRegistry 按名称保存处理器，可并发使用
+type ProcessorRegistry struct {
+	mu      sync.RWMutex
+	order   []string
+	entries map[string]registryEntry
+}
+
+// registryEntry 注册表中的一个处理器
+type registryEntry struct {
+	typed   any // Processor[In, Out]，供 Lookup 按类型取回
+	erased  Processor[any, any]
+	accepts func(v any) bool // v 是否为处理器的输入类型
+}
+
+// NewProcessorRegistry 创建处理器注册表
+func NewProcessorRegistry() *ProcessorRegistry {
+	return &ProcessorRegistry{entries: make(map[string]registryEntry)}
+}
+
+// Register 以 p.Name() 为名注册处理器；Go 的方法不能有类型参数，所以是函数
+func Register[In, Out any](r *ProcessorRegistry, p Processor[In, Out]) error {
+	name := p.Name()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.entries[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateProcessor, name)
+	}
+	r.order = append(r.order, name)
+	r.entries[name] = registryEntry{
+		typed:  p,
+		erased: Erase(p),
+		accepts: func(v any) bool {
+			_, ok := v.(In)
+			return ok
+		},
+	}
+	return nil
+}
+
+// Lookup 按名称取回类型确定的处理器
+func Lookup[In, Out any](r *ProcessorRegistry, name string) (Processor[In, Out], error) {
+	r.mu.RLock()
+	e, ok := r.entries[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProcessor, name)
+	}
+	p, ok := e.typed.(Processor[In, Out])
+	if !ok {
+		return nil, fmt.Errorf("%w: %s 不是 %s -> %s 的处理器", ErrProcessorType, name, reflect.TypeFor[In](), reflect.TypeFor[Out]())
+	}
+	return p, nil
+}
+
+// Names 已注册的处理器名称，按注册顺序
+func (r *ProcessorRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.order)
+}
+
+// Process 用指定名称的处理器处理任意类型的数据
+func (r *ProcessorRegistry) Process(ctx context.Context, name string, data any) (any, error) {
+	r.mu.RLock()
+	e, ok := r.entries[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProcessor, name)
+	}
+	return e.erased.Process(ctx, data)
+}
+
+// Dispatch 按注册顺序找到第一个接受 data 类型的处理器并处理，返回所用处理器的名称
+//
+// 只按输入类型挑选处理器，选中的处理器返回的错误原样返回，不会再尝试下一个。
+func (r *ProcessorRegistry) Dispatch(ctx context.Context, data any) (string, any, error) {
+	r.mu.RLock()
+	var (
+		name  string
+		found registryEntry
+	)
+	for _, n := range r.order {
+		if e := r.entries[n]; e.accepts(data) {
+			name, found = n, e
+			break
+		}
+	}
+	r.mu.RUnlock()
+	if found.erased == nil {
+		return "", nil, fmt.Errorf("%w: 没有处理器接受 %T", ErrUnsupported, data)
+	}
+	out, err := found.erased.Process(ctx, data)
+	return name, out, err
+}
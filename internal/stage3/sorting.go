@@ -0,0 +1,123 @@
+package stage3
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrUnknownSortKey 无法识别的排序键
+var ErrUnknownSortKey = errors.New("stage3: 未知的排序键")
+
+// ShapeCompare 形状比较函数，a 排在 b 之前时返回负数，与 slices.SortFunc 的约定一致
+type ShapeCompare func(a, b Shape) int
+
+var (
+	// ByArea 按面积升序
+	ByArea ShapeCompare = func(a, b Shape) int { return cmp.Compare(a.Area(), b.Area()) }
+	// ByPerimeter 按周长升序
+	ByPerimeter ShapeCompare = func(a, b Shape) int { return cmp.Compare(a.Perimeter(), b.Perimeter()) }
+	// ByTypeName 按类型名升序，例如 Circle < Rectangle < Triangle
+	ByTypeName ShapeCompare = func(a, b Shape) int { return strings.Compare(shapeTypeName(a), shapeTypeName(b)) }
+)
+
+// shapeTypeName 形状的类型名，不含包名
+func shapeTypeName(s Shape) string {
+	t := reflect.TypeOf(s)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// Reverse 反转排序方向
+func (c ShapeCompare) Reverse() ShapeCompare {
+	return func(a, b Shape) int { return c(b, a) }
+}
+
+// Then c 认为相等时再用 next 比较
+func (c ShapeCompare) Then(next ShapeCompare) ShapeCompare {
+	return func(a, b Shape) int {
+		if r := c(a, b); r != 0 {
+			return r
+		}
+		return next(a, b)
+	}
+}
+
+// Compose 依次使用多个比较函数，前一个相等时才比较下一个；没有参数时所有形状都相等
+func Compose(keys ...ShapeCompare) ShapeCompare {
+	return func(a, b Shape) int {
+		for _, key := range keys {
+			if r := key(a, b); r != 0 {
+				return r
+			}
+		}
+		return 0
+	}
+}
+
+// sortKeys 排序配置中可用的键
+var sortKeys = map[string]ShapeCompare{
+	"area":      ByArea,
+	"perimeter": ByPerimeter,
+	"type":      ByTypeName,
+}
+
+// sortShapes 稳定排序，返回新切片，不修改输入
+func sortShapes(shapes []Shape, compare ShapeCompare) []Shape {
+	result := slices.Clone(shapes)
+	slices.SortStableFunc(result, compare)
+	return result
+}
+
+// CompareStrategy 基于比较函数的稳定排序策略
+type CompareStrategy struct {
+	Label   string
+	Compare ShapeCompare
+}
+
+// Sort 稳定排序，比较结果相等的形状保持原来的相对顺序
+func (cs CompareStrategy) Sort(shapes []Shape) []Shape {
+	return sortShapes(shapes, cs.Compare)
+}
+
+// Name 策略名称
+func (cs CompareStrategy) Name() string {
+	return cs.Label
+}
+
+// ParseSortStrategy 从配置解析排序策略
+//
+// spec 是逗号分隔的排序键，前缀 "-" 表示降序，例如 "area,-perimeter,type"
+// 表示按面积升序，面积相同按周长降序，再相同按类型名升序。
+// 可用的键为 area、perimeter 和 type。
+func ParseSortStrategy(spec string) (SortStrategy, error) {
+	var keys []ShapeCompare
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		name, desc := strings.CutPrefix(field, "-")
+		key, ok := sortKeys[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q（可用: area, perimeter, type）", ErrUnknownSortKey, field)
+		}
+		if desc {
+			key = key.Reverse()
+		}
+		keys = append(keys, key)
+	}
+	return CompareStrategy{Label: "按 " + spec + " 排序", Compare: Compose(keys...)}, nil
+}
+
+// SetStrategyByName 按配置设置排序策略，格式见 ParseSortStrategy
+func (ss *ShapeSorter) SetStrategyByName(spec string) error {
+	strategy, err := ParseSortStrategy(spec)
+	if err != nil {
+		return err
+	}
+	ss.SetStrategy(strategy)
+	return nil
+}
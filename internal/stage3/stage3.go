@@ -1,6 +1,10 @@
 package stage3
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/howard/go.study/pkg/geometry"
+)
 
 // RunStage3 运行第3阶段演示
 func RunStage3() {
@@ -70,6 +74,10 @@ func DemoPolymorphism() {
 	// 5. 多态的实际应用
 	fmt.Println("\n5. 多态的实际应用：")
 	demoPolymorphismInPractice()
+	
+	// 6. 统一的几何模型
+	fmt.Println("\n6. 统一的几何模型：")
+	demoGeometry()
 }
 
 // DemoInterfaceComposition 演示接口组合
@@ -164,12 +172,28 @@ type Rectangle struct {
 
 // Area 计算矩形面积
 func (r Rectangle) Area() float64 {
-	return r.Width * r.Height
+	return r.Geometry().Area()
 }
 
 // Perimeter 计算矩形周长
 func (r Rectangle) Perimeter() float64 {
-	return 2 * (r.Width + r.Height)
+	return r.Geometry().Perimeter()
+}
+
+// Geometry 以原点为左下角的几何矩形
+func (r Rectangle) Geometry() geometry.Rect {
+	return geometry.NewRect(geometry.Pt(0, 0), geometry.Pt(r.Width, r.Height))
+}
+
+// Polygon 矩形的顶点
+func (r Rectangle) Polygon() geometry.Polygon {
+	return r.Geometry().Polygon()
+}
+
+// Transform 仿射变换，旋转或错切后的矩形变为多边形
+func (r Rectangle) Transform(t geometry.Transform) geometry.Shape {
+	s, _ := t.Shape(r.Geometry()) // 矩形总是可以变换
+	return s
 }
 
 // String 矩形字符串表示
@@ -184,12 +208,23 @@ type Circle struct {
 
 // Area 计算圆形面积
 func (c Circle) Area() float64 {
-	return 3.14159 * c.Radius * c.Radius
+	return c.Geometry().Area()
 }
 
 // Perimeter 计算圆形周长
 func (c Circle) Perimeter() float64 {
-	return 2 * 3.14159 * c.Radius
+	return c.Geometry().Perimeter()
+}
+
+// Geometry 以原点为圆心的几何圆
+func (c Circle) Geometry() geometry.Circle {
+	return geometry.Circle{Radius: c.Radius}
+}
+
+// Transform 仿射变换，非均匀缩放或错切后的圆变为多边形
+func (c Circle) Transform(t geometry.Transform) geometry.Shape {
+	s, _ := t.Shape(c.Geometry()) // 圆总是可以变换
+	return s
 }
 
 // String 圆形字符串表示
@@ -351,26 +386,6 @@ func demoCompositionInPractice() {
 	fmt.Println("组合实际应用演示")
 }
 
-func demoObserverPattern() {
-	fmt.Println("观察者模式演示")
-}
-
-func demoDecoratorPattern() {
-	fmt.Println("装饰器模式演示")
-}
-
-func demoAdapterPattern() {
-	fmt.Println("适配器模式演示")
-}
-
-func demoCommandPattern() {
-	fmt.Println("命令模式演示")
-}
-
-func demoChainOfResponsibility() {
-	fmt.Println("责任链模式演示")
-}
-
 func demoBasicTypeAssertion() {
 	fmt.Println("基本类型断言演示")
 }
@@ -0,0 +1,236 @@
+package stage3
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ===== 访问者模式 =====
+
+// ShapeVisitor 形状访问者
+//
+// 新增一种分析只需实现一个访问者，不必修改任何形状类型。
+// 没有 Accept 方法的形状（例如适配过来的 stage2 形状）交给 VisitOther。
+type ShapeVisitor interface {
+	VisitRectangle(r Rectangle)
+	VisitCircle(c Circle)
+	VisitTriangle(t Triangle)
+	VisitOther(s Shape)
+}
+
+// Visitable 可以接受访问者的形状
+type Visitable interface {
+	Accept(v ShapeVisitor)
+}
+
+// Accept 接受访问者
+func (r Rectangle) Accept(v ShapeVisitor) { v.VisitRectangle(r) }
+
+// Accept 接受访问者
+func (c Circle) Accept(v ShapeVisitor) { v.VisitCircle(c) }
+
+// Accept 接受访问者
+func (t Triangle) Accept(v ShapeVisitor) { v.VisitTriangle(t) }
+
+// Walk 让访问者依次访问每个形状，返回 v 以便链式取结果
+func Walk[V ShapeVisitor](v V, shapes ...Shape) V {
+	for _, s := range shapes {
+		if a, ok := s.(Visitable); ok {
+			a.Accept(v)
+		} else {
+			v.VisitOther(s)
+		}
+	}
+	return v
+}
+
+// ShapeFuncVisitor 把所有形状交给同一个函数的访问者，
+// 适合只依赖 Shape 接口的分析；嵌入后可以只重写关心的方法
+type ShapeFuncVisitor func(s Shape)
+
+// VisitRectangle 访问矩形
+func (f ShapeFuncVisitor) VisitRectangle(r Rectangle) { f(r) }
+
+// VisitCircle 访问圆
+func (f ShapeFuncVisitor) VisitCircle(c Circle) { f(c) }
+
+// VisitTriangle 访问三角形
+func (f ShapeFuncVisitor) VisitTriangle(t Triangle) { f(t) }
+
+// VisitOther 访问其他形状
+func (f ShapeFuncVisitor) VisitOther(s Shape) { f(s) }
+
+// otherTypeName 没有 Accept 方法的形状的类型名，适配器显示被适配的类型，例如 stage2.Rectangle
+func otherTypeName(s Shape) string {
+	return fmt.Sprintf("%T", ToStage2(s))
+}
+
+// TotalArea 统计总面积和总周长
+type TotalArea struct {
+	Count     int
+	Area      float64
+	Perimeter float64
+}
+
+func (t *TotalArea) add(s Shape) {
+	t.Count++
+	t.Area += s.Area()
+	t.Perimeter += s.Perimeter()
+}
+
+// VisitRectangle 访问矩形
+func (t *TotalArea) VisitRectangle(r Rectangle) { t.add(r) }
+
+// VisitCircle 访问圆
+func (t *TotalArea) VisitCircle(c Circle) { t.add(c) }
+
+// VisitTriangle 访问三角形
+func (t *TotalArea) VisitTriangle(tr Triangle) { t.add(tr) }
+
+// VisitOther 访问其他形状
+func (t *TotalArea) VisitOther(s Shape) { t.add(s) }
+
+// TypeHistogram 按形状类型计数
+type TypeHistogram struct {
+	counts map[string]int
+}
+
+func (h *TypeHistogram) add(name string) {
+	if h.counts == nil {
+		h.counts = make(map[string]int)
+	}
+	h.counts[name]++
+}
+
+// VisitRectangle 访问矩形
+func (h *TypeHistogram) VisitRectangle(Rectangle) { h.add("rectangle") }
+
+// VisitCircle 访问圆
+func (h *TypeHistogram) VisitCircle(Circle) { h.add("circle") }
+
+// VisitTriangle 访问三角形
+func (h *TypeHistogram) VisitTriangle(Triangle) { h.add("triangle") }
+
+// VisitOther 访问其他形状，以类型名计数
+func (h *TypeHistogram) VisitOther(s Shape) { h.add(otherTypeName(s)) }
+
+// Count 某类形状的数量
+func (h *TypeHistogram) Count(name string) int { return h.counts[name] }
+
+// Counts 各类形状的数量
+func (h *TypeHistogram) Counts() map[string]int {
+	counts := make(map[string]int, len(h.counts))
+	for k, v := range h.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// String 文本直方图，数量多的在前，数量相同按名称
+func (h *TypeHistogram) String() string {
+	names := make([]string, 0, len(h.counts))
+	width := 0
+	for name := range h.counts {
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if c := h.counts[b] - h.counts[a]; c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%-*s %s %d\n", width, name, strings.Repeat("█", h.counts[name]), h.counts[name])
+	}
+	return b.String()
+}
+
+// JSONVisitor 把形状序列化为 JSON 数组
+//
+// 内置形状的格式与 ShapeFactory.Parse 接受的 JSON 一致，可以原样解析回来；
+// 其他形状只能记录类型名、面积和周长。
+type JSONVisitor struct {
+	items []any
+}
+
+// shapeJSON 内置形状的 JSON 表示
+type shapeJSON struct {
+	Type   string   `json:"type"`
+	Width  *float64 `json:"width,omitempty"`
+	Height *float64 `json:"height,omitempty"`
+	Radius *float64 `json:"radius,omitempty"`
+	Base   *float64 `json:"base,omitempty"`
+	Offset *float64 `json:"offset,omitempty"`
+}
+
+// otherJSON 其他形状的 JSON 表示
+type otherJSON struct {
+	Type      string  `json:"type"`
+	Area      float64 `json:"area"`
+	Perimeter float64 `json:"perimeter"`
+}
+
+// VisitRectangle 访问矩形
+func (j *JSONVisitor) VisitRectangle(r Rectangle) {
+	j.items = append(j.items, shapeJSON{Type: "rectangle", Width: &r.Width, Height: &r.Height})
+}
+
+// VisitCircle 访问圆
+func (j *JSONVisitor) VisitCircle(c Circle) {
+	j.items = append(j.items, shapeJSON{Type: "circle", Radius: &c.Radius})
+}
+
+// VisitTriangle 访问三角形，偏移为零时省略
+func (j *JSONVisitor) VisitTriangle(t Triangle) {
+	v := shapeJSON{Type: "triangle", Base: &t.Base, Height: &t.Height}
+	if t.Offset != 0 {
+		v.Offset = &t.Offset
+	}
+	j.items = append(j.items, v)
+}
+
+// VisitOther 访问其他形状
+func (j *JSONVisitor) VisitOther(s Shape) {
+	j.items = append(j.items, otherJSON{Type: otherTypeName(s), Area: s.Area(), Perimeter: s.Perimeter()})
+}
+
+// MarshalJSON 实现 json.Marshaler，没有访问过形状时为 []
+func (j *JSONVisitor) MarshalJSON() ([]byte, error) {
+	if j.items == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(j.items)
+}
+
+// Largest 找出最大的形状
+type Largest struct {
+	// By 比较方式，为 nil 时按面积；相等时保留先访问到的形状
+	By    ShapeCompare
+	Shape Shape
+}
+
+func (l *Largest) add(s Shape) {
+	by := l.By
+	if by == nil {
+		by = ByArea
+	}
+	if l.Shape == nil || by(s, l.Shape) > 0 {
+		l.Shape = s
+	}
+}
+
+// VisitRectangle 访问矩形
+func (l *Largest) VisitRectangle(r Rectangle) { l.add(r) }
+
+// VisitCircle 访问圆
+func (l *Largest) VisitCircle(c Circle) { l.add(c) }
+
+// VisitTriangle 访问三角形
+func (l *Largest) VisitTriangle(t Triangle) { l.add(t) }
+
+// VisitOther 访问其他形状
+func (l *Largest) VisitOther(s Shape) { l.add(s) }
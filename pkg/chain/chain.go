@@ -0,0 +1,191 @@
+// Package chain 责任链式的请求处理管道
+//
+// 请求依次经过一组 Handler，每个处理器可以：
+//
+//   - 处理后交给下一个：调用 next(req)
+//   - 修改请求或响应：在调用 next 之前改 req，之后改返回的 *Response
+//   - 短路：不调用 next，直接返回响应或错误
+//
+// 链的末端是 Endpoint。同一条链既可以在进程内直接调用 Serve，
+// 也可以作为 http.Handler 或 net/http 中间件使用（见 http.go）。
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrUnauthorized 未认证
+	ErrUnauthorized = errors.New("chain: 未认证")
+	// ErrBadRequest 请求不合法
+	ErrBadRequest = errors.New("chain: 请求不合法")
+	// ErrMethodNotAllowed 请求方法不被允许
+	ErrMethodNotAllowed = errors.New("chain: 不允许的请求方法")
+)
+
+// StatusError 带 HTTP 状态码的错误，Err 通常包装上面的哨兵错误
+type StatusError struct {
+	Status int
+	Err    error
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%d %s: %v", e.Status, http.StatusText(e.Status), e.Err)
+}
+
+func (e *StatusError) Unwrap() error { return e.Err }
+
+// StatusOf 错误对应的 HTTP 状态码，不是 *StatusError 时为 500
+func StatusOf(err error) int {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// Request 在链中流转的请求
+type Request struct {
+	Method   string
+	Path     string
+	RawQuery string // 不含 '?' 的查询字符串，例如 page=2
+	Header   http.Header
+	Body     []byte
+
+	ctx    context.Context
+	values map[any]any
+}
+
+// NewRequest 创建请求
+func NewRequest(ctx context.Context, method, path string, body []byte) *Request {
+	return &Request{
+		Method: method,
+		Path:   path,
+		Header: make(http.Header),
+		Body:   body,
+		ctx:    ctx,
+	}
+}
+
+// Context 请求的上下文
+func (r *Request) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// Set 保存处理器之间传递的数据（例如认证得到的用户）
+func (r *Request) Set(key, value any) {
+	if r.values == nil {
+		r.values = make(map[any]any)
+	}
+	r.values[key] = value
+}
+
+// Get 读取 Set 保存的数据
+func (r *Request) Get(key any) (any, bool) {
+	v, ok := r.values[key]
+	return v, ok
+}
+
+// Response 处理结果
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// NewResponse 创建响应
+func NewResponse(status int, body []byte) *Response {
+	return &Response{Status: status, Header: make(http.Header), Body: body}
+}
+
+// withDefaults 补全直接用字面量构造的响应：Status 为 0 时视为 200，
+// Header 为 nil 时换成空表，后面的处理器可以直接调用 resp.Header.Set
+func withDefaults(resp *Response) *Response {
+	if resp == nil {
+		return nil
+	}
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	return resp
+}
+
+// Next 调用链中的下一个处理器
+type Next func(req *Request) (*Response, error)
+
+// Handler 链中的一个处理器
+type Handler interface {
+	Handle(req *Request, next Next) (*Response, error)
+}
+
+// HandlerFunc 把函数适配为 Handler
+type HandlerFunc func(req *Request, next Next) (*Response, error)
+
+// Handle 调用 f
+func (f HandlerFunc) Handle(req *Request, next Next) (*Response, error) {
+	return f(req, next)
+}
+
+// Endpoint 链的末端，真正处理业务的函数
+type Endpoint func(req *Request) (*Response, error)
+
+// Chain 处理器链，构建完成后可以被并发使用
+type Chain struct {
+	handlers []Handler
+	endpoint Endpoint
+}
+
+// New 创建以 endpoint 结尾的处理器链，handlers 按顺序执行
+func New(endpoint Endpoint, handlers ...Handler) *Chain {
+	return &Chain{handlers: handlers, endpoint: endpoint}
+}
+
+// Use 在链尾（endpoint 之前）追加处理器
+func (c *Chain) Use(handlers ...Handler) *Chain {
+	c.handlers = append(c.handlers, handlers...)
+	return c
+}
+
+// Serve 让请求经过整条链
+func (c *Chain) Serve(req *Request) (*Response, error) {
+	return c.next(0)(req)
+}
+
+// next 返回从第 i 个处理器开始的调用函数
+func (c *Chain) next(i int) Next {
+	if i == len(c.handlers) {
+		return func(req *Request) (*Response, error) {
+			resp, err := c.endpoint(req)
+			return withDefaults(resp), err
+		}
+	}
+	return func(req *Request) (*Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
+		}
+		resp, err := c.handlers[i].Handle(req, c.next(i+1))
+		return withDefaults(resp), err
+	}
+}
+
+// Timing 记录请求耗时并写入响应头 X-Response-Time 的处理器
+func Timing() Handler {
+	return HandlerFunc(func(req *Request, next Next) (*Response, error) {
+		start := time.Now()
+		resp, err := next(req)
+		if resp != nil {
+			resp.Header.Set("X-Response-Time", time.Since(start).String())
+		}
+		return resp, err
+	})
+}
@@ -0,0 +1,317 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/howard/go.study/pkg/validator"
+)
+
+func echo(req *Request) (*Response, error) {
+	user, _ := User(req)
+	return NewResponse(http.StatusOK, []byte(user+":"+req.Path)), nil
+}
+
+func TestChainOrder(t *testing.T) {
+	var trace []string
+	mark := func(name string) Handler {
+		return HandlerFunc(func(req *Request, next Next) (*Response, error) {
+			trace = append(trace, name+">")
+			resp, err := next(req)
+			trace = append(trace, "<"+name)
+			return resp, err
+		})
+	}
+	c := New(func(req *Request) (*Response, error) {
+		trace = append(trace, "endpoint")
+		return NewResponse(http.StatusOK, nil), nil
+	}, mark("a")).Use(mark("b"))
+
+	if _, err := c.Serve(NewRequest(context.Background(), http.MethodGet, "/", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(trace, " "); got != "a> b> endpoint <b <a" {
+		t.Errorf("unexpected order %q", got)
+	}
+}
+
+func TestChainCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := New(echo, Timing())
+	if _, err := c.Serve(NewRequest(ctx, http.MethodGet, "/", nil)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+type createUser struct {
+	Name string `json:"name" validate:"required"`
+	Age  int    `json:"age" validate:"min=0,max=150"`
+}
+
+func TestHandlers(t *testing.T) {
+	tokens := NewTokenTable(map[string]string{"t1": "alice"})
+	c := New(func(req *Request) (*Response, error) {
+		if u, ok := Body[createUser](req); ok {
+			return NewResponse(http.StatusCreated, []byte(u.Name)), nil
+		}
+		return echo(req)
+	},
+		Auth(tokens),
+		Validate(AllowMethods(http.MethodGet, http.MethodPost), MaxBodySize(64), JSONBody[createUser](validator.New())),
+	)
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		status int
+		err    error
+		want   string
+	}{
+		{"ok", http.MethodGet, "t1", "", http.StatusOK, nil, "alice:/x"},
+		{"missing token", http.MethodGet, "", "", http.StatusUnauthorized, ErrUnauthorized, ""},
+		{"bad token", http.MethodGet, "nope", "", http.StatusUnauthorized, ErrUnauthorized, ""},
+		{"method", http.MethodDelete, "t1", "", http.StatusMethodNotAllowed, ErrMethodNotAllowed, ""},
+		{"too large", http.MethodPost, "t1", strings.Repeat("x", 65), http.StatusRequestEntityTooLarge, ErrBadRequest, ""},
+		{"bad json", http.MethodPost, "t1", "{", http.StatusBadRequest, ErrBadRequest, ""},
+		{"invalid", http.MethodPost, "t1", `{"age":200}`, http.StatusUnprocessableEntity, ErrBadRequest, ""},
+		{"created", http.MethodPost, "t1", `{"name":"bob","age":30}`, http.StatusCreated, nil, "bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := NewRequest(context.Background(), tt.method, "/x", []byte(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := c.Serve(req)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) || StatusOf(err) != tt.status {
+					t.Fatalf("expected %v (%d), got %v (%d)", tt.err, tt.status, err, StatusOf(err))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Status != tt.status || string(resp.Body) != tt.want {
+				t.Errorf("expected %d %q, got %d %q", tt.status, tt.want, resp.Status, resp.Body)
+			}
+		})
+	}
+
+	tokens.Revoke("t1")
+	req := NewRequest(context.Background(), http.MethodGet, "/x", nil)
+	req.Header.Set("Authorization", "Bearer t1")
+	if _, err := c.Serve(req); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("revoked token: expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	c := New(func(req *Request) (*Response, error) {
+		calls++
+		if req.Path == "/missing" {
+			return NewResponse(http.StatusNotFound, nil), nil
+		}
+		return echo(req)
+	},
+		Auth(NewTokenTable(map[string]string{"a": "alice", "b": "bob"})),
+		Cache(time.Minute, WithCacheClock(func() time.Time { return now }), WithCacheSize(2)),
+	)
+	get := func(token, path string) *Response {
+		t.Helper()
+		req := NewRequest(context.Background(), http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := c.Serve(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	steps := []struct {
+		token, path string
+		advance     time.Duration
+		cache       string
+		calls       int
+	}{
+		{"a", "/x", 0, "MISS", 1},
+		{"a", "/x", 30 * time.Second, "HIT", 1},
+		{"b", "/x", 0, "MISS", 2},   // 不同用户不共享缓存
+		{"a", "/missing", 0, "", 3}, // 非 200 不缓存
+		{"a", "/missing", 0, "", 4},
+		{"a", "/x", 31 * time.Second, "MISS", 5}, // 过期
+		{"a", "/y", 0, "MISS", 6},                // 超出容量，淘汰最早过期的 b /x
+		{"b", "/x", 0, "MISS", 7},
+	}
+	for i, s := range steps {
+		now = now.Add(s.advance)
+		resp := get(s.token, s.path)
+		if got := resp.Header.Get("X-Cache"); got != s.cache || calls != s.calls {
+			t.Errorf("step %d: expected X-Cache %q after %d calls, got %q after %d", i, s.cache, s.calls, got, calls)
+		}
+	}
+
+	// 修改命中的响应不影响缓存
+	resp := get("b", "/x")
+	resp.Body[0] = 'X'
+	if resp := get("b", "/x"); string(resp.Body) != "bob:/x" {
+		t.Errorf("cached body was modified: %q", resp.Body)
+	}
+}
+
+func TestAudit(t *testing.T) {
+	var entries []AuditEntry
+	c := New(echo,
+		Audit(func(e AuditEntry) { entries = append(entries, e) }),
+		Auth(NewTokenTable(map[string]string{"t": "alice"})),
+	)
+	for _, token := range []string{"t", "bad"} {
+		req := NewRequest(context.Background(), http.MethodGet, "/a", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		c.Serve(req)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.User != "alice" || e.Status != http.StatusOK || e.Err != nil {
+		t.Errorf("unexpected entry %v", e)
+	}
+	if e := entries[1]; e.User != "" || e.Status != http.StatusUnauthorized || !strings.Contains(e.String(), " - GET /a 401 ") {
+		t.Errorf("unexpected entry %v", e)
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	tokens := NewTokenTable(map[string]string{"t": "alice"})
+	var audited []int
+	// 下游 handler 读取链中认证得到的用户和（被修改过的）请求头
+	h := Middleware(
+		Audit(func(e AuditEntry) { audited = append(audited, e.Status) }),
+		Auth(tokens),
+		HandlerFunc(func(req *Request, next Next) (*Response, error) {
+			req.Header.Set("X-User", "set-by-chain")
+			return next(req)
+		}),
+		Cache(time.Minute),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := FromContext(r.Context())
+		user, _ := User(req)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, user+" "+r.Header.Get("X-User")+" "+string(body))
+	}))
+
+	tests := []struct {
+		method, token, body string
+		status              int
+		want                string
+	}{
+		{http.MethodPost, "t", "hi", http.StatusAccepted, "alice set-by-chain hi"},
+		{http.MethodGet, "", "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/p", strings.NewReader(tt.body))
+		if tt.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d", tt.method, tt.status, w.Code)
+		}
+		if tt.want != "" && (w.Body.String() != tt.want || w.Header().Get("Content-Type") != "text/plain") {
+			t.Errorf("%s: unexpected response %q %v", tt.method, w.Body, w.Header())
+		}
+	}
+	if len(audited) != 2 || audited[0] != http.StatusAccepted || audited[1] != http.StatusUnauthorized {
+		t.Errorf("unexpected audit %v", audited)
+	}
+
+	// 链本身也是 http.Handler
+	srv := httptest.NewServer(New(echo, Auth(tokens)))
+	defer srv.Close()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/q", nil)
+	req.Header.Set("Authorization", "Bearer t")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "alice:/q" {
+		t.Errorf("unexpected response %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestHTTPQueryAndBodyLimit(t *testing.T) {
+	calls := 0
+	h := Middleware(Cache(time.Minute))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		io.WriteString(w, r.URL.RawQuery)
+	}))
+
+	// 同一路径的不同查询字符串分别缓存
+	tests := []struct {
+		target, want, cache string
+		calls               int
+	}{
+		{"/items?page=1", "page=1", "MISS", 1},
+		{"/items?page=2", "page=2", "MISS", 2},
+		{"/items?page=1", "page=1", "HIT", 2},
+		{"/items", "", "MISS", 3},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if w.Body.String() != tt.want || w.Header().Get("X-Cache") != tt.cache || calls != tt.calls {
+			t.Errorf("%s: expected %q %s after %d calls, got %q %s after %d",
+				tt.target, tt.want, tt.cache, tt.calls, w.Body, w.Header().Get("X-Cache"), calls)
+		}
+	}
+
+	// 超过上限的请求体返回 413，不会把截断的内容交给处理器
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(strings.Repeat("x", MaxHTTPBody+1))))
+	if w.Code != http.StatusRequestEntityTooLarge || calls != 3 {
+		t.Errorf("oversized body: expected 413 without calling handler, got %d after %d calls", w.Code, calls)
+	}
+}
+
+func TestHTTPResponseDefaults(t *testing.T) {
+	// 端点直接返回字面量：没有 Status 和 Header
+	literal := func(req *Request) (*Response, error) {
+		if req.Path == "/fail" {
+			return nil, errors.New("db: password=secret")
+		}
+		return &Response{Body: []byte("ok")}, nil
+	}
+	h := New(literal, Timing(), Cache(time.Minute))
+
+	tests := []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/ok", http.StatusOK, "ok"},
+		{"/fail", http.StatusInternalServerError, "Internal Server Error\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.status || w.Body.String() != tt.want {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.path, tt.status, tt.want, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package chain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/howard/go.study/pkg/validator"
+)
+
+// ctxKey Request.Set 使用的内部键
+type ctxKey int
+
+const (
+	userKey ctxKey = iota
+	bodyKey
+)
+
+// User 认证处理器写入的用户名
+func User(req *Request) (string, bool) {
+	v, ok := req.Get(userKey)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// TokenTable 令牌到用户名的映射，可并发读写
+type TokenTable struct {
+	mu     sync.RWMutex
+	tokens map[string]string
+}
+
+// NewTokenTable 以 token->user 的映射创建令牌表
+func NewTokenTable(tokens map[string]string) *TokenTable {
+	t := &TokenTable{tokens: make(map[string]string, len(tokens))}
+	for k, v := range tokens {
+		t.tokens[k] = v
+	}
+	return t
+}
+
+// Add 添加或更新令牌
+func (t *TokenTable) Add(token, user string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tokens[token] = user
+}
+
+// Revoke 吊销令牌
+func (t *TokenTable) Revoke(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.tokens, token)
+}
+
+// Lookup 查找令牌对应的用户
+func (t *TokenTable) Lookup(token string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	user, ok := t.tokens[token]
+	return user, ok
+}
+
+// Auth 认证处理器：从 Authorization: Bearer <token> 中取出令牌并在令牌表中查找，
+// 成功后把用户名存入请求（用 User 读取），失败时以 401 短路
+func Auth(tokens *TokenTable) Handler {
+	return HandlerFunc(func(req *Request, next Next) (*Response, error) {
+		token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			return nil, &StatusError{Status: http.StatusUnauthorized, Err: fmt.Errorf("%w: 缺少令牌", ErrUnauthorized)}
+		}
+		user, ok := tokens.Lookup(token)
+		if !ok {
+			return nil, &StatusError{Status: http.StatusUnauthorized, Err: fmt.Errorf("%w: 无效的令牌", ErrUnauthorized)}
+		}
+		req.Set(userKey, user)
+		return next(req)
+	})
+}
+
+// Rule 校验规则，返回的错误应当是 *StatusError
+type Rule func(req *Request) error
+
+// Validate 校验处理器：依次检查规则，第一个失败的规则会短路整条链
+func Validate(rules ...Rule) Handler {
+	return HandlerFunc(func(req *Request, next Next) (*Response, error) {
+		for _, rule := range rules {
+			if err := rule(req); err != nil {
+				return nil, err
+			}
+		}
+		return next(req)
+	})
+}
+
+// AllowMethods 只允许指定的请求方法
+func AllowMethods(methods ...string) Rule {
+	return func(req *Request) error {
+		if !slices.Contains(methods, req.Method) {
+			return &StatusError{Status: http.StatusMethodNotAllowed, Err: fmt.Errorf("%w: %s", ErrMethodNotAllowed, req.Method)}
+		}
+		return nil
+	}
+}
+
+// MaxBodySize 限制请求体大小
+func MaxBodySize(n int) Rule {
+	return func(req *Request) error {
+		if len(req.Body) > n {
+			return &StatusError{Status: http.StatusRequestEntityTooLarge, Err: fmt.Errorf("%w: 请求体 %d 字节，上限 %d", ErrBadRequest, len(req.Body), n)}
+		}
+		return nil
+	}
+}
+
+// RequireHeader 要求请求带有指定的头
+func RequireHeader(name string) Rule {
+	return func(req *Request) error {
+		if req.Header.Get(name) == "" {
+			return &StatusError{Status: http.StatusBadRequest, Err: fmt.Errorf("%w: 缺少请求头 %s", ErrBadRequest, name)}
+		}
+		return nil
+	}
+}
+
+// JSONBody 把请求体解码为 T 并用 v 按 validate 标签校验，成功后可用 Body[T] 取出
+//
+// 没有请求体的请求（例如 GET）不做检查。v 为 nil 时只解码不校验。
+func JSONBody[T any](v *validator.Validator) Rule {
+	return func(req *Request) error {
+		if len(req.Body) == 0 {
+			return nil
+		}
+		var body T
+		if err := json.Unmarshal(req.Body, &body); err != nil {
+			return &StatusError{Status: http.StatusBadRequest, Err: fmt.Errorf("%w: %v", ErrBadRequest, err)}
+		}
+		if v != nil {
+			if err := v.Struct(&body); err != nil {
+				return &StatusError{Status: http.StatusUnprocessableEntity, Err: fmt.Errorf("%w: %w", ErrBadRequest, err)}
+			}
+		}
+		req.Set(bodyKey, body)
+		return nil
+	}
+}
+
+// Body JSONBody 解码得到的请求体
+func Body[T any](req *Request) (T, bool) {
+	v, ok := req.Get(bodyKey)
+	body, ok2 := v.(T)
+	return body, ok && ok2
+}
+
+// CacheOption 缓存选项
+type CacheOption func(*cache)
+
+// WithCacheClock 设置缓存使用的时钟，便于测试
+func WithCacheClock(now func() time.Time) CacheOption {
+	return func(c *cache) {
+		c.now = now
+	}
+}
+
+// WithCacheSize 设置最多缓存的条目数，超出时淘汰最早过期的条目，默认 1024
+func WithCacheSize(n int) CacheOption {
+	return func(c *cache) {
+		c.size = n
+	}
+}
+
+type cacheEntry struct {
+	resp    *Response
+	expires time.Time
+}
+
+// cacheKey 缓存键，各部分分开保存，用户名或路径中的任何字符都不会造成混淆
+type cacheKey struct {
+	user, path, query string
+}
+
+type cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	now     func() time.Time
+	entries map[cacheKey]cacheEntry
+}
+
+// Cache 缓存处理器：缓存 GET 请求的 200 响应 ttl 时长，命中时不再调用后续处理器
+//
+// 缓存键由用户名、路径和查询字符串组成，因此应放在 Auth 之后，避免不同用户共享响应。
+// 响应头 X-Cache 标明 HIT 或 MISS。
+func Cache(ttl time.Duration, opts ...CacheOption) Handler {
+	c := &cache{ttl: ttl, size: 1024, now: time.Now, entries: make(map[cacheKey]cacheEntry)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return HandlerFunc(c.handle)
+}
+
+func (c *cache) handle(req *Request, next Next) (*Response, error) {
+	if req.Method != http.MethodGet {
+		return next(req)
+	}
+	user, _ := User(req)
+	key := cacheKey{user: user, path: req.Path, query: req.RawQuery}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	now := c.now()
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		resp := entry.resp.clone()
+		resp.Header.Set("X-Cache", "HIT")
+		return resp, nil
+	}
+
+	resp, err := next(req)
+	if err != nil || resp == nil || resp.Status != http.StatusOK {
+		return resp, err
+	}
+	resp.Header.Set("X-Cache", "MISS")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.size {
+		c.evict(now)
+	}
+	c.entries[key] = cacheEntry{resp: resp.clone(), expires: now.Add(c.ttl)}
+	return resp, nil
+}
+
+// evict 删除过期条目，仍然满时删除最早过期的一条
+func (c *cache) evict(now time.Time) {
+	var (
+		oldest cacheKey
+		found  bool
+	)
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+			continue
+		}
+		if !found || e.expires.Before(c.entries[oldest].expires) {
+			oldest, found = k, true
+		}
+	}
+	if len(c.entries) >= c.size && found {
+		delete(c.entries, oldest)
+	}
+}
+
+func (r *Response) clone() *Response {
+	return &Response{Status: r.Status, Header: r.Header.Clone(), Body: slices.Clone(r.Body)}
+}
+
+// AuditEntry 一条审计记录
+type AuditEntry struct {
+	Time     time.Time
+	User     string
+	Method   string
+	Path     string
+	Status   int
+	Duration time.Duration
+	Err      error
+}
+
+// String 审计记录的单行表示
+func (e AuditEntry) String() string {
+	user := e.User
+	if user == "" {
+		user = "-"
+	}
+	s := fmt.Sprintf("%s %s %s %s %d %s", e.Time.Format(time.RFC3339), user, e.Method, e.Path, e.Status, e.Duration)
+	if e.Err != nil {
+		s += " err=" + e.Err.Error()
+	}
+	return s
+}
+
+// Audit 审计处理器：请求结束后把结果交给 sink，不改变请求和响应
+//
+// 放在链的最前面可以记录被后续处理器拒绝的请求；用户名在 Auth 之后才可用。
+func Audit(sink func(AuditEntry)) Handler {
+	return HandlerFunc(func(req *Request, next Next) (*Response, error) {
+		start := time.Now()
+		resp, err := next(req)
+
+		entry := AuditEntry{Time: start, Method: req.Method, Path: req.Path, Duration: time.Since(start), Err: err}
+		entry.User, _ = User(req)
+		switch {
+		case err != nil:
+			entry.Status = StatusOf(err)
+		case resp != nil:
+			entry.Status = resp.Status
+		}
+		sink(entry)
+		return resp, err
+	})
+}
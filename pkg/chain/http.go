@@ -0,0 +1,133 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// MaxHTTPBody 从 HTTP 请求读取的最大请求体，超出时返回 413
+const MaxHTTPBody = 10 << 20
+
+// requestKey 在 http.Request 的 context 中保存 *Request 的键
+type requestKey struct{}
+
+// FromContext 取出中间件为下游 http.Handler 保存的 *Request，
+// 通过它可以读取 User、Body 等处理器写入的数据
+func FromContext(ctx context.Context) (*Request, bool) {
+	req, ok := ctx.Value(requestKey{}).(*Request)
+	return req, ok
+}
+
+// fromHTTP 把 http.Request 转换为链中的请求
+func fromHTTP(r *http.Request) (*Request, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(r.Body, MaxHTTPBody+1))
+		if err != nil {
+			return nil, &StatusError{Status: http.StatusBadRequest, Err: errors.Join(ErrBadRequest, err)}
+		}
+		if len(body) > MaxHTTPBody {
+			return nil, &StatusError{Status: http.StatusRequestEntityTooLarge, Err: fmt.Errorf("%w: 请求体超过 %d 字节", ErrBadRequest, MaxHTTPBody)}
+		}
+	}
+	req := NewRequest(r.Context(), r.Method, r.URL.Path, body)
+	req.RawQuery = r.URL.RawQuery
+	req.Header = r.Header.Clone()
+	return req, nil
+}
+
+// writeResponse 把链的结果写回 HTTP 响应
+//
+// 只有 *StatusError 的内容会发给客户端，其他错误可能带有内部细节，
+// 只回复状态码对应的文字。
+func writeResponse(w http.ResponseWriter, resp *Response, err error) {
+	if err != nil {
+		var se *StatusError
+		if errors.As(err, &se) {
+			http.Error(w, err.Error(), se.Status)
+		} else {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	resp = withDefaults(resp)
+	for k, vs := range resp.Header {
+		w.Header()[k] = vs
+	}
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// ServeHTTP 让链直接作为 http.Handler 使用
+func (c *Chain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := fromHTTP(r)
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+	resp, err := c.Serve(req)
+	writeResponse(w, resp, err)
+}
+
+// Middleware 把处理器包装成 net/http 中间件
+//
+// 链的末端调用下游的 http.Handler：处理器对请求头和请求体的修改会传给下游，
+// 下游写出的响应会先被记录下来，处理器可以在返回途中修改它（例如 Cache 会缓存它）。
+func Middleware(handlers ...Handler) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req, err := fromHTTP(r)
+			if err != nil {
+				writeResponse(w, nil, err)
+				return
+			}
+			downstream := func(req *Request) (*Response, error) {
+				r := r.Clone(context.WithValue(req.Context(), requestKey{}, req))
+				r.Method = req.Method
+				r.URL.Path = req.Path
+				r.URL.RawQuery = req.RawQuery
+				r.Header = req.Header
+				r.Body = io.NopCloser(bytes.NewReader(req.Body))
+				r.ContentLength = int64(len(req.Body))
+
+				rec := &recorder{resp: NewResponse(http.StatusOK, nil)}
+				h.ServeHTTP(rec, r)
+				return rec.resp, nil
+			}
+			resp, err := New(downstream, handlers...).Serve(req)
+			writeResponse(w, resp, err)
+		})
+	}
+}
+
+// recorder 记录下游 http.Handler 写出的响应
+type recorder struct {
+	resp        *Response
+	wroteHeader bool
+	buf         bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.resp.Header }
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.resp.Status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	n, err := r.buf.Write(p)
+	r.resp.Body = r.buf.Bytes()
+	return n, err
+}
@@ -0,0 +1,441 @@
+// Package diff 基于反射的结构化比较
+//
+// Diff 递归比较结构体、映射、切片、数组、指针和接口，返回以 JSON Pointer（RFC 6901）
+// 标识位置的变更列表。路径按 encoding/json 的规则生成：结构体字段使用 json 标签名，
+// 忽略未导出字段和 `json:"-"`，匿名嵌入的结构体会被展开。
+// 变更列表可以转换为 JSON Patch（RFC 6902）并应用到 JSON 文档上。
+package diff
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported 无法比较的类型（函数、通道等）
+var ErrUnsupported = errors.New("diff: 不支持的类型")
+
+// Kind 变更类型
+type Kind int
+
+const (
+	// Added 新增
+	Added Kind = iota
+	// Removed 删除
+	Removed
+	// Modified 修改
+	Modified
+)
+
+// String 变更类型的名称
+func (k Kind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	default:
+		return "Kind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Change 一处变更
+type Change struct {
+	Kind Kind
+	Path string // JSON Pointer，根为 ""
+	From any    // 旧值，Added 时为 nil
+	To   any    // 新值，Removed 时为 nil
+}
+
+// String 变更的字符串表示
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "/"
+	}
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %v", path, c.To)
+	case Removed:
+		return fmt.Sprintf("- %s: %v", path, c.From)
+	default:
+		return fmt.Sprintf("~ %s: %v -> %v", path, c.From, c.To)
+	}
+}
+
+// Diff 比较 a 和 b，返回把 a 变为 b 所需的变更
+//
+// 切片按下标比较，多出的元素记为 Added，缺少的元素按下标从大到小记为 Removed，
+// 这样转换成 JSON Patch 后可以按顺序应用。nil 与空映射（或空切片）视为不同。
+// 实现了 json.Marshaler 或 encoding.TextMarshaler 的类型（例如 time.Time）按序列化结果整体比较。
+func Diff(a, b any) ([]Change, error) {
+	d := &differ{visited: make(map[visit]bool)}
+	if err := d.diff("", reflect.ValueOf(a), reflect.ValueOf(b)); err != nil {
+		return nil, err
+	}
+	return d.changes, nil
+}
+
+// Equal 在 Diff 的语义下 a 和 b 是否相等
+func Equal(a, b any) (bool, error) {
+	changes, err := Diff(a, b)
+	return len(changes) == 0, err
+}
+
+// visit 已比较过的指针对，用于处理循环引用
+type visit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+type differ struct {
+	changes []Change
+	visited map[visit]bool
+}
+
+func (d *differ) add(kind Kind, path string, from, to reflect.Value) {
+	d.changes = append(d.changes, Change{Kind: kind, Path: path, From: iface(from), To: iface(to)})
+}
+
+// iface 取出值，无效值返回 nil
+func iface(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+var (
+	jsonMarshaler = reflect.TypeFor[json.Marshaler]()
+	textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func (d *differ) diff(path string, a, b reflect.Value) error {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			d.add(Modified, path, a, b)
+		}
+		return nil
+	}
+	if a.Type() != b.Type() {
+		d.add(Modified, path, a, b)
+		return nil
+	}
+
+	t := a.Type()
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface &&
+		(t.Implements(jsonMarshaler) || t.Implements(textMarshaler)) {
+		return d.diffMarshaled(path, a, b)
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(Modified, path, a, b)
+			}
+			return nil
+		}
+		if a.Pointer() == b.Pointer() {
+			return nil
+		}
+		key := visit{a.Pointer(), b.Pointer(), t}
+		if d.visited[key] {
+			return nil
+		}
+		d.visited[key] = true
+		return d.diff(path, a.Elem(), b.Elem())
+
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				d.add(Modified, path, a, b)
+			}
+			return nil
+		}
+		return d.diff(path, a.Elem(), b.Elem())
+
+	case reflect.Struct:
+		return d.diffStruct(path, a, b)
+
+	case reflect.Map:
+		return d.diffMap(path, a, b)
+
+	case reflect.Slice:
+		if a.IsNil() != b.IsNil() {
+			d.add(Modified, path, a, b)
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			// []byte 在 JSON 中是 base64 字符串，整体比较
+			if !bytes.Equal(a.Bytes(), b.Bytes()) {
+				d.add(Modified, path, a, b)
+			}
+			return nil
+		}
+		return d.diffList(path, a, b)
+
+	case reflect.Array:
+		return d.diffList(path, a, b)
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return fmt.Errorf("%w: %s (%s)", ErrUnsupported, t, pathOrRoot(path))
+
+	default:
+		if !a.Equal(b) {
+			d.add(Modified, path, a, b)
+		}
+		return nil
+	}
+}
+
+// diffMarshaled 按 JSON 序列化结果比较
+func (d *differ) diffMarshaled(path string, a, b reflect.Value) error {
+	ja, err := json.Marshal(a.Interface())
+	if err != nil {
+		return fmt.Errorf("diff: 序列化 %s 失败: %w", pathOrRoot(path), err)
+	}
+	jb, err := json.Marshal(b.Interface())
+	if err != nil {
+		return fmt.Errorf("diff: 序列化 %s 失败: %w", pathOrRoot(path), err)
+	}
+	if !bytes.Equal(ja, jb) {
+		d.add(Modified, path, a, b)
+	}
+	return nil
+}
+
+func (d *differ) diffStruct(path string, a, b reflect.Value) error {
+	for _, f := range fieldsOf(a.Type()) {
+		// 嵌入的结构体指针为 nil 时其字段不出现在 JSON 中，omitempty 的空值也一样，
+		// 字段出现与否的变化对应新增或删除
+		fa, errA := a.FieldByIndexErr(f.index)
+		fb, errB := b.FieldByIndexErr(f.index)
+		pa := errA == nil && !(f.omitEmpty && isEmpty(fa))
+		pb := errB == nil && !(f.omitEmpty && isEmpty(fb))
+		p := path + "/" + EscapeToken(f.name)
+		switch {
+		case !pa && !pb:
+		case !pa:
+			d.add(Added, p, reflect.Value{}, fb)
+		case !pb:
+			d.add(Removed, p, fa, reflect.Value{})
+		default:
+			if err := d.diff(p, fa, fb); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *differ) diffMap(path string, a, b reflect.Value) error {
+	if a.IsNil() != b.IsNil() {
+		d.add(Modified, path, a, b)
+		return nil
+	}
+
+	keys := make(map[string]reflect.Value)
+	for _, m := range []reflect.Value{a, b} {
+		iter := m.MapRange()
+		for iter.Next() {
+			k, err := keyString(iter.Key())
+			if err != nil {
+				return fmt.Errorf("%w (%s)", err, pathOrRoot(path))
+			}
+			keys[k] = iter.Key()
+		}
+	}
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := keys[name]
+		va, vb := a.MapIndex(key), b.MapIndex(key)
+		p := path + "/" + EscapeToken(name)
+		switch {
+		case !va.IsValid():
+			d.add(Added, p, reflect.Value{}, vb)
+		case !vb.IsValid():
+			d.add(Removed, p, va, reflect.Value{})
+		default:
+			if err := d.diff(p, va, vb); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *differ) diffList(path string, a, b reflect.Value) error {
+	common := min(a.Len(), b.Len())
+	for i := 0; i < common; i++ {
+		if err := d.diff(path+"/"+strconv.Itoa(i), a.Index(i), b.Index(i)); err != nil {
+			return err
+		}
+	}
+	for i := common; i < b.Len(); i++ {
+		d.add(Added, path+"/"+strconv.Itoa(i), reflect.Value{}, b.Index(i))
+	}
+	for i := a.Len() - 1; i >= common; i-- {
+		d.add(Removed, path+"/"+strconv.Itoa(i), a.Index(i), reflect.Value{})
+	}
+	return nil
+}
+
+// keyString 把映射键转为 JSON 对象的键
+func keyString(k reflect.Value) (string, error) {
+	switch k.Kind() {
+	case reflect.String:
+		return k.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("%w: 映射键类型 %s", ErrUnsupported, k.Type())
+	}
+}
+
+// field 参与比较的结构体字段
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	tagged    bool // 名字来自 json 标签
+}
+
+// fieldsOf 按 encoding/json 的规则列出字段，匿名嵌入的结构体和结构体指针展开
+//
+// 同名字段按 encoding/json 的规则取舍：嵌入层次最浅的胜出，
+// 层次相同时带 json 标签的胜出，仍然无法区分时这些字段都被忽略。
+func fieldsOf(t reflect.Type) []field {
+	all := embeddedFields(t, map[reflect.Type]bool{t: true})
+	byName := make(map[string][]field, len(all))
+	for _, f := range all {
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	var fields []field
+	for _, f := range all {
+		dominant, ok := dominantField(byName[f.name])
+		if ok && slices.Equal(dominant.index, f.index) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// dominantField 从同名字段中选出 encoding/json 会使用的那一个
+func dominantField(fields []field) (field, bool) {
+	depth := len(fields[0].index)
+	for _, f := range fields[1:] {
+		depth = min(depth, len(f.index))
+	}
+	var winner field
+	found, tagged := 0, 0
+	for _, f := range fields {
+		if len(f.index) != depth {
+			continue
+		}
+		switch {
+		case f.tagged && tagged == 0:
+			winner, found, tagged = f, 1, 1
+		case f.tagged:
+			tagged++
+		case tagged == 0:
+			winner = f
+			found++
+		}
+	}
+	return winner, tagged == 1 || (tagged == 0 && found == 1)
+}
+
+// embeddedFields 列出 t 的字段，seen 记录展开路径上的类型，避免自引用的嵌入无限展开
+func embeddedFields(t reflect.Type, seen map[reflect.Type]bool) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if et := sf.Type; sf.Anonymous && name == "" {
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				if seen[et] {
+					continue
+				}
+				seen[et] = true
+				for _, f := range embeddedFields(et, seen) {
+					f.index = append([]int{i}, f.index...)
+					fields = append(fields, f)
+				}
+				delete(seen, et)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		tagged := name != ""
+		if !tagged {
+			name = sf.Name
+		}
+		fields = append(fields, field{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+			tagged:    tagged,
+		})
+	}
+	return fields
+}
+
+// isEmpty 与 encoding/json 的 omitempty 判定一致
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// EscapeToken 按 RFC 6901 转义路径中的一段
+func EscapeToken(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// UnescapeToken 还原 EscapeToken 转义的路径段
+func UnescapeToken(s string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,345 @@
+package diff
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type Base struct {
+	ID int `json:"id"`
+}
+
+type person struct {
+	Base
+	Name     string            `json:"name"`
+	Age      int               `json:"age"`
+	Tags     []string          `json:"tags"`
+	Address  *address          `json:"address"`
+	Scores   map[string]int    `json:"scores"`
+	Meta     map[string]any    `json:"meta,omitempty"`
+	Nick     string            `json:"nick,omitempty"`
+	Born     time.Time         `json:"born"`
+	Secret   string            `json:"-"`
+	internal int               //nolint:unused // 未导出字段不参与比较
+	Extra    map[int]time.Time `json:"extra,omitempty"`
+}
+
+func samplePerson() person {
+	return person{
+		Base:    Base{ID: 1},
+		Name:    "Alice",
+		Age:     20,
+		Tags:    []string{"go", "rust"},
+		Address: &address{City: "Beijing"},
+		Scores:  map[string]int{"math": 90, "physics": 85},
+		Born:    time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := samplePerson()
+	b := samplePerson()
+	b.ID = 2
+	b.Name = "Alicia"
+	b.Tags = []string{"go", "zig", "c"}
+	b.Address = &address{City: "Shanghai", Zip: "200000"}
+	b.Scores = map[string]int{"math": 95, "chemistry": 70}
+	b.Nick = "ali"
+	b.Born = b.Born.Add(time.Hour)
+	b.Secret = "ignored"
+	b.internal = 42
+
+	changes, err := Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Modified, "/id", 1, 2},
+		{Modified, "/name", "Alice", "Alicia"},
+		{Modified, "/tags/1", "rust", "zig"},
+		{Added, "/tags/2", nil, "c"},
+		{Modified, "/address/city", "Beijing", "Shanghai"},
+		{Added, "/address/zip", nil, "200000"},
+		{Added, "/scores/chemistry", nil, 70},
+		{Modified, "/scores/math", 90, 95},
+		{Removed, "/scores/physics", 85, nil},
+		{Added, "/nick", nil, "ali"},
+		{Modified, "/born", a.Born, b.Born},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("unexpected changes:\n got %v\nwant %v", changes, want)
+	}
+}
+
+func TestDiffEdgeCases(t *testing.T) {
+	type node struct {
+		Value int
+		Next  *node
+	}
+	cycleA := &node{Value: 1}
+	cycleA.Next = cycleA
+	cycleB := &node{Value: 2}
+	cycleB.Next = cycleB
+
+	tests := []struct {
+		name string
+		a, b any
+		want []string
+	}{
+		{"equal", samplePerson(), samplePerson(), nil},
+		{"root type change", 1, "1", []string{"~ /: 1 -> 1"}},
+		{"nil vs value", nil, 1, []string{"~ /: <nil> -> 1"}},
+		{"nil slice vs empty", []int(nil), []int{}, []string{"~ /: [] -> []"}},
+		{"shrinking slice removes from the end", []int{1, 2, 3, 4}, []int{1}, []string{"- /3: 4", "- /2: 3", "- /1: 2"}},
+		{"interface type change", []any{1, "x"}, []any{1.0, "x"}, []string{"~ /0: 1 -> 1"}},
+		{"escaped keys", map[string]int{"a/b": 1, "c~d": 1}, map[string]int{"a/b": 2, "c~d": 2}, []string{"~ /a~1b: 1 -> 2", "~ /c~0d: 1 -> 2"}},
+		{"int keys", map[int]string{10: "a"}, map[int]string{10: "b"}, []string{"~ /10: a -> b"}},
+		{"bytes", []byte("ab"), []byte("ac"), []string{"~ /: [97 98] -> [97 99]"}},
+		{"cycle", cycleA, cycleB, []string{"~ /Value: 1 -> 2"}},
+		{"arrays", [2]int{1, 2}, [2]int{1, 3}, []string{"~ /1: 2 -> 3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Diff(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range changes {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDiffUnsupported(t *testing.T) {
+	type withFunc struct{ F func() }
+	if _, err := Diff(withFunc{}, withFunc{}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+	if _, err := Diff(map[float64]int{1: 1}, map[float64]int{1: 2}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported for float keys, got %v", err)
+	}
+}
+
+type withEmbeddedPointer struct {
+	*Base
+	Name string `json:"name"`
+}
+
+func TestDiffEmbeddedPointer(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b withEmbeddedPointer
+		want []string
+	}{
+		{"both nil", withEmbeddedPointer{Name: "a"}, withEmbeddedPointer{Name: "a"}, nil},
+		{"set", withEmbeddedPointer{}, withEmbeddedPointer{Base: &Base{ID: 2}}, []string{"+ /id: 2"}},
+		{"cleared", withEmbeddedPointer{Base: &Base{ID: 2}}, withEmbeddedPointer{}, []string{"- /id: 2"}},
+		{"changed", withEmbeddedPointer{Base: &Base{ID: 1}}, withEmbeddedPointer{Base: &Base{ID: 2}}, []string{"~ /id: 1 -> 2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Diff(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range changes {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+
+			// 路径与 encoding/json 展开后的文档一致，补丁可以直接应用
+			a := tt.a
+			patch, err := CreatePatch(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := patch.ApplyTo(&a); err != nil {
+				t.Fatalf("apply %s: %v", patch, err)
+			}
+			wantJSON, _ := json.Marshal(tt.b)
+			gotJSON, _ := json.Marshal(a)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("patch %s\n got %s\nwant %s", patch, gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+type named struct{ Name string }
+
+type otherNamed struct{ Name string }
+
+type taggedNamed struct {
+	Label string `json:"Name"`
+}
+
+// shadowed 外层的 Name 遮住 named.Name
+type shadowed struct {
+	named
+	Name string
+}
+
+// tieTagged 同一层的两个 Name 中带标签的胜出
+type tieTagged struct {
+	named
+	taggedNamed
+}
+
+// conflicting 同一层的两个 Name 都不带标签，都被忽略
+type conflicting struct {
+	named
+	otherNamed
+}
+
+func TestDiffShadowedFields(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b any
+		want []string
+	}{
+		{"outer wins", shadowed{named{"a"}, "x"}, shadowed{named{"b"}, "y"}, []string{"~ /Name: x -> y"}},
+		{"hidden only", shadowed{named{"a"}, "x"}, shadowed{named{"b"}, "x"}, nil},
+		{"tagged wins", tieTagged{named{"a"}, taggedNamed{"x"}}, tieTagged{named{"b"}, taggedNamed{"y"}}, []string{"~ /Name: x -> y"}},
+		{"conflict dropped", conflicting{named{"a"}, otherNamed{"x"}}, conflicting{named{"b"}, otherNamed{"y"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := Diff(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, c := range changes {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+
+			// 没有变更当且仅当 encoding/json 的输出相同
+			aJSON, _ := json.Marshal(tt.a)
+			bJSON, _ := json.Marshal(tt.b)
+			if same := string(aJSON) == string(bJSON); same != (len(changes) == 0) {
+				t.Errorf("json %s vs %s, but %d changes", aJSON, bJSON, len(changes))
+			}
+		})
+	}
+}
+
+// TestPatchRoundTrip 对 a 应用由 Diff(a, b) 生成的补丁后应当得到 b
+func TestPatchRoundTrip(t *testing.T) {
+	base := samplePerson()
+	tests := []struct {
+		name   string
+		modify func(p *person)
+	}{
+		{"no change", func(p *person) {}},
+		{"scalars", func(p *person) { p.Name, p.Age, p.ID = "Bob", 30, 7 }},
+		{"grow slice", func(p *person) { p.Tags = append(p.Tags, "c", "zig") }},
+		{"shrink slice", func(p *person) { p.Tags = p.Tags[:0] }},
+		{"nil slice", func(p *person) { p.Tags = nil }},
+		{"nil pointer", func(p *person) { p.Address = nil }},
+		{"map edits", func(p *person) { p.Scores = map[string]int{"art": 1, "math": 0} }},
+		{"omitempty add and remove", func(p *person) { p.Nick = "al"; p.Meta = map[string]any{"k": []any{"v"}} }},
+		{"time", func(p *person) { p.Born = p.Born.AddDate(1, 0, 0) }},
+		{"int keyed map", func(p *person) { p.Extra = map[int]time.Time{3: p.Born} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := base
+			a.Tags = append([]string(nil), base.Tags...)
+			b := samplePerson()
+			tt.modify(&b)
+
+			patch, err := CreatePatch(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := patch.ApplyTo(&a); err != nil {
+				t.Fatalf("apply %s: %v", patch, err)
+			}
+
+			wantJSON, _ := json.Marshal(b)
+			gotJSON, _ := json.Marshal(a)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("patch %s\n got %s\nwant %s", patch, gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestApplyRFC6902(t *testing.T) {
+	doc := `{"foo":"bar","list":[1,2,3],"obj":{"a":1}}`
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		err   error
+	}{
+		{"add member", `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar","list":[1,2,3],"obj":{"a":1}}`, nil},
+		{"insert into array", `[{"op":"add","path":"/list/1","value":9}]`, `{"foo":"bar","list":[1,9,2,3],"obj":{"a":1}}`, nil},
+		{"append to array", `[{"op":"add","path":"/list/-","value":4}]`, `{"foo":"bar","list":[1,2,3,4],"obj":{"a":1}}`, nil},
+		{"remove", `[{"op":"remove","path":"/list/0"}]`, `{"foo":"bar","list":[2,3],"obj":{"a":1}}`, nil},
+		{"replace", `[{"op":"replace","path":"/obj/a","value":null}]`, `{"foo":"bar","list":[1,2,3],"obj":{"a":null}}`, nil},
+		{"move", `[{"op":"move","from":"/foo","path":"/obj/foo"}]`, `{"list":[1,2,3],"obj":{"a":1,"foo":"bar"}}`, nil},
+		{"copy", `[{"op":"copy","from":"/obj","path":"/obj2"}]`, `{"foo":"bar","list":[1,2,3],"obj":{"a":1},"obj2":{"a":1}}`, nil},
+		{"test passes", `[{"op":"test","path":"/list","value":[1,2,3.0]}]`, doc, nil},
+		{"test fails", `[{"op":"test","path":"/foo","value":"baz"}]`, "", ErrTestFailed},
+		{"replace missing", `[{"op":"replace","path":"/missing","value":1}]`, "", ErrPathNotFound},
+		{"index out of range", `[{"op":"add","path":"/list/5","value":1}]`, "", ErrPathNotFound},
+		{"leading zero index", `[{"op":"remove","path":"/list/01"}]`, "", ErrInvalidPatch},
+		{"move into child", `[{"op":"move","from":"/obj","path":"/obj/x"}]`, "", ErrInvalidPatch},
+		{"unknown op", `[{"op":"merge","path":"/foo"}]`, "", ErrInvalidPatch},
+		{"missing value", `[{"op":"add","path":"/foo"}]`, "", ErrInvalidPatch},
+		{"bad pointer", `[{"op":"remove","path":"foo"}]`, "", ErrInvalidPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParsePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := patch.Apply([]byte(doc))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPatchJSON(t *testing.T) {
+	patch, err := CreatePatch(map[string]any{"a": 1, "b": true}, map[string]any{"a": 0, "c": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 零值必须保留在 value 字段中
+	want := `[{"op":"replace","path":"/a","value":0},{"op":"remove","path":"/b"},{"op":"add","path":"/c","value":false}]`
+	if patch.String() != want {
+		t.Errorf("expected %s, got %s", want, patch)
+	}
+}
@@ -0,0 +1,426 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch 补丁格式错误
+	ErrInvalidPatch = errors.New("diff: 无效的补丁")
+	// ErrPathNotFound 补丁路径在文档中不存在
+	ErrPathNotFound = errors.New("diff: 路径不存在")
+	// ErrTestFailed test 操作的值不匹配
+	ErrTestFailed = errors.New("diff: test 操作失败")
+)
+
+// Operation JSON Patch 的一个操作
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch JSON Patch 文档（RFC 6902）
+type Patch []Operation
+
+// ToPatch 把变更列表转为 JSON Patch
+func ToPatch(changes []Change) (Patch, error) {
+	patch := make(Patch, 0, len(changes))
+	for _, c := range changes {
+		op := Operation{Path: c.Path}
+		switch c.Kind {
+		case Added:
+			op.Op = "add"
+		case Removed:
+			op.Op = "remove"
+			patch = append(patch, op)
+			continue
+		case Modified:
+			op.Op = "replace"
+		default:
+			return nil, fmt.Errorf("%w: 未知的变更类型 %s", ErrInvalidPatch, c.Kind)
+		}
+		value, err := json.Marshal(c.To)
+		if err != nil {
+			return nil, fmt.Errorf("diff: 序列化 %s 的值失败: %w", pathOrRoot(c.Path), err)
+		}
+		op.Value = value
+		patch = append(patch, op)
+	}
+	return patch, nil
+}
+
+// CreatePatch 比较 a 和 b 并生成 JSON Patch
+func CreatePatch(a, b any) (Patch, error) {
+	changes, err := Diff(a, b)
+	if err != nil {
+		return nil, err
+	}
+	return ToPatch(changes)
+}
+
+// ParsePatch 解析 JSON Patch 文档
+func ParsePatch(data []byte) (Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return patch, nil
+}
+
+// Apply 把补丁应用到 JSON 文档，任一操作失败时返回错误且不产生部分结果
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("diff: 解析文档失败: %w", err)
+	}
+	for i, op := range p {
+		if root, err = op.apply(root); err != nil {
+			return nil, fmt.Errorf("第%d个操作 %s %s: %w", i+1, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// ApplyTo 把补丁应用到 target 指向的值：先序列化为 JSON，应用补丁后再反序列化回去
+func (p Patch) ApplyTo(target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("diff: ApplyTo 需要非 nil 指针，得到 %T", target)
+	}
+	doc, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	out, err := p.Apply(doc)
+	if err != nil {
+		return err
+	}
+	// 解码到新值，避免映射中残留已删除的键
+	fresh := reflect.New(rv.Elem().Type())
+	if err := json.Unmarshal(out, fresh.Interface()); err != nil {
+		return err
+	}
+	rv.Elem().Set(fresh.Elem())
+	return nil
+}
+
+// String 补丁的 JSON 表示
+func (p Patch) String() string {
+	data, _ := json.Marshal(p)
+	return string(data)
+}
+
+func (op Operation) apply(root any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+
+	case "remove":
+		if len(path) == 0 {
+			return nil, fmt.Errorf("%w: 不能删除根节点", ErrInvalidPatch)
+		}
+		root, _, err := remove(root, path)
+		return root, err
+
+	case "replace":
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if root, _, err = remove(root, path); err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("%w: 不能把 %s 移动到自己的子节点", ErrInvalidPatch, op.From)
+			}
+			if op.Path == op.From {
+				return root, nil
+			}
+			if len(from) == 0 {
+				return nil, fmt.Errorf("%w: 不能移动根节点", ErrInvalidPatch)
+			}
+			if root, value, err = remove(root, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = get(root, from); err != nil {
+				return nil, err
+			}
+			value = deepCopy(value)
+		}
+		return add(root, path, value)
+
+	case "test":
+		want, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		got, err := get(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(got, want) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+
+	default:
+		return nil, fmt.Errorf("%w: 未知操作 %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+func (op Operation) value() (any, error) {
+	if op.Value == nil {
+		return nil, fmt.Errorf("%w: 缺少 value", ErrInvalidPatch)
+	}
+	return decode(op.Value)
+}
+
+// decode 解码 JSON，数字保留为 json.Number 以免精度损失
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// parsePointer 解析 JSON Pointer
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("%w: 路径 %q 必须以 / 开头", ErrInvalidPatch, p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = UnescapeToken(t)
+	}
+	return tokens, nil
+}
+
+// arrayIndex 解析数组下标，allowEnd 时允许 "-" 和 len 表示追加
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("%w: 无效的数组下标 %q", ErrInvalidPatch, token)
+	}
+	limit := length - 1
+	if allowEnd {
+		limit = length
+	}
+	if i > limit {
+		return 0, fmt.Errorf("%w: 下标 %d 越界", ErrPathNotFound, i)
+	}
+	return i, nil
+}
+
+// get 读取路径上的值
+func get(node any, path []string) (any, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+			}
+			node = v
+		case []any:
+			i, err := arrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("%w: %q 不是容器", ErrPathNotFound, token)
+		}
+	}
+	return node, nil
+}
+
+// add 在路径处加入值，返回更新后的节点
+func add(node any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, rest := path[0], path[1:]
+
+	switch n := node.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+		}
+		updated, err := add(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = updated
+		return n, nil
+
+	case []any:
+		if len(rest) == 0 {
+			i, err := arrayIndex(token, len(n), true)
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		i, err := arrayIndex(token, len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := add(n[i], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %q 不是容器", ErrPathNotFound, token)
+	}
+}
+
+// remove 删除路径处的值，返回更新后的节点和被删除的值
+func remove(node any, path []string) (any, any, error) {
+	token, rest := path[0], path[1:]
+
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %q", ErrPathNotFound, token)
+		}
+		if len(rest) == 0 {
+			delete(n, token)
+			return n, child, nil
+		}
+		updated, removed, err := remove(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[token] = updated
+		return n, removed, nil
+
+	case []any:
+		i, err := arrayIndex(token, len(n), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := n[i]
+			return append(n[:i], n[i+1:]...), removed, nil
+		}
+		updated, removed, err := remove(n[i], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[i] = updated
+		return n, removed, nil
+
+	default:
+		return nil, nil, fmt.Errorf("%w: %q 不是容器", ErrPathNotFound, token)
+	}
+}
+
+// deepCopy 复制解码后的 JSON 值
+func deepCopy(v any) any {
+	switch n := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(n))
+		for k, val := range n {
+			m[k] = deepCopy(val)
+		}
+		return m
+	case []any:
+		s := make([]any, len(n))
+		for i, val := range n {
+			s[i] = deepCopy(val)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// jsonEqual 比较解码后的 JSON 值，数字按数值比较
+func jsonEqual(a, b any) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
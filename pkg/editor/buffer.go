@@ -0,0 +1,158 @@
+// Package editor 基于命令模式的文本编辑
+//
+// GapBuffer 是底层文档，所有修改都封装为 Command，由 History 执行并记录，
+// 从而支持撤销、重做、宏命令以及连续输入的合并。LineEditor 在此之上提供
+// 类似 ed 的按行编辑命令。
+//
+// 所有位置都以 rune 为单位，行号从 1 开始。
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrRange 位置或行号越界
+var ErrRange = errors.New("editor: 位置越界")
+
+// minGap 间隙不足时至少扩展的大小
+const minGap = 64
+
+// GapBuffer 间隙缓冲区
+//
+// 文本存放在一个切片中，光标处留有一段空白（间隙）。在同一位置附近连续插入或删除
+// 只需移动间隙边界，均摊 O(1)；在远处编辑时需要先把间隙移过去，代价与距离成正比。
+type GapBuffer struct {
+	data     []rune
+	gapStart int
+	gapEnd   int
+}
+
+// NewGapBuffer 以 s 为初始内容创建缓冲区
+func NewGapBuffer(s string) *GapBuffer {
+	r := []rune(s)
+	data := make([]rune, len(r)+minGap)
+	copy(data, r)
+	return &GapBuffer{data: data, gapStart: len(r), gapEnd: len(data)}
+}
+
+// Len 文本长度（rune 数）
+func (b *GapBuffer) Len() int {
+	return len(b.data) - (b.gapEnd - b.gapStart)
+}
+
+// Insert 在 pos 处插入 s
+func (b *GapBuffer) Insert(pos int, s string) error {
+	if pos < 0 || pos > b.Len() {
+		return fmt.Errorf("%w: 插入位置 %d，长度 %d", ErrRange, pos, b.Len())
+	}
+	r := []rune(s)
+	b.moveGap(pos)
+	if b.gapEnd-b.gapStart < len(r) {
+		b.grow(len(r))
+	}
+	copy(b.data[b.gapStart:], r)
+	b.gapStart += len(r)
+	return nil
+}
+
+// Delete 删除 [pos, pos+n)，返回被删除的文本
+func (b *GapBuffer) Delete(pos, n int) (string, error) {
+	if pos < 0 || n < 0 || pos+n > b.Len() {
+		return "", fmt.Errorf("%w: 删除 [%d, %d)，长度 %d", ErrRange, pos, pos+n, b.Len())
+	}
+	b.moveGap(pos)
+	deleted := string(b.data[b.gapEnd : b.gapEnd+n])
+	b.gapEnd += n
+	return deleted, nil
+}
+
+// Slice 返回 [from, to) 的文本
+func (b *GapBuffer) Slice(from, to int) (string, error) {
+	if from < 0 || from > to || to > b.Len() {
+		return "", fmt.Errorf("%w: 区间 [%d, %d)，长度 %d", ErrRange, from, to, b.Len())
+	}
+	var sb strings.Builder
+	for i := from; i < to; i++ {
+		sb.WriteRune(b.at(i))
+	}
+	return sb.String(), nil
+}
+
+// String 完整文本
+func (b *GapBuffer) String() string {
+	return string(b.data[:b.gapStart]) + string(b.data[b.gapEnd:])
+}
+
+// at 第 i 个 rune
+func (b *GapBuffer) at(i int) rune {
+	if i < b.gapStart {
+		return b.data[i]
+	}
+	return b.data[i+b.gapEnd-b.gapStart]
+}
+
+// moveGap 把间隙移到 pos
+func (b *GapBuffer) moveGap(pos int) {
+	switch {
+	case pos < b.gapStart:
+		n := b.gapStart - pos
+		copy(b.data[b.gapEnd-n:b.gapEnd], b.data[pos:b.gapStart])
+		b.gapStart -= n
+		b.gapEnd -= n
+	case pos > b.gapStart:
+		n := pos - b.gapStart
+		copy(b.data[b.gapStart:], b.data[b.gapEnd:b.gapEnd+n])
+		b.gapStart += n
+		b.gapEnd += n
+	}
+}
+
+// grow 扩大间隙，使其至少能容纳 n 个 rune
+func (b *GapBuffer) grow(n int) {
+	size := max(2*len(b.data), len(b.data)+n+minGap)
+	data := make([]rune, size)
+	copy(data, b.data[:b.gapStart])
+	tail := len(b.data) - b.gapEnd
+	copy(data[size-tail:], b.data[b.gapEnd:])
+	b.data = data
+	b.gapEnd = size - tail
+}
+
+// line 一行在文本中的位置，end 不含换行符
+type line struct {
+	start, end int
+	newline    bool
+}
+
+// lines 计算所有行的位置；以换行符结尾的文本不会多出一个空行
+func (b *GapBuffer) lines() []line {
+	var out []line
+	start := 0
+	n := b.Len()
+	for i := 0; i < n; i++ {
+		if b.at(i) == '\n' {
+			out = append(out, line{start: start, end: i, newline: true})
+			start = i + 1
+		}
+	}
+	if start < n {
+		out = append(out, line{start: start, end: n})
+	}
+	return out
+}
+
+// LineCount 行数
+func (b *GapBuffer) LineCount() int {
+	return len(b.lines())
+}
+
+// Line 第 n 行的内容（不含换行符）
+func (b *GapBuffer) Line(n int) (string, error) {
+	ls := b.lines()
+	if n < 1 || n > len(ls) {
+		return "", fmt.Errorf("%w: 第 %d 行，共 %d 行", ErrRange, n, len(ls))
+	}
+	return b.Slice(ls[n-1].start, ls[n-1].end)
+}
@@ -0,0 +1,123 @@
+package editor
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Command 可撤销的编辑命令
+//
+// Undo 只会在 Execute 成功之后调用，并且必须把缓冲区恢复到执行前的状态。
+type Command interface {
+	Execute(b *GapBuffer) error
+	Undo(b *GapBuffer) error
+}
+
+// Merger 可以与紧随其后的命令合并的命令
+//
+// History 在执行 next 之后调用 Merge；返回 true 表示 next 的效果已并入当前命令，
+// 之后撤销一次即可同时撤销两者。
+type Merger interface {
+	Merge(next Command) bool
+}
+
+// Insert 在 Pos 处插入 Text
+type Insert struct {
+	Pos  int
+	Text string
+}
+
+// Execute 执行插入
+func (c *Insert) Execute(b *GapBuffer) error {
+	return b.Insert(c.Pos, c.Text)
+}
+
+// Undo 删除插入的文本
+func (c *Insert) Undo(b *GapBuffer) error {
+	_, err := b.Delete(c.Pos, utf8.RuneCountInString(c.Text))
+	return err
+}
+
+// Merge 合并紧接在后面的插入，模拟逐字输入；遇到换行就开始新的一次撤销
+func (c *Insert) Merge(next Command) bool {
+	n, ok := next.(*Insert)
+	if !ok || n.Pos != c.Pos+utf8.RuneCountInString(c.Text) {
+		return false
+	}
+	if strings.HasSuffix(c.Text, "\n") || strings.Contains(n.Text, "\n") {
+		return false
+	}
+	c.Text += n.Text
+	return true
+}
+
+// Delete 删除 [Pos, Pos+N)
+type Delete struct {
+	Pos int
+	N   int
+
+	deleted string
+}
+
+// Execute 执行删除并记住被删除的文本
+func (c *Delete) Execute(b *GapBuffer) error {
+	deleted, err := b.Delete(c.Pos, c.N)
+	if err != nil {
+		return err
+	}
+	c.deleted = deleted
+	return nil
+}
+
+// Undo 恢复被删除的文本
+func (c *Delete) Undo(b *GapBuffer) error {
+	return b.Insert(c.Pos, c.deleted)
+}
+
+// Merge 合并连续的退格（向前删除）或 Delete 键（原地删除）
+func (c *Delete) Merge(next Command) bool {
+	n, ok := next.(*Delete)
+	if !ok {
+		return false
+	}
+	switch {
+	case n.Pos+n.N == c.Pos: // 退格
+		c.Pos = n.Pos
+		c.deleted = n.deleted + c.deleted
+	case n.Pos == c.Pos: // 向后删除
+		c.deleted += n.deleted
+	default:
+		return false
+	}
+	c.N += n.N
+	return true
+}
+
+// Macro 依次执行的一组命令，作为一个整体撤销
+type Macro []Command
+
+// Execute 依次执行，某一步失败时撤销已执行的步骤
+func (m Macro) Execute(b *GapBuffer) error {
+	for i, c := range m {
+		if err := c.Execute(b); err != nil {
+			return errors.Join(err, m[:i].Undo(b))
+		}
+	}
+	return nil
+}
+
+// Undo 逆序撤销
+func (m Macro) Undo(b *GapBuffer) error {
+	for i := len(m) - 1; i >= 0; i-- {
+		if err := m[i].Undo(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replace 把 [pos, pos+n) 替换为 text 的宏命令
+func Replace(pos, n int, text string) Macro {
+	return Macro{&Delete{Pos: pos, N: n}, &Insert{Pos: pos, Text: text}}
+}
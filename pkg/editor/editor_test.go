@@ -0,0 +1,262 @@
+package editor
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// TestGapBufferRandom 随机插入删除，与直接操作 []rune 的结果比较
+func TestGapBufferRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	words := []string{"a", "世界", "hello ", "\n", strings.Repeat("x", 100)}
+	b := NewGapBuffer("初始内容")
+	model := []rune("初始内容")
+
+	for i := range 2000 {
+		if rng.Intn(3) > 0 {
+			pos := rng.Intn(len(model) + 1)
+			s := words[rng.Intn(len(words))]
+			if err := b.Insert(pos, s); err != nil {
+				t.Fatal(err)
+			}
+			model = append(model[:pos], append([]rune(s), model[pos:]...)...)
+		} else if len(model) > 0 {
+			pos := rng.Intn(len(model))
+			n := rng.Intn(min(len(model)-pos, 20) + 1)
+			got, err := b.Delete(pos, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := string(model[pos : pos+n]); got != want {
+				t.Fatalf("step %d: deleted %q, want %q", i, got, want)
+			}
+			model = append(model[:pos], model[pos+n:]...)
+		}
+		if b.Len() != len(model) {
+			t.Fatalf("step %d: length %d, want %d", i, b.Len(), len(model))
+		}
+	}
+	if b.String() != string(model) {
+		t.Fatal("content mismatch")
+	}
+	if s, _ := b.Slice(2, 7); s != string(model[2:7]) {
+		t.Errorf("Slice: %q vs %q", s, string(model[2:7]))
+	}
+}
+
+func TestGapBufferRange(t *testing.T) {
+	b := NewGapBuffer("abc")
+	if err := b.Insert(4, "x"); !errors.Is(err, ErrRange) {
+		t.Errorf("insert: expected ErrRange, got %v", err)
+	}
+	if _, err := b.Delete(2, 2); !errors.Is(err, ErrRange) {
+		t.Errorf("delete: expected ErrRange, got %v", err)
+	}
+	if _, err := b.Slice(2, 1); !errors.Is(err, ErrRange) {
+		t.Errorf("slice: expected ErrRange, got %v", err)
+	}
+}
+
+func TestHistoryUndoRedo(t *testing.T) {
+	h := NewHistory(NewGapBuffer("hello"))
+	steps := []struct {
+		cmd  Command
+		want string
+	}{
+		{&Insert{Pos: 5, Text: " world"}, "hello world"},
+		{&Delete{Pos: 0, N: 1}, "ello world"},
+		{Replace(0, 4, "HELLO"), "HELLO world"},
+	}
+	for _, s := range steps {
+		h.Seal()
+		if err := h.Do(s.cmd); err != nil {
+			t.Fatal(err)
+		}
+		if got := h.Buffer().String(); got != s.want {
+			t.Fatalf("after %T: expected %q, got %q", s.cmd, s.want, got)
+		}
+	}
+
+	for i := len(steps) - 2; i >= -1; i-- {
+		if err := h.Undo(); err != nil {
+			t.Fatal(err)
+		}
+		want := "hello"
+		if i >= 0 {
+			want = steps[i].want
+		}
+		if got := h.Buffer().String(); got != want {
+			t.Errorf("undo: expected %q, got %q", want, got)
+		}
+	}
+	if err := h.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected ErrNothingToUndo, got %v", err)
+	}
+
+	for _, s := range steps {
+		if err := h.Redo(); err != nil {
+			t.Fatal(err)
+		}
+		if got := h.Buffer().String(); got != s.want {
+			t.Errorf("redo: expected %q, got %q", s.want, got)
+		}
+	}
+	if err := h.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("expected ErrNothingToRedo, got %v", err)
+	}
+
+	// 新命令清空重做栈
+	h.Undo()
+	h.Do(&Insert{Pos: 0, Text: ">"})
+	if h.CanRedo() {
+		t.Error("redo stack should be cleared by a new command")
+	}
+}
+
+func TestHistoryMerge(t *testing.T) {
+	h := NewHistory(NewGapBuffer(""))
+	typeText := func(pos int, s string) {
+		for _, r := range s {
+			if err := h.Do(&Insert{Pos: pos, Text: string(r)}); err != nil {
+				t.Fatal(err)
+			}
+			pos++
+		}
+	}
+
+	typeText(0, "你好")
+	typeText(2, "，世界\n")
+	typeText(6, "再见")
+	// 退格两次
+	h.Do(&Delete{Pos: 7, N: 1})
+	h.Do(&Delete{Pos: 6, N: 1})
+	if got := h.Buffer().String(); got != "你好，世界\n" {
+		t.Fatalf("unexpected text %q", got)
+	}
+
+	// 撤销顺序：两次退格（合并为一次）、"再见"、"\n"、"你好，世界"
+	wants := []string{"你好，世界\n再见", "你好，世界\n", "你好，世界", ""}
+	for _, want := range wants {
+		if err := h.Undo(); err != nil {
+			t.Fatal(err)
+		}
+		if got := h.Buffer().String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+	if h.CanUndo() {
+		t.Error("expected history to be empty")
+	}
+
+	// Seal 之后不再合并
+	h = NewHistory(NewGapBuffer(""))
+	h.Do(&Insert{Pos: 0, Text: "a"})
+	h.Seal()
+	h.Do(&Insert{Pos: 1, Text: "b"})
+	h.Undo()
+	if got := h.Buffer().String(); got != "a" {
+		t.Errorf("sealed insert should undo separately, got %q", got)
+	}
+
+	// 向后删除的合并
+	h = NewHistory(NewGapBuffer("abcdef"))
+	h.Do(&Delete{Pos: 1, N: 1})
+	h.Do(&Delete{Pos: 1, N: 2})
+	h.Undo()
+	if got := h.Buffer().String(); got != "abcdef" {
+		t.Errorf("forward deletes should undo together, got %q", got)
+	}
+}
+
+func TestMacroRollback(t *testing.T) {
+	h := NewHistory(NewGapBuffer("abc"))
+	bad := Macro{&Insert{Pos: 0, Text: "x"}, &Delete{Pos: 1, N: 1}, &Delete{Pos: 10, N: 1}}
+	if err := h.Do(bad); !errors.Is(err, ErrRange) {
+		t.Fatalf("expected ErrRange, got %v", err)
+	}
+	if got := h.Buffer().String(); got != "abc" || h.CanUndo() {
+		t.Errorf("failed macro should leave the buffer untouched, got %q", got)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	h := NewHistory(NewGapBuffer(""), WithLimit(2))
+	for _, s := range []string{"a\n", "b\n", "c\n"} {
+		h.Do(&Insert{Pos: h.Buffer().Len(), Text: s})
+	}
+	h.Undo()
+	h.Undo()
+	if err := h.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected only 2 undo steps, got %v", err)
+	}
+	if got := h.Buffer().String(); got != "a\n" {
+		t.Errorf("unexpected text %q", got)
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		script  []string
+		want    string
+		output  string
+	}{
+		{"append and insert", "b\n", []string{"a 1 c", "i 1 a", "a 0 top"}, "top\na\nb\nc\n", ""},
+		{"no trailing newline", "a\nb", []string{"a 2 c", "d 1"}, "b\nc", ""},
+		{"delete last line without newline", "a\nb\nc", []string{"d 2,3"}, "a", ""},
+		{"delete everything", "a\nb\n", []string{"d 1,2"}, "", ""},
+		{"change", "foo\nbar\n", []string{"c 2 baz"}, "foo\nbaz\n", ""},
+		{"substitute", "一二一\n", []string{"s 1 一 三"}, "三二三\n", "替换了 2 处\n"},
+		{"undo and redo", "a\n", []string{"a 1 b", "c 1 x", "u", "u", "r"}, "a\nb\n", ""},
+		{"undo one delete", "a\nb\nc\nd\n", []string{"d 1", "d 1", "u"}, "b\nc\nd\n", ""},
+		{"undo one backward delete", "a\nb\nc\nd\n", []string{"d 3", "d 2", "u"}, "a\nb\nd\n", ""},
+		{"print", "x\ny\nz", []string{"p 2,3", "="}, "x\ny\nz", "   2  y\n   3  z\n3\n"},
+		{"print empty", "", []string{"p"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			e := NewLineEditor(tt.initial, &out)
+			for _, cmd := range tt.script {
+				if err := e.Exec(cmd); err != nil {
+					t.Fatalf("%q: %v", cmd, err)
+				}
+			}
+			if e.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, e.String())
+			}
+			if out.String() != tt.output {
+				t.Errorf("expected output %q, got %q", tt.output, out.String())
+			}
+		})
+	}
+}
+
+func TestLineEditorErrors(t *testing.T) {
+	tests := []struct {
+		cmd string
+		err error
+	}{
+		{"x", ErrUnknownCommand},
+		{"d a", ErrSyntax},
+		{"s 1 only", ErrSyntax},
+		{"s 1  new", ErrSyntax},
+		{"d 3", ErrRange},
+		{"p 2,1", ErrRange},
+		{"a 5 x", ErrRange},
+		{"c 0 x", ErrRange},
+		{"u", ErrNothingToUndo},
+	}
+	for _, tt := range tests {
+		e := NewLineEditor("one\ntwo\n", &strings.Builder{})
+		if err := e.Exec(tt.cmd); !errors.Is(err, tt.err) {
+			t.Errorf("%q: expected %v, got %v", tt.cmd, tt.err, err)
+		}
+		if e.String() != "one\ntwo\n" {
+			t.Errorf("%q: failed command modified the text", tt.cmd)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package editor
+
+import "errors"
+
+var (
+	// ErrNothingToUndo 没有可撤销的命令
+	ErrNothingToUndo = errors.New("editor: 没有可撤销的操作")
+	// ErrNothingToRedo 没有可重做的命令
+	ErrNothingToRedo = errors.New("editor: 没有可重做的操作")
+)
+
+// DefaultHistoryLimit 默认保留的撤销步数
+const DefaultHistoryLimit = 1000
+
+// HistoryOption 历史记录选项
+type HistoryOption func(*History)
+
+// WithLimit 设置最多保留的撤销步数，超出时丢弃最早的记录；不大于 0 表示不限制
+func WithLimit(n int) HistoryOption {
+	return func(h *History) {
+		h.limit = n
+	}
+}
+
+// History 在缓冲区上执行命令并维护撤销/重做栈
+type History struct {
+	buf    *GapBuffer
+	undo   []Command
+	redo   []Command
+	limit  int
+	sealed bool // 为 true 时下一条命令不与栈顶合并
+}
+
+// NewHistory 创建历史记录
+func NewHistory(buf *GapBuffer, opts ...HistoryOption) *History {
+	h := &History{buf: buf, limit: DefaultHistoryLimit}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Buffer 被编辑的缓冲区
+func (h *History) Buffer() *GapBuffer { return h.buf }
+
+// Do 执行命令并记入历史，清空重做栈
+//
+// 若栈顶命令实现了 Merger 且接受合并，则不会新增一条记录。
+func (h *History) Do(c Command) error {
+	if err := c.Execute(h.buf); err != nil {
+		return err
+	}
+	h.redo = nil
+
+	if n := len(h.undo); n > 0 && !h.sealed {
+		if m, ok := h.undo[n-1].(Merger); ok && m.Merge(c) {
+			return nil
+		}
+	}
+	h.sealed = false
+	h.undo = append(h.undo, c)
+	if h.limit > 0 && len(h.undo) > h.limit {
+		h.undo = h.undo[len(h.undo)-h.limit:]
+	}
+	return nil
+}
+
+// Seal 结束当前的合并，下一条命令总是单独记录（例如光标移动之后）
+func (h *History) Seal() {
+	h.sealed = true
+}
+
+// Undo 撤销最近一条命令
+func (h *History) Undo() error {
+	if len(h.undo) == 0 {
+		return ErrNothingToUndo
+	}
+	c := h.undo[len(h.undo)-1]
+	if err := c.Undo(h.buf); err != nil {
+		return err
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, c)
+	h.sealed = true
+	return nil
+}
+
+// Redo 重做最近撤销的命令
+func (h *History) Redo() error {
+	if len(h.redo) == 0 {
+		return ErrNothingToRedo
+	}
+	c := h.redo[len(h.redo)-1]
+	if err := c.Execute(h.buf); err != nil {
+		return err
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, c)
+	h.sealed = true
+	return nil
+}
+
+// CanUndo 是否有可撤销的命令
+func (h *History) CanUndo() bool { return len(h.undo) > 0 }
+
+// CanRedo 是否有可重做的命令
+func (h *History) CanRedo() bool { return len(h.redo) > 0 }
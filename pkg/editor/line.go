@@ -0,0 +1,212 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrUnknownCommand 无法识别的行编辑命令
+	ErrUnknownCommand = errors.New("editor: 未知命令")
+	// ErrSyntax 命令参数格式错误
+	ErrSyntax = errors.New("editor: 命令格式错误")
+)
+
+// LineEditor 类似 ed 的行编辑器，每个修改都可以撤销，一次撤销一条命令
+type LineEditor struct {
+	*History
+	out io.Writer
+}
+
+// NewLineEditor 以 text 为初始内容创建行编辑器，Print 等输出写入 out
+func NewLineEditor(text string, out io.Writer, opts ...HistoryOption) *LineEditor {
+	return &LineEditor{History: NewHistory(NewGapBuffer(text), opts...), out: out}
+}
+
+// String 当前文本
+func (e *LineEditor) String() string { return e.buf.String() }
+
+// do 执行一条行命令并结束合并，使连续的行命令不会被合并为一次撤销
+func (e *LineEditor) do(c Command) error {
+	defer e.Seal()
+	return e.Do(c)
+}
+
+// Print 打印 [from, to] 行，带行号
+func (e *LineEditor) Print(from, to int) error {
+	ls := e.buf.lines()
+	if from < 1 || from > to || to > len(ls) {
+		return fmt.Errorf("%w: 第 %d-%d 行，共 %d 行", ErrRange, from, to, len(ls))
+	}
+	for n := from; n <= to; n++ {
+		text, _ := e.buf.Slice(ls[n-1].start, ls[n-1].end)
+		fmt.Fprintf(e.out, "%4d  %s\n", n, text)
+	}
+	return nil
+}
+
+// Append 在第 n 行之后追加若干行，n 为 0 时插入到开头
+func (e *LineEditor) Append(n int, lines ...string) error {
+	ls := e.buf.lines()
+	if n < 0 || n > len(ls) {
+		return fmt.Errorf("%w: 第 %d 行，共 %d 行", ErrRange, n, len(ls))
+	}
+	text := strings.Join(lines, "\n")
+	if n > 0 && !ls[n-1].newline {
+		// 最后一行没有换行符：补上换行后追加，保持文件末尾没有换行的风格
+		return e.do(&Insert{Pos: ls[n-1].end, Text: "\n" + text})
+	}
+	pos := 0
+	if n > 0 {
+		pos = ls[n-1].end + 1
+	}
+	return e.do(&Insert{Pos: pos, Text: text + "\n"})
+}
+
+// InsertBefore 在第 n 行之前插入若干行
+func (e *LineEditor) InsertBefore(n int, lines ...string) error {
+	return e.Append(n-1, lines...)
+}
+
+// DeleteLines 删除 [from, to] 行
+func (e *LineEditor) DeleteLines(from, to int) error {
+	ls := e.buf.lines()
+	if from < 1 || from > to || to > len(ls) {
+		return fmt.Errorf("%w: 第 %d-%d 行，共 %d 行", ErrRange, from, to, len(ls))
+	}
+	start, end := ls[from-1].start, ls[to-1].end
+	if ls[to-1].newline {
+		end++
+	} else if from > 1 {
+		// 删除没有换行符的最后一行时，一并删除上一行的换行符
+		start--
+	}
+	return e.do(&Delete{Pos: start, N: end - start})
+}
+
+// Change 把第 n 行替换为 text
+func (e *LineEditor) Change(n int, text string) error {
+	ls := e.buf.lines()
+	if n < 1 || n > len(ls) {
+		return fmt.Errorf("%w: 第 %d 行，共 %d 行", ErrRange, n, len(ls))
+	}
+	l := ls[n-1]
+	return e.do(Replace(l.start, l.end-l.start, text))
+}
+
+// Substitute 把第 n 行中所有的 old 替换为 new，返回替换次数
+func (e *LineEditor) Substitute(n int, old, new string) (int, error) {
+	if old == "" {
+		return 0, fmt.Errorf("%w: 替换的文本不能为空", ErrSyntax)
+	}
+	text, err := e.buf.Line(n)
+	if err != nil {
+		return 0, err
+	}
+	count := strings.Count(text, old)
+	if count == 0 {
+		return 0, nil
+	}
+	return count, e.Change(n, strings.ReplaceAll(text, old, new))
+}
+
+// Exec 执行一条文本命令
+//
+//	p [N[,M]]     打印（默认全部）
+//	a N 文本      在第 N 行后追加（N 可为 0）
+//	i N 文本      在第 N 行前插入
+//	c N 文本      替换第 N 行
+//	d N[,M]       删除行
+//	s N 旧 新     在第 N 行中替换（以空格分隔，不支持含空格的旧文本）
+//	u             撤销
+//	r             重做
+//	=             打印行数
+func (e *LineEditor) Exec(cmd string) error {
+	name, rest, _ := strings.Cut(strings.TrimSpace(cmd), " ")
+	switch name {
+	case "":
+		return nil
+	case "p":
+		if rest == "" {
+			if e.buf.LineCount() == 0 {
+				return nil
+			}
+			return e.Print(1, e.buf.LineCount())
+		}
+		from, to, err := parseRange(rest)
+		if err != nil {
+			return err
+		}
+		return e.Print(from, to)
+	case "a", "i", "c":
+		addr, text, _ := strings.Cut(rest, " ")
+		n, err := parseLine(addr)
+		if err != nil {
+			return err
+		}
+		switch name {
+		case "a":
+			return e.Append(n, text)
+		case "i":
+			return e.InsertBefore(n, text)
+		default:
+			return e.Change(n, text)
+		}
+	case "d":
+		from, to, err := parseRange(rest)
+		if err != nil {
+			return err
+		}
+		return e.DeleteLines(from, to)
+	case "s":
+		fields := strings.SplitN(rest, " ", 3)
+		if len(fields) != 3 {
+			return fmt.Errorf("%w: 用法 s N 旧 新", ErrSyntax)
+		}
+		n, err := parseLine(fields[0])
+		if err != nil {
+			return err
+		}
+		count, err := e.Substitute(n, fields[1], fields[2])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(e.out, "替换了 %d 处\n", count)
+		return nil
+	case "u":
+		return e.Undo()
+	case "r":
+		return e.Redo()
+	case "=":
+		fmt.Fprintln(e.out, e.buf.LineCount())
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownCommand, name)
+	}
+}
+
+// parseLine 解析行号
+func parseLine(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: 无效的行号 %q", ErrSyntax, s)
+	}
+	return n, nil
+}
+
+// parseRange 解析 "N" 或 "N,M"
+func parseRange(s string) (int, int, error) {
+	a, b, found := strings.Cut(s, ",")
+	from, err := parseLine(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !found {
+		return from, from, nil
+	}
+	to, err := parseLine(b)
+	return from, to, err
+}
@@ -0,0 +1,266 @@
+// Package event 泛型事件总线（观察者模式）
+//
+// Bus[E] 只投递类型为 E 的事件，订阅和发布都在编译期检查类型。
+// 处理函数按优先级从高到低依次调用，优先级相同时按订阅顺序。
+// 投递前会复制订阅者列表，因此处理函数中可以安全地订阅、退订或再次发布。
+//
+// 处理函数可以返回两个特殊错误：
+//
+//	ErrUnsubscribe      退订自己，之后不再收到事件（包括已经排队的异步事件）
+//	ErrStopPropagation  本次事件不再传给后面的处理函数
+//
+// 其他错误会被收集起来，由 Publish 以 errors.Join 的形式返回。
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrClosed 总线已关闭
+	ErrClosed = errors.New("event: 总线已关闭")
+	// ErrUnsubscribe 处理函数返回它以退订自己，不计为错误
+	ErrUnsubscribe = errors.New("event: 退订")
+	// ErrStopPropagation 处理函数返回它以阻止低优先级的处理函数收到本次事件，不计为错误
+	ErrStopPropagation = errors.New("event: 停止传播")
+	// ErrHandlerPanic 处理函数发生 panic
+	ErrHandlerPanic = errors.New("event: 处理函数 panic")
+)
+
+// Handler 事件处理函数
+type Handler[E any] func(ctx context.Context, e E) error
+
+// HandlerError 某个处理函数返回的错误
+type HandlerError struct {
+	Subscriber string // 订阅者名称，未设置时为 "#<编号>"
+	Err        error
+}
+
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("event: 订阅者 %s: %v", e.Subscriber, e.Err)
+}
+
+func (e *HandlerError) Unwrap() error { return e.Err }
+
+// SubscribeOption 订阅选项
+type SubscribeOption func(*subscribeConfig)
+
+type subscribeConfig struct {
+	name     string
+	priority int
+	once     bool
+}
+
+// WithPriority 设置优先级，数值大的先收到事件，默认 0
+func WithPriority(p int) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.priority = p
+	}
+}
+
+// WithName 设置订阅者名称，用于错误信息
+func WithName(name string) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.name = name
+	}
+}
+
+// Once 只接收一次事件，即使事件被并发发布也只会调用一次
+func Once() SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.once = true
+	}
+}
+
+// subscriber 一个订阅
+type subscriber[E any] struct {
+	id      uint64
+	cfg     subscribeConfig
+	handler Handler[E]
+	active  atomic.Bool
+}
+
+func (s *subscriber[E]) name() string {
+	if s.cfg.name != "" {
+		return s.cfg.name
+	}
+	return fmt.Sprintf("#%d", s.id)
+}
+
+// call 调用处理函数，把 panic 转为错误
+func (s *subscriber[E]) call(ctx context.Context, e E) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrHandlerPanic, r)
+		}
+	}()
+	return s.handler(ctx, e)
+}
+
+// Subscription 订阅句柄
+type Subscription struct {
+	once   sync.Once
+	cancel func()
+	active func() bool
+}
+
+// Unsubscribe 退订，可重复调用，也可以在处理函数内部调用
+//
+// 返回后该订阅不会再被调用；正在执行的调用不受影响。
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(s.cancel)
+}
+
+// Active 订阅是否仍然有效
+func (s *Subscription) Active() bool {
+	return s.active()
+}
+
+// Bus 类型为 E 的事件总线，零值不可用，请使用 New
+type Bus[E any] struct {
+	mu     sync.RWMutex
+	subs   []*subscriber[E] // 按优先级降序、订阅顺序升序
+	nextID uint64
+	closed bool
+	wg     sync.WaitGroup // 未完成的异步投递
+}
+
+// New 创建事件总线
+func New[E any]() *Bus[E] {
+	return &Bus[E]{}
+}
+
+// Subscribe 订阅事件
+func (b *Bus[E]) Subscribe(h Handler[E], opts ...SubscribeOption) *Subscription {
+	s := &subscriber[E]{handler: h}
+	for _, opt := range opts {
+		opt(&s.cfg)
+	}
+	s.active.Store(true)
+
+	b.mu.Lock()
+	b.nextID++
+	s.id = b.nextID
+	// 插入到同优先级订阅者的末尾
+	i, _ := slices.BinarySearchFunc(b.subs, s, func(x, target *subscriber[E]) int {
+		if x.cfg.priority >= target.cfg.priority {
+			return -1
+		}
+		return 1
+	})
+	b.subs = slices.Insert(b.subs, i, s)
+	b.mu.Unlock()
+
+	return &Subscription{
+		cancel: func() { b.remove(s) },
+		active: s.active.Load,
+	}
+}
+
+// remove 删除订阅者
+func (b *Bus[E]) remove(s *subscriber[E]) {
+	s.active.Store(false)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if i := slices.Index(b.subs, s); i >= 0 {
+		b.subs = slices.Delete(b.subs, i, i+1)
+	}
+}
+
+// Len 当前订阅者数量
+func (b *Bus[E]) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Publish 同步发布事件，所有处理函数返回后才返回
+//
+// 返回所有处理函数错误的合并（每个都包装为 *HandlerError）；
+// ctx 在投递过程中被取消时停止投递并附带 ctx.Err()。
+func (b *Bus[E]) Publish(ctx context.Context, e E) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	subs := slices.Clone(b.subs)
+	b.mu.RUnlock()
+	return b.dispatch(ctx, e, subs)
+}
+
+// PublishAsync 在新的 goroutine 中发布事件，投递完成后从返回的通道收到结果
+//
+// 同一事件的处理函数仍按优先级依次调用；不同事件之间不保证顺序。
+// 订阅者列表在调用时确定，之后订阅的不会收到该事件。
+func (b *Bus[E]) PublishAsync(ctx context.Context, e E) <-chan error {
+	done := make(chan error, 1)
+
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		done <- ErrClosed
+		close(done)
+		return done
+	}
+	subs := slices.Clone(b.subs)
+	b.wg.Add(1)
+	b.mu.RUnlock()
+
+	go func() {
+		defer b.wg.Done()
+		done <- b.dispatch(ctx, e, subs)
+		close(done)
+	}()
+	return done
+}
+
+// Wait 等待所有异步投递完成
+func (b *Bus[E]) Wait() {
+	b.wg.Wait()
+}
+
+// Close 关闭总线：之后的发布返回 ErrClosed，并等待进行中的异步投递完成
+func (b *Bus[E]) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.wg.Wait()
+}
+
+// dispatch 依次调用订阅者
+func (b *Bus[E]) dispatch(ctx context.Context, e E, subs []*subscriber[E]) error {
+	var errs []error
+	for _, s := range subs {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if s.cfg.once {
+			// 抢到的调用者负责退订，其余的直接跳过
+			if !s.active.CompareAndSwap(true, false) {
+				continue
+			}
+			b.remove(s)
+		} else if !s.active.Load() {
+			continue
+		}
+
+		err := s.call(ctx, e)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrUnsubscribe):
+			b.remove(s)
+		case errors.Is(err, ErrStopPropagation):
+			return errors.Join(errs...)
+		default:
+			errs = append(errs, &HandlerError{Subscriber: s.name(), Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,230 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type orderPlaced struct {
+	ID    int
+	Total float64
+}
+
+// recorder 记录处理函数的调用顺序
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) handler(name string, err error) Handler[orderPlaced] {
+	return func(_ context.Context, e orderPlaced) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.calls = append(r.calls, fmt.Sprintf("%s:%d", name, e.ID))
+		return err
+	}
+}
+
+func (r *recorder) take() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := r.calls
+	r.calls = nil
+	return calls
+}
+
+func TestPriorityOrder(t *testing.T) {
+	bus := New[orderPlaced]()
+	var r recorder
+	bus.Subscribe(r.handler("low", nil), WithPriority(-1))
+	bus.Subscribe(r.handler("a", nil))
+	bus.Subscribe(r.handler("high", nil), WithPriority(10))
+	bus.Subscribe(r.handler("b", nil))
+
+	if err := bus.Publish(context.Background(), orderPlaced{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"high:1", "a:1", "b:1", "low:1"}
+	if got := r.take(); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestErrorCollection(t *testing.T) {
+	bus := New[orderPlaced]()
+	var r recorder
+	errStock := errors.New("库存不足")
+	bus.Subscribe(r.handler("stock", errStock), WithName("inventory"))
+	bus.Subscribe(func(context.Context, orderPlaced) error { panic("boom") })
+	bus.Subscribe(r.handler("mail", nil))
+
+	err := bus.Publish(context.Background(), orderPlaced{ID: 7})
+	if !errors.Is(err, errStock) || !errors.Is(err, ErrHandlerPanic) {
+		t.Fatalf("expected both handler errors, got %v", err)
+	}
+	var he *HandlerError
+	if !errors.As(err, &he) || he.Subscriber != "inventory" {
+		t.Errorf("expected HandlerError from inventory, got %v", he)
+	}
+	// 出错的处理函数不影响后续投递
+	if got := r.take(); !slices.Equal(got, []string{"stock:7", "mail:7"}) {
+		t.Errorf("unexpected calls %v", got)
+	}
+}
+
+func TestStopReceiving(t *testing.T) {
+	bus := New[orderPlaced]()
+	var r recorder
+
+	// 返回 ErrUnsubscribe 退订自己
+	bus.Subscribe(func(ctx context.Context, e orderPlaced) error {
+		r.handler("quit", nil)(ctx, e)
+		if e.ID >= 2 {
+			return ErrUnsubscribe
+		}
+		return nil
+	})
+	// 在处理函数中调用 Unsubscribe 退订另一个订阅者
+	var victim *Subscription
+	bus.Subscribe(func(ctx context.Context, e orderPlaced) error {
+		victim.Unsubscribe()
+		return r.handler("killer", nil)(ctx, e)
+	}, WithPriority(1))
+	victim = bus.Subscribe(r.handler("victim", nil), WithPriority(-1))
+	bus.Subscribe(r.handler("once", nil), Once())
+
+	for id := 1; id <= 3; id++ {
+		if err := bus.Publish(context.Background(), orderPlaced{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"killer:1", "quit:1", "once:1", "killer:2", "quit:2", "killer:3"}
+	if got := r.take(); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if victim.Active() || bus.Len() != 1 {
+		t.Errorf("expected only killer to remain, %d subscribers", bus.Len())
+	}
+}
+
+func TestStopPropagation(t *testing.T) {
+	bus := New[orderPlaced]()
+	var r recorder
+	bus.Subscribe(func(ctx context.Context, e orderPlaced) error {
+		r.handler("fraud", nil)(ctx, e)
+		if e.Total > 1000 {
+			return fmt.Errorf("订单 %d 可疑: %w", e.ID, ErrStopPropagation)
+		}
+		return nil
+	}, WithPriority(100))
+	bus.Subscribe(r.handler("ship", nil))
+
+	bus.Publish(context.Background(), orderPlaced{ID: 1, Total: 10})
+	if err := bus.Publish(context.Background(), orderPlaced{ID: 2, Total: 5000}); err != nil {
+		t.Errorf("stop propagation is not an error: %v", err)
+	}
+	want := []string{"fraud:1", "ship:1", "fraud:2"}
+	if got := r.take(); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	bus := New[orderPlaced]()
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	bus.Subscribe(func(context.Context, orderPlaced) error {
+		calls.Add(1)
+		cancel()
+		return nil
+	})
+	bus.Subscribe(func(context.Context, orderPlaced) error {
+		calls.Add(1)
+		return nil
+	})
+	if err := bus.Publish(ctx, orderPlaced{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected delivery to stop after cancel, got %d calls", calls.Load())
+	}
+}
+
+func TestAsyncAndClose(t *testing.T) {
+	bus := New[orderPlaced]()
+	var sum atomic.Int64
+	errOdd := errors.New("odd")
+	bus.Subscribe(func(_ context.Context, e orderPlaced) error {
+		sum.Add(int64(e.ID))
+		if e.ID%2 == 1 {
+			return errOdd
+		}
+		return nil
+	})
+
+	results := make([]<-chan error, 100)
+	for i := range results {
+		results[i] = bus.PublishAsync(context.Background(), orderPlaced{ID: i})
+	}
+	failed := 0
+	for _, ch := range results {
+		if err := <-ch; errors.Is(err, errOdd) {
+			failed++
+		}
+	}
+	if failed != 50 || sum.Load() != 4950 {
+		t.Errorf("failed=%d sum=%d", failed, sum.Load())
+	}
+
+	bus.Close()
+	if err := bus.Publish(context.Background(), orderPlaced{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if err := <-bus.PublishAsync(context.Background(), orderPlaced{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+// TestConcurrent 并发订阅、退订和发布，配合 -race 运行
+func TestConcurrent(t *testing.T) {
+	bus := New[orderPlaced]()
+	var onceCalls, total atomic.Int32
+	bus.Subscribe(func(context.Context, orderPlaced) error {
+		onceCalls.Add(1)
+		return nil
+	}, Once())
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				sub := bus.Subscribe(func(context.Context, orderPlaced) error {
+					total.Add(1)
+					return nil
+				}, WithPriority(i%3))
+				if i%2 == 0 {
+					bus.Publish(context.Background(), orderPlaced{ID: w*100 + i})
+				} else {
+					bus.PublishAsync(context.Background(), orderPlaced{ID: w*100 + i})
+				}
+				sub.Unsubscribe()
+			}
+		}()
+	}
+	wg.Wait()
+	bus.Wait()
+
+	if onceCalls.Load() != 1 {
+		t.Errorf("Once handler called %d times", onceCalls.Load())
+	}
+	if total.Load() == 0 || bus.Len() != 0 {
+		t.Errorf("total=%d, remaining subscribers=%d", total.Load(), bus.Len())
+	}
+}
@@ -0,0 +1,55 @@
+package fsm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT 以 Graphviz DOT 格式输出状态图，状态和事件用 %v 格式化
+func (d *Definition[S, E]) WriteDOT(w io.Writer, name string) error {
+	return writeDOT(w, name, d, nil)
+}
+
+// DOT 以字符串形式返回 WriteDOT 的结果
+func (d *Definition[S, E]) DOT(name string) string {
+	var b strings.Builder
+	d.WriteDOT(&b, name)
+	return b.String()
+}
+
+// WriteDOT 输出状态图，当前状态填充显示，有守卫的转移用虚线表示
+func (m *Machine[S, E]) WriteDOT(w io.Writer, name string) error {
+	return writeDOT(w, name, m.def, m)
+}
+
+// DOT 以字符串形式返回 WriteDOT 的结果
+func (m *Machine[S, E]) DOT(name string) string {
+	var b strings.Builder
+	m.WriteDOT(&b, name)
+	return b.String()
+}
+
+func writeDOT[S, E comparable](w io.Writer, name string, d *Definition[S, E], m *Machine[S, E]) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", name)
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=circle];\n")
+	for _, s := range d.spec.States {
+		if m != nil && m.current == s {
+			fmt.Fprintf(&b, "\t%q [style=filled, fillcolor=lightblue];\n", fmt.Sprint(s))
+		} else {
+			fmt.Fprintf(&b, "\t%q;\n", fmt.Sprint(s))
+		}
+	}
+	for _, t := range d.spec.Transitions {
+		attrs := fmt.Sprintf("label=%q", fmt.Sprint(t.Event))
+		if m != nil && len(m.guards[edge[S, E]{t.From, t.Event}]) > 0 {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "\t%q -> %q [%s];\n", fmt.Sprint(t.From), fmt.Sprint(t.To), attrs)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
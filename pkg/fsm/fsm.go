@@ -0,0 +1,285 @@
+// Package fsm 泛型有限状态机
+//
+// Definition 描述状态、事件和转移规则，构建后只读，可以被任意多个状态机共享；
+// Machine 是定义的一个实例，保存当前状态以及该实例自己的守卫和钩子。
+//
+// 一次 Fire 的执行顺序：
+//
+//	守卫 -> 离开 From 的钩子 -> 进入 To 的钩子 -> 切换状态 -> 转移完成的回调
+//
+// 守卫或钩子返回错误时转移取消，状态保持不变；已经执行的离开钩子不会被撤销。
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrInvalidTransition 当前状态不能响应该事件
+	ErrInvalidTransition = errors.New("fsm: 无效的状态转移")
+	// ErrUnknownState 未声明的状态
+	ErrUnknownState = errors.New("fsm: 未声明的状态")
+	// ErrUnknownEvent 未声明的事件
+	ErrUnknownEvent = errors.New("fsm: 未声明的事件")
+	// ErrDuplicateTransition 同一状态对同一事件声明了多条转移
+	ErrDuplicateTransition = errors.New("fsm: 重复的状态转移")
+	// ErrRejected 守卫拒绝了转移
+	ErrRejected = errors.New("fsm: 转移被拒绝")
+)
+
+// Transition 一条状态转移
+type Transition[S, E comparable] struct {
+	From  S
+	Event E
+	To    S
+}
+
+// String 转移的文字表示，例如 "inactive --activate--> active"
+func (t Transition[S, E]) String() string {
+	return fmt.Sprintf("%v --%v--> %v", t.From, t.Event, t.To)
+}
+
+// Spec 状态机的声明
+type Spec[S, E comparable] struct {
+	States      []S // 按声明顺序导出 DOT
+	Events      []E
+	Transitions []Transition[S, E]
+}
+
+// Definition 校验过的状态机定义
+type Definition[S, E comparable] struct {
+	spec  Spec[S, E]
+	edges map[S]map[E]S
+}
+
+// Define 校验声明并创建定义：转移中的状态和事件必须都已声明，
+// 同一状态对同一事件只能有一条转移
+func Define[S, E comparable](spec Spec[S, E]) (*Definition[S, E], error) {
+	d := &Definition[S, E]{
+		spec: Spec[S, E]{
+			States:      slices.Clone(spec.States),
+			Events:      slices.Clone(spec.Events),
+			Transitions: slices.Clone(spec.Transitions),
+		},
+		edges: make(map[S]map[E]S, len(spec.States)),
+	}
+	for _, s := range spec.States {
+		if _, ok := d.edges[s]; ok {
+			return nil, fmt.Errorf("fsm: 状态 %v 重复声明", s)
+		}
+		d.edges[s] = make(map[E]S)
+	}
+	for _, t := range spec.Transitions {
+		if err := d.checkState(t.From); err != nil {
+			return nil, fmt.Errorf("%w（转移 %v）", err, t)
+		}
+		if err := d.checkState(t.To); err != nil {
+			return nil, fmt.Errorf("%w（转移 %v）", err, t)
+		}
+		if err := d.checkEvent(t.Event); err != nil {
+			return nil, fmt.Errorf("%w（转移 %v）", err, t)
+		}
+		if to, ok := d.edges[t.From][t.Event]; ok {
+			return nil, fmt.Errorf("%w: 状态 %v 对事件 %v 已有转移到 %v", ErrDuplicateTransition, t.From, t.Event, to)
+		}
+		d.edges[t.From][t.Event] = t.To
+	}
+	return d, nil
+}
+
+// MustDefine 与 Define 相同，声明有误时 panic，用于初始化包级变量
+func MustDefine[S, E comparable](spec Spec[S, E]) *Definition[S, E] {
+	d, err := Define(spec)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d *Definition[S, E]) checkState(s S) error {
+	if _, ok := d.edges[s]; !ok {
+		return fmt.Errorf("%w: %v", ErrUnknownState, s)
+	}
+	return nil
+}
+
+func (d *Definition[S, E]) checkEvent(e E) error {
+	if !slices.Contains(d.spec.Events, e) {
+		return fmt.Errorf("%w: %v", ErrUnknownEvent, e)
+	}
+	return nil
+}
+
+// States 声明的状态
+func (d *Definition[S, E]) States() []S { return slices.Clone(d.spec.States) }
+
+// Events 声明的事件
+func (d *Definition[S, E]) Events() []E { return slices.Clone(d.spec.Events) }
+
+// Transitions 声明的转移，按声明顺序
+func (d *Definition[S, E]) Transitions() []Transition[S, E] {
+	return slices.Clone(d.spec.Transitions)
+}
+
+// Target 状态 from 收到事件 e 后的目标状态
+func (d *Definition[S, E]) Target(from S, e E) (S, bool) {
+	to, ok := d.edges[from][e]
+	return to, ok
+}
+
+// Hook 进入或离开状态时调用的钩子，返回错误会取消转移
+type Hook[S, E comparable] func(ctx context.Context, t Transition[S, E]) error
+
+// Guard 转移守卫，返回错误表示拒绝，错误会以 ErrRejected 包装后由 Fire 返回
+type Guard[S, E comparable] func(ctx context.Context, t Transition[S, E]) error
+
+// Option 状态机实例的选项
+type Option[S, E comparable] func(*Machine[S, E])
+
+// OnEnter 进入状态 s 时调用 hook，可以多次设置，按顺序调用
+func OnEnter[S, E comparable](s S, hook Hook[S, E]) Option[S, E] {
+	return func(m *Machine[S, E]) {
+		m.enter[s] = append(m.enter[s], hook)
+	}
+}
+
+// OnExit 离开状态 s 时调用 hook
+func OnExit[S, E comparable](s S, hook Hook[S, E]) Option[S, E] {
+	return func(m *Machine[S, E]) {
+		m.exit[s] = append(m.exit[s], hook)
+	}
+}
+
+// WithGuard 为状态 from 收到事件 e 的转移设置守卫，其他状态对 e 的转移不受影响
+func WithGuard[S, E comparable](from S, e E, guard Guard[S, E]) Option[S, E] {
+	return func(m *Machine[S, E]) {
+		k := edge[S, E]{from, e}
+		m.guards[k] = append(m.guards[k], guard)
+	}
+}
+
+// AfterTransition 每次转移完成后调用 fn，例如用于记录日志
+func AfterTransition[S, E comparable](fn func(ctx context.Context, t Transition[S, E])) Option[S, E] {
+	return func(m *Machine[S, E]) {
+		m.after = append(m.after, fn)
+	}
+}
+
+// edge 标识一条转移：起始状态和事件
+type edge[S, E comparable] struct {
+	from  S
+	event E
+}
+
+// Machine 状态机实例
+//
+// Machine 不是并发安全的，多个 goroutine 共用时由调用方加锁；
+// 钩子在 Fire 中同步执行，因此可以在调用方的锁内访问受保护的数据。
+type Machine[S, E comparable] struct {
+	def     *Definition[S, E]
+	current S
+	guards  map[edge[S, E]][]Guard[S, E]
+	enter   map[S][]Hook[S, E]
+	exit    map[S][]Hook[S, E]
+	after   []func(ctx context.Context, t Transition[S, E])
+	firing  bool
+}
+
+// New 以 initial 为初始状态创建状态机实例，初始状态的进入钩子不会被调用
+func (d *Definition[S, E]) New(initial S, opts ...Option[S, E]) (*Machine[S, E], error) {
+	if err := d.checkState(initial); err != nil {
+		return nil, err
+	}
+	m := &Machine[S, E]{
+		def:     d,
+		current: initial,
+		guards:  make(map[edge[S, E]][]Guard[S, E]),
+		enter:   make(map[S][]Hook[S, E]),
+		exit:    make(map[S][]Hook[S, E]),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// MustNew 与 New 相同，初始状态未声明时 panic
+func (d *Definition[S, E]) MustNew(initial S, opts ...Option[S, E]) *Machine[S, E] {
+	m, err := d.New(initial, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Definition 状态机的定义
+func (m *Machine[S, E]) Definition() *Definition[S, E] { return m.def }
+
+// Current 当前状态
+func (m *Machine[S, E]) Current() S { return m.current }
+
+// Is 当前状态是否为 s
+func (m *Machine[S, E]) Is(s S) bool { return m.current == s }
+
+// Can 当前状态是否有事件 e 的转移（不检查守卫）
+func (m *Machine[S, E]) Can(e E) bool {
+	_, ok := m.def.Target(m.current, e)
+	return ok
+}
+
+// Available 当前状态可以响应的事件，按声明顺序
+func (m *Machine[S, E]) Available() []E {
+	var events []E
+	for _, e := range m.def.spec.Events {
+		if m.Can(e) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// Fire 触发事件
+//
+// 事件未声明时返回 ErrUnknownEvent，当前状态没有对应转移时返回 ErrInvalidTransition，
+// 守卫拒绝时返回包装了守卫错误的 ErrRejected，钩子的错误原样返回。
+// 不能在钩子中再次调用 Fire。
+func (m *Machine[S, E]) Fire(ctx context.Context, e E) error {
+	if err := m.def.checkEvent(e); err != nil {
+		return err
+	}
+	if m.firing {
+		return fmt.Errorf("%w: 不能在钩子中触发事件 %v", ErrInvalidTransition, e)
+	}
+	to, ok := m.def.Target(m.current, e)
+	if !ok {
+		return fmt.Errorf("%w: 状态 %v 不能响应事件 %v", ErrInvalidTransition, m.current, e)
+	}
+	t := Transition[S, E]{From: m.current, Event: e, To: to}
+
+	m.firing = true
+	defer func() { m.firing = false }()
+
+	for _, guard := range m.guards[edge[S, E]{t.From, e}] {
+		if err := guard(ctx, t); err != nil {
+			return fmt.Errorf("%w: %v: %w", ErrRejected, t, err)
+		}
+	}
+	for _, hook := range m.exit[t.From] {
+		if err := hook(ctx, t); err != nil {
+			return err
+		}
+	}
+	for _, hook := range m.enter[t.To] {
+		if err := hook(ctx, t); err != nil {
+			return err
+		}
+	}
+	m.current = to
+	for _, fn := range m.after {
+		fn(ctx, t)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package fsm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type state string
+type event string
+
+var door = MustDefine(Spec[state, event]{
+	States: []state{"closed", "open", "locked"},
+	Events: []event{"open", "close", "lock", "unlock"},
+	Transitions: []Transition[state, event]{
+		{"closed", "open", "open"},
+		{"open", "close", "closed"},
+		{"closed", "lock", "locked"},
+		{"locked", "unlock", "closed"},
+	},
+})
+
+func TestDefine(t *testing.T) {
+	tests := []struct {
+		name string
+		spec Spec[state, event]
+		err  error
+	}{
+		{"unknown from", Spec[state, event]{States: []state{"a"}, Events: []event{"e"}, Transitions: []Transition[state, event]{{"x", "e", "a"}}}, ErrUnknownState},
+		{"unknown to", Spec[state, event]{States: []state{"a"}, Events: []event{"e"}, Transitions: []Transition[state, event]{{"a", "e", "x"}}}, ErrUnknownState},
+		{"unknown event", Spec[state, event]{States: []state{"a"}, Transitions: []Transition[state, event]{{"a", "e", "a"}}}, ErrUnknownEvent},
+		{"duplicate", Spec[state, event]{States: []state{"a", "b"}, Events: []event{"e"}, Transitions: []Transition[state, event]{{"a", "e", "a"}, {"a", "e", "b"}}}, ErrDuplicateTransition},
+	}
+	for _, tt := range tests {
+		if _, err := Define(tt.spec); !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+	if _, err := door.New("ajar"); !errors.Is(err, ErrUnknownState) {
+		t.Errorf("expected ErrUnknownState for initial state, got %v", err)
+	}
+}
+
+func TestFire(t *testing.T) {
+	ctx := context.Background()
+	var trace []string
+	record := func(prefix string) Hook[state, event] {
+		return func(_ context.Context, t Transition[state, event]) error {
+			trace = append(trace, prefix+" "+t.String())
+			return nil
+		}
+	}
+	hasKey := false
+	m := door.MustNew("closed",
+		OnExit("closed", record("exit")),
+		OnEnter("locked", record("enter")),
+		WithGuard("locked", "unlock", func(context.Context, Transition[state, event]) error {
+			if !hasKey {
+				return errors.New("no key")
+			}
+			return nil
+		}),
+		AfterTransition(func(_ context.Context, t Transition[state, event]) {
+			trace = append(trace, "after "+string(t.To))
+		}),
+	)
+
+	steps := []struct {
+		event event
+		err   error
+		state state
+	}{
+		{"close", ErrInvalidTransition, "closed"},
+		{"lock", nil, "locked"},
+		{"open", ErrInvalidTransition, "locked"},
+		{"unlock", ErrRejected, "locked"},
+		{"kick", ErrUnknownEvent, "locked"},
+	}
+	for _, s := range steps {
+		if err := m.Fire(ctx, s.event); !errors.Is(err, s.err) {
+			t.Errorf("%s: expected %v, got %v", s.event, s.err, err)
+		}
+		if m.Current() != s.state {
+			t.Errorf("%s: expected state %s, got %s", s.event, s.state, m.Current())
+		}
+	}
+	want := "exit closed --lock--> locked|enter closed --lock--> locked|after locked"
+	if got := strings.Join(trace, "|"); got != want {
+		t.Errorf("unexpected trace %q", got)
+	}
+
+	hasKey = true
+	if err := m.Fire(ctx, "unlock"); err != nil || !m.Is("closed") {
+		t.Fatalf("unlock: %v, state %s", err, m.Current())
+	}
+	if got := m.Available(); len(got) != 2 || got[0] != "open" || got[1] != "lock" {
+		t.Errorf("unexpected available events %v", got)
+	}
+}
+
+func TestHookErrorCancels(t *testing.T) {
+	ctx := context.Background()
+	boom := errors.New("boom")
+	var m *Machine[state, event]
+	m = door.MustNew("closed", OnEnter("open", func(ctx context.Context, _ Transition[state, event]) error {
+		if err := m.Fire(ctx, "close"); !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("re-entrant Fire: expected ErrInvalidTransition, got %v", err)
+		}
+		return boom
+	}))
+	if err := m.Fire(ctx, "open"); !errors.Is(err, boom) {
+		t.Errorf("expected boom, got %v", err)
+	}
+	if !m.Is("closed") {
+		t.Errorf("failed hook should keep the state, got %s", m.Current())
+	}
+	// 钩子失败后状态机仍然可用
+	if err := m.Fire(ctx, "lock"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDOT(t *testing.T) {
+	want := `digraph "door" {
+	rankdir=LR;
+	node [shape=circle];
+	"closed";
+	"open";
+	"locked";
+	"closed" -> "open" [label="open"];
+	"open" -> "closed" [label="close"];
+	"closed" -> "locked" [label="lock"];
+	"locked" -> "closed" [label="unlock"];
+}
+`
+	if got := door.DOT("door"); got != want {
+		t.Errorf("unexpected DOT:\n%s", got)
+	}
+
+	m := door.MustNew("locked", WithGuard("locked", "unlock", func(context.Context, Transition[state, event]) error { return nil }))
+	got := m.DOT("door")
+	for _, line := range []string{`"locked" [style=filled, fillcolor=lightblue];`, `"locked" -> "closed" [label="unlock", style=dashed];`} {
+		if !strings.Contains(got, line) {
+			t.Errorf("expected %q in:\n%s", line, got)
+		}
+	}
+}
+
+func TestGuardPerTransition(t *testing.T) {
+	ctx := context.Background()
+	// 同一事件 next 从两个状态出发，只有 a -> b 有守卫
+	d := MustDefine(Spec[state, event]{
+		States: []state{"a", "b", "c"},
+		Events: []event{"next"},
+		Transitions: []Transition[state, event]{
+			{"a", "next", "b"},
+			{"b", "next", "c"},
+		},
+	})
+	reject := func(context.Context, Transition[state, event]) error { return errors.New("no") }
+
+	m := d.MustNew("b", WithGuard("a", "next", reject))
+	if err := m.Fire(ctx, "next"); err != nil || !m.Is("c") {
+		t.Errorf("unguarded transition: %v, state %s", err, m.Current())
+	}
+	m = d.MustNew("a", WithGuard("a", "next", reject))
+	if err := m.Fire(ctx, "next"); !errors.Is(err, ErrRejected) {
+		t.Errorf("guarded transition: expected ErrRejected, got %v", err)
+	}
+
+	got := m.DOT("g")
+	if !strings.Contains(got, `"a" -> "b" [label="next", style=dashed];`) || !strings.Contains(got, `"b" -> "c" [label="next"];`) {
+		t.Errorf("only the guarded edge should be dashed:\n%s", got)
+	}
+}
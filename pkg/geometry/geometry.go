@@ -0,0 +1,169 @@
+// Package geometry 二维几何：点与向量运算、线段、圆、矩形和任意多边形
+//
+// 所有形状都实现 Shape 接口，可以计算面积、周长、包围盒并做包含和相交测试。
+// 坐标比较使用 Epsilon 作为容差。
+package geometry
+
+import (
+	"fmt"
+	"math"
+)
+
+// Epsilon 浮点比较的容差
+const Epsilon = 1e-9
+
+// Point 平面上的点
+type Point struct {
+	X, Y float64
+}
+
+// Pt 创建点的简写
+func Pt(x, y float64) Point {
+	return Point{X: x, Y: y}
+}
+
+// Add 点沿向量平移
+func (p Point) Add(v Vector) Point {
+	return Point{p.X + v.X, p.Y + v.Y}
+}
+
+// Sub 返回从 q 指向 p 的向量
+func (p Point) Sub(q Point) Vector {
+	return Vector{p.X - q.X, p.Y - q.Y}
+}
+
+// Distance 两点间距离
+func (p Point) Distance(q Point) float64 {
+	return p.Sub(q).Len()
+}
+
+// Eq 在容差范围内比较两点
+func (p Point) Eq(q Point) bool {
+	return math.Abs(p.X-q.X) <= Epsilon && math.Abs(p.Y-q.Y) <= Epsilon
+}
+
+// String 点的字符串表示
+func (p Point) String() string {
+	return fmt.Sprintf("(%.2f, %.2f)", p.X, p.Y)
+}
+
+// Vector 二维向量
+type Vector struct {
+	X, Y float64
+}
+
+// Vec 创建向量的简写
+func Vec(x, y float64) Vector {
+	return Vector{X: x, Y: y}
+}
+
+// Add 向量加法
+func (v Vector) Add(w Vector) Vector {
+	return Vector{v.X + w.X, v.Y + w.Y}
+}
+
+// Sub 向量减法
+func (v Vector) Sub(w Vector) Vector {
+	return Vector{v.X - w.X, v.Y - w.Y}
+}
+
+// Scale 数乘
+func (v Vector) Scale(k float64) Vector {
+	return Vector{v.X * k, v.Y * k}
+}
+
+// Dot 点积
+func (v Vector) Dot(w Vector) float64 {
+	return v.X*w.X + v.Y*w.Y
+}
+
+// Cross 叉积的 z 分量，w 在 v 的逆时针方向时为正
+func (v Vector) Cross(w Vector) float64 {
+	return v.X*w.Y - v.Y*w.X
+}
+
+// Len 向量长度
+func (v Vector) Len() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// Unit 同方向的单位向量，零向量返回零向量
+func (v Vector) Unit() Vector {
+	l := v.Len()
+	if l == 0 {
+		return Vector{}
+	}
+	return v.Scale(1 / l)
+}
+
+// Rotate 逆时针旋转 theta 弧度
+func (v Vector) Rotate(theta float64) Vector {
+	sin, cos := math.Sincos(theta)
+	return Vector{v.X*cos - v.Y*sin, v.X*sin + v.Y*cos}
+}
+
+// Angle 与 x 轴正方向的夹角，范围 (-π, π]
+func (v Vector) Angle() float64 {
+	return math.Atan2(v.Y, v.X)
+}
+
+// Segment 线段
+type Segment struct {
+	A, B Point
+}
+
+// Len 线段长度
+func (s Segment) Len() float64 {
+	return s.A.Distance(s.B)
+}
+
+// ClosestPoint 线段上离 p 最近的点
+func (s Segment) ClosestPoint(p Point) Point {
+	d := s.B.Sub(s.A)
+	l2 := d.Dot(d)
+	if l2 == 0 {
+		return s.A
+	}
+	t := math.Max(0, math.Min(1, p.Sub(s.A).Dot(d)/l2))
+	return s.A.Add(d.Scale(t))
+}
+
+// Distance 点到线段的距离
+func (s Segment) Distance(p Point) float64 {
+	return p.Distance(s.ClosestPoint(p))
+}
+
+// Intersects 两条线段是否相交（包括端点接触和共线重叠）
+func (s Segment) Intersects(t Segment) bool {
+	d1 := orient(t.A, t.B, s.A)
+	d2 := orient(t.A, t.B, s.B)
+	d3 := orient(s.A, s.B, t.A)
+	d4 := orient(s.A, s.B, t.B)
+
+	if d1*d2 < 0 && d3*d4 < 0 {
+		return true
+	}
+	return (d1 == 0 && onSegment(t, s.A)) ||
+		(d2 == 0 && onSegment(t, s.B)) ||
+		(d3 == 0 && onSegment(s, t.A)) ||
+		(d4 == 0 && onSegment(s, t.B))
+}
+
+// orient 返回 c 相对有向直线 ab 的方向：1 左侧，-1 右侧，0 共线
+func orient(a, b, c Point) int {
+	cross := b.Sub(a).Cross(c.Sub(a))
+	switch {
+	case cross > Epsilon:
+		return 1
+	case cross < -Epsilon:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// onSegment 已知 p 与 s 共线时判断 p 是否落在 s 的范围内
+func onSegment(s Segment, p Point) bool {
+	return p.X >= math.Min(s.A.X, s.B.X)-Epsilon && p.X <= math.Max(s.A.X, s.B.X)+Epsilon &&
+		p.Y >= math.Min(s.A.Y, s.B.Y)-Epsilon && p.Y <= math.Max(s.A.Y, s.B.Y)+Epsilon
+}
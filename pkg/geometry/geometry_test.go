@@ -0,0 +1,191 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestVector(t *testing.T) {
+	v := Vec(3, 4)
+	if v.Len() != 5 || !approx(v.Unit().Len(), 1) {
+		t.Errorf("unexpected length %v / unit %v", v.Len(), v.Unit())
+	}
+	if v.Dot(Vec(1, 0)) != 3 || Vec(1, 0).Cross(Vec(0, 1)) != 1 {
+		t.Error("dot/cross mismatch")
+	}
+	r := Vec(1, 0).Rotate(math.Pi / 2)
+	if !approx(r.X, 0) || !approx(r.Y, 1) {
+		t.Errorf("expected (0,1), got %v", r)
+	}
+	if p := Pt(1, 1).Add(v); p != Pt(4, 5) || Pt(4, 5).Sub(Pt(1, 1)) != v {
+		t.Error("point/vector arithmetic mismatch")
+	}
+	if Vec(0, 0).Unit() != (Vector{}) {
+		t.Error("unit of zero vector should be zero")
+	}
+}
+
+func TestSegmentIntersects(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Segment
+		want bool
+	}{
+		{"crossing", Segment{Pt(0, 0), Pt(2, 2)}, Segment{Pt(0, 2), Pt(2, 0)}, true},
+		{"parallel", Segment{Pt(0, 0), Pt(2, 0)}, Segment{Pt(0, 1), Pt(2, 1)}, false},
+		{"touching endpoint", Segment{Pt(0, 0), Pt(1, 1)}, Segment{Pt(1, 1), Pt(2, 0)}, true},
+		{"collinear overlap", Segment{Pt(0, 0), Pt(2, 0)}, Segment{Pt(1, 0), Pt(3, 0)}, true},
+		{"collinear disjoint", Segment{Pt(0, 0), Pt(1, 0)}, Segment{Pt(2, 0), Pt(3, 0)}, false},
+		{"t shape miss", Segment{Pt(0, 0), Pt(2, 0)}, Segment{Pt(1, 1), Pt(1, 0.5)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Intersects(tt.b); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+			if got := tt.b.Intersects(tt.a); got != tt.want {
+				t.Errorf("not symmetric: expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	s := Segment{Pt(0, 0), Pt(4, 0)}
+	if d := s.Distance(Pt(2, 3)); d != 3 {
+		t.Errorf("expected distance 3, got %v", d)
+	}
+	if d := s.Distance(Pt(7, 4)); d != 5 {
+		t.Errorf("expected distance to endpoint 5, got %v", d)
+	}
+}
+
+func TestPolygonMeasures(t *testing.T) {
+	square := Polygon{Pt(0, 0), Pt(4, 0), Pt(4, 4), Pt(0, 4)}
+	clockwise := Polygon{Pt(0, 0), Pt(0, 4), Pt(4, 4), Pt(4, 0)}
+	triangle := Polygon{Pt(0, 0), Pt(6, 0), Pt(3, 4)}
+
+	if square.Area() != 16 || square.SignedArea() != 16 || clockwise.SignedArea() != -16 {
+		t.Errorf("unexpected areas %v %v", square.SignedArea(), clockwise.SignedArea())
+	}
+	if triangle.Area() != 12 || triangle.Perimeter() != 16 {
+		t.Errorf("expected area 12 perimeter 16, got %v %v", triangle.Area(), triangle.Perimeter())
+	}
+	if c := square.Centroid(); !c.Eq(Pt(2, 2)) {
+		t.Errorf("expected centroid (2,2), got %v", c)
+	}
+	if b := triangle.Bounds(); b != NewRect(Pt(6, 4), Pt(0, 0)) {
+		t.Errorf("unexpected bounds %v", b)
+	}
+}
+
+func TestPolygonIsConvex(t *testing.T) {
+	tests := []struct {
+		name string
+		pg   Polygon
+		want bool
+	}{
+		{"square", Polygon{Pt(0, 0), Pt(1, 0), Pt(1, 1), Pt(0, 1)}, true},
+		{"clockwise square", Polygon{Pt(0, 0), Pt(0, 1), Pt(1, 1), Pt(1, 0)}, true},
+		{"collinear vertex", Polygon{Pt(0, 0), Pt(1, 0), Pt(2, 0), Pt(2, 2), Pt(0, 2)}, true},
+		{"arrow", Polygon{Pt(0, 0), Pt(2, 1), Pt(0, 2), Pt(1, 1)}, false},
+		{"pentagram", Polygon{Pt(0, 3), Pt(2, -3), Pt(-3, 1), Pt(3, 1), Pt(-2, -3)}, false},
+		{"degenerate", Polygon{Pt(0, 0), Pt(1, 1)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pg.IsConvex(); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestContains(t *testing.T) {
+	// 凹多边形（U 形）
+	u := Polygon{Pt(0, 0), Pt(3, 0), Pt(3, 3), Pt(2, 3), Pt(2, 1), Pt(1, 1), Pt(1, 3), Pt(0, 3)}
+	tests := []struct {
+		name  string
+		shape Shape
+		p     Point
+		want  bool
+	}{
+		{"polygon inside arm", u, Pt(0.5, 2), true},
+		{"polygon notch", u, Pt(1.5, 2), false},
+		{"polygon edge", u, Pt(1.5, 1), true},
+		{"polygon vertex", u, Pt(3, 3), true},
+		{"polygon outside", u, Pt(4, 1), false},
+		{"circle inside", Circle{Pt(0, 0), 2}, Pt(1, 1), true},
+		{"circle boundary", Circle{Pt(0, 0), 2}, Pt(0, 2), true},
+		{"circle outside", Circle{Pt(0, 0), 2}, Pt(2, 2), false},
+		{"rect inside", NewRect(Pt(0, 0), Pt(2, 1)), Pt(1, 0.5), true},
+		{"rect outside", NewRect(Pt(0, 0), Pt(2, 1)), Pt(1, 2), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.shape.Contains(tt.p); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// blob 不能表示为多边形的形状，用于测试退化为包围盒的路径
+type blob struct{ r Rect }
+
+func (b blob) Area() float64         { return b.r.Area() }
+func (b blob) Perimeter() float64    { return b.r.Perimeter() }
+func (b blob) Bounds() Rect          { return b.r }
+func (b blob) Contains(p Point) bool { return b.r.Contains(p) }
+
+func TestIntersects(t *testing.T) {
+	unit := NewRect(Pt(0, 0), Pt(1, 1))
+	tests := []struct {
+		name string
+		a, b Shape
+		want bool
+	}{
+		{"circles overlap", Circle{Pt(0, 0), 1}, Circle{Pt(1.5, 0), 1}, true},
+		{"circles touch", Circle{Pt(0, 0), 1}, Circle{Pt(2, 0), 1}, true},
+		{"circles apart", Circle{Pt(0, 0), 1}, Circle{Pt(3, 0), 1}, false},
+		{"circle near rect corner", Circle{Pt(2, 2), 1}, unit, false},
+		{"circle touches rect edge", Circle{Pt(2, 0.5), 1}, unit, true},
+		{"circle inside polygon", Circle{Pt(5, 5), 1}, NewRect(Pt(0, 0), Pt(10, 10)), true},
+		{"polygon inside circle", unit, Circle{Pt(0.5, 0.5), 5}, true},
+		{"rects overlap", unit, NewRect(Pt(0.5, 0.5), Pt(2, 2)), true},
+		{"rects apart", unit, NewRect(Pt(2, 2), Pt(3, 3)), false},
+		{
+			"triangles with overlapping bounds but no contact",
+			Polygon{Pt(0, 0), Pt(2, 0), Pt(0, 2)},
+			Polygon{Pt(2, 2), Pt(2, 1.5), Pt(1.5, 2)},
+			false,
+		},
+		{"nested polygons", Polygon{Pt(0, 0), Pt(10, 0), Pt(5, 10)}, Polygon{Pt(4, 2), Pt(6, 2), Pt(5, 4)}, true},
+		{"bounds fallback", blob{unit}, blob{NewRect(Pt(0.5, 0.5), Pt(2, 2))}, true},
+		{"circle pointer near rect corner", &Circle{Pt(2, 2), 1}, unit, false},
+		{"pointers apart", &Polygon{Pt(0, 0), Pt(2, 0), Pt(0, 2)}, &Circle{Pt(2, 2), 0.5}, false},
+		{"rect pointer", &unit, &Circle{Pt(2, 0.5), 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Intersects(tt.a, tt.b); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+			if got := Intersects(tt.b, tt.a); got != tt.want {
+				t.Errorf("not symmetric: expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	box := BoundingBox(Circle{Pt(0, 0), 1}, NewRect(Pt(2, 2), Pt(3, 5)))
+	if box != NewRect(Pt(-1, -1), Pt(3, 5)) {
+		t.Errorf("unexpected bounding box %v", box)
+	}
+	if BoundingBox() != (Rect{}) {
+		t.Error("expected zero rect for no shapes")
+	}
+}
@@ -0,0 +1,341 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+)
+
+// Shape 二维形状
+type Shape interface {
+	Area() float64
+	Perimeter() float64
+	Bounds() Rect
+	Contains(p Point) bool
+}
+
+// Polygonal 可以精确表示为多边形的形状
+type Polygonal interface {
+	Polygon() Polygon
+}
+
+// Circle 圆
+type Circle struct {
+	Center Point
+	Radius float64
+}
+
+// Area 圆的面积
+func (c Circle) Area() float64 {
+	return math.Pi * c.Radius * c.Radius
+}
+
+// Perimeter 圆的周长
+func (c Circle) Perimeter() float64 {
+	return 2 * math.Pi * c.Radius
+}
+
+// Bounds 包围盒
+func (c Circle) Bounds() Rect {
+	r := Vec(c.Radius, c.Radius)
+	return Rect{Min: c.Center.Add(r.Scale(-1)), Max: c.Center.Add(r)}
+}
+
+// Contains 点是否在圆内（含边界）
+func (c Circle) Contains(p Point) bool {
+	return p.Distance(c.Center) <= c.Radius+Epsilon
+}
+
+// String 圆的字符串表示
+func (c Circle) String() string {
+	return fmt.Sprintf("Circle{Center: %s, Radius: %.2f}", c.Center, c.Radius)
+}
+
+// Rect 轴对齐矩形，也用作包围盒
+type Rect struct {
+	Min, Max Point
+}
+
+// NewRect 由任意两个对角点创建矩形
+func NewRect(a, b Point) Rect {
+	return Rect{
+		Min: Point{math.Min(a.X, b.X), math.Min(a.Y, b.Y)},
+		Max: Point{math.Max(a.X, b.X), math.Max(a.Y, b.Y)},
+	}
+}
+
+// Width 宽度
+func (r Rect) Width() float64 {
+	return r.Max.X - r.Min.X
+}
+
+// Height 高度
+func (r Rect) Height() float64 {
+	return r.Max.Y - r.Min.Y
+}
+
+// Center 中心点
+func (r Rect) Center() Point {
+	return Point{(r.Min.X + r.Max.X) / 2, (r.Min.Y + r.Max.Y) / 2}
+}
+
+// Area 面积
+func (r Rect) Area() float64 {
+	return r.Width() * r.Height()
+}
+
+// Perimeter 周长
+func (r Rect) Perimeter() float64 {
+	return 2 * (r.Width() + r.Height())
+}
+
+// Bounds 矩形自身
+func (r Rect) Bounds() Rect {
+	return r
+}
+
+// Contains 点是否在矩形内（含边界）
+func (r Rect) Contains(p Point) bool {
+	return p.X >= r.Min.X-Epsilon && p.X <= r.Max.X+Epsilon &&
+		p.Y >= r.Min.Y-Epsilon && p.Y <= r.Max.Y+Epsilon
+}
+
+// Overlaps 两个矩形是否重叠（含边界接触）
+func (r Rect) Overlaps(s Rect) bool {
+	return r.Min.X <= s.Max.X+Epsilon && s.Min.X <= r.Max.X+Epsilon &&
+		r.Min.Y <= s.Max.Y+Epsilon && s.Min.Y <= r.Max.Y+Epsilon
+}
+
+// Union 同时包含两个矩形的最小矩形
+func (r Rect) Union(s Rect) Rect {
+	return Rect{
+		Min: Point{math.Min(r.Min.X, s.Min.X), math.Min(r.Min.Y, s.Min.Y)},
+		Max: Point{math.Max(r.Max.X, s.Max.X), math.Max(r.Max.Y, s.Max.Y)},
+	}
+}
+
+// Polygon 按逆时针顺序返回四个顶点
+func (r Rect) Polygon() Polygon {
+	return Polygon{r.Min, {r.Max.X, r.Min.Y}, r.Max, {r.Min.X, r.Max.Y}}
+}
+
+// String 矩形的字符串表示
+func (r Rect) String() string {
+	return fmt.Sprintf("Rect{Min: %s, Max: %s}", r.Min, r.Max)
+}
+
+// Polygon 简单多边形，顶点按顺序排列，首尾自动闭合
+type Polygon []Point
+
+// Edges 返回所有边
+func (pg Polygon) Edges() []Segment {
+	n := len(pg)
+	if n < 2 {
+		return nil
+	}
+	edges := make([]Segment, n)
+	for i := range pg {
+		edges[i] = Segment{pg[i], pg[(i+1)%n]}
+	}
+	return edges
+}
+
+// SignedArea 鞋带公式计算的有向面积，逆时针为正
+func (pg Polygon) SignedArea() float64 {
+	sum := 0.0
+	for i, p := range pg {
+		q := pg[(i+1)%len(pg)]
+		sum += p.X*q.Y - q.X*p.Y
+	}
+	return sum / 2
+}
+
+// Area 面积
+func (pg Polygon) Area() float64 {
+	return math.Abs(pg.SignedArea())
+}
+
+// Perimeter 周长
+func (pg Polygon) Perimeter() float64 {
+	sum := 0.0
+	for _, e := range pg.Edges() {
+		sum += e.Len()
+	}
+	return sum
+}
+
+// Bounds 包围盒，空多边形返回零值
+func (pg Polygon) Bounds() Rect {
+	if len(pg) == 0 {
+		return Rect{}
+	}
+	r := Rect{Min: pg[0], Max: pg[0]}
+	for _, p := range pg[1:] {
+		r = r.Union(Rect{Min: p, Max: p})
+	}
+	return r
+}
+
+// Centroid 质心，退化多边形返回顶点平均值
+func (pg Polygon) Centroid() Point {
+	a := pg.SignedArea()
+	if math.Abs(a) <= Epsilon {
+		var c Point
+		for _, p := range pg {
+			c.X += p.X / float64(len(pg))
+			c.Y += p.Y / float64(len(pg))
+		}
+		return c
+	}
+	var cx, cy float64
+	for i, p := range pg {
+		q := pg[(i+1)%len(pg)]
+		cross := p.X*q.Y - q.X*p.Y
+		cx += (p.X + q.X) * cross
+		cy += (p.Y + q.Y) * cross
+	}
+	return Point{cx / (6 * a), cy / (6 * a)}
+}
+
+// Contains 点是否在多边形内（含边界），使用射线法
+func (pg Polygon) Contains(p Point) bool {
+	inside := false
+	for _, e := range pg.Edges() {
+		if e.Distance(p) <= Epsilon {
+			return true
+		}
+		a, b := e.A, e.B
+		if (a.Y > p.Y) != (b.Y > p.Y) {
+			x := a.X + (p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if p.X < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// IsConvex 是否为凸多边形，共线的顶点不影响结果，自交的多边形不是凸的
+func (pg Polygon) IsConvex() bool {
+	n := len(pg)
+	if n < 3 {
+		return false
+	}
+	sign := 0
+	turning := 0.0
+	for i := range pg {
+		d1 := pg[(i+1)%n].Sub(pg[i])
+		d2 := pg[(i+2)%n].Sub(pg[(i+1)%n])
+		cross := d1.Cross(d2)
+		if math.Abs(cross) > Epsilon {
+			s := 1
+			if cross < 0 {
+				s = -1
+			}
+			if sign != 0 && s != sign {
+				return false
+			}
+			sign = s
+		}
+		turning += math.Atan2(cross, d1.Dot(d2))
+	}
+	// 凸多边形总转角恰好为一周，星形等自交多边形会转多周
+	return sign != 0 && math.Abs(math.Abs(turning)-2*math.Pi) < 1e-6
+}
+
+// Polygon 返回自身
+func (pg Polygon) Polygon() Polygon {
+	return pg
+}
+
+// BoundingBox 多个形状的公共包围盒，没有形状时返回零值
+func BoundingBox(shapes ...Shape) Rect {
+	if len(shapes) == 0 {
+		return Rect{}
+	}
+	r := shapes[0].Bounds()
+	for _, s := range shapes[1:] {
+		r = r.Union(s.Bounds())
+	}
+	return r
+}
+
+// Intersects 两个形状是否相交（含边界接触和包含）
+//
+// 圆和实现了 Polygonal 的形状精确计算，其他形状退化为包围盒测试。
+// *Circle、*Rect 和 *Polygon 与对应的值类型同样处理。
+func Intersects(a, b Shape) bool {
+	a, b = valueOf(a), valueOf(b)
+	if !a.Bounds().Overlaps(b.Bounds()) {
+		return false
+	}
+
+	ca, aCircle := a.(Circle)
+	cb, bCircle := b.(Circle)
+	switch {
+	case aCircle && bCircle:
+		return ca.Center.Distance(cb.Center) <= ca.Radius+cb.Radius+Epsilon
+	case aCircle:
+		if pb, ok := b.(Polygonal); ok {
+			return circlePolygon(ca, pb.Polygon())
+		}
+	case bCircle:
+		if pa, ok := a.(Polygonal); ok {
+			return circlePolygon(cb, pa.Polygon())
+		}
+	default:
+		pa, okA := a.(Polygonal)
+		pb, okB := b.(Polygonal)
+		if okA && okB {
+			return polygonPolygon(pa.Polygon(), pb.Polygon())
+		}
+	}
+	// 无法精确计算时包围盒重叠即视为相交
+	return true
+}
+
+// valueOf 把指向本包形状的非空指针换成值，使类型判断不必同时列出指针类型
+func valueOf(s Shape) Shape {
+	switch v := s.(type) {
+	case *Circle:
+		if v != nil {
+			return *v
+		}
+	case *Rect:
+		if v != nil {
+			return *v
+		}
+	case *Polygon:
+		if v != nil {
+			return *v
+		}
+	}
+	return s
+}
+
+// circlePolygon 圆与多边形相交：圆心在多边形内或某条边离圆心不超过半径
+func circlePolygon(c Circle, pg Polygon) bool {
+	if pg.Contains(c.Center) {
+		return true
+	}
+	for _, e := range pg.Edges() {
+		if e.Distance(c.Center) <= c.Radius+Epsilon {
+			return true
+		}
+	}
+	return false
+}
+
+// polygonPolygon 多边形相交：有边相交，或一个完全包含另一个
+func polygonPolygon(a, b Polygon) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	for _, ea := range a.Edges() {
+		for _, eb := range b.Edges() {
+			if ea.Intersects(eb) {
+				return true
+			}
+		}
+	}
+	return a.Contains(b[0]) || b.Contains(a[0])
+}
@@ -0,0 +1,200 @@
+package geometry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrSingular 变换不可逆
+	ErrSingular = errors.New("geometry: 变换不可逆")
+	// ErrNotTransformable 形状不支持变换
+	ErrNotTransformable = errors.New("geometry: 形状不支持变换")
+)
+
+// CircleSegments 圆在变换后失去圆形时用多少条边的多边形近似
+const CircleSegments = 64
+
+// Transform 二维仿射变换，对应矩阵
+//
+//	| A C E |
+//	| B D F |
+//	| 0 0 1 |
+//
+// 与 SVG 的 matrix(a b c d e f) 顺序一致。零值不是恒等变换，请使用 Identity。
+type Transform struct {
+	A, B, C, D, E, F float64
+}
+
+// Identity 恒等变换
+func Identity() Transform {
+	return Transform{A: 1, D: 1}
+}
+
+// Translate 平移
+func Translate(dx, dy float64) Transform {
+	return Transform{A: 1, D: 1, E: dx, F: dy}
+}
+
+// Rotate 绕原点逆时针旋转 theta 弧度
+func Rotate(theta float64) Transform {
+	sin, cos := math.Sincos(theta)
+	return Transform{A: cos, B: sin, C: -sin, D: cos}
+}
+
+// RotateAbout 绕点 p 旋转
+func RotateAbout(theta float64, p Point) Transform {
+	return Translate(-p.X, -p.Y).Then(Rotate(theta)).Then(Translate(p.X, p.Y))
+}
+
+// Scale 以原点为中心缩放
+func Scale(sx, sy float64) Transform {
+	return Transform{A: sx, D: sy}
+}
+
+// ScaleAbout 以点 p 为中心缩放
+func ScaleAbout(sx, sy float64, p Point) Transform {
+	return Translate(-p.X, -p.Y).Then(Scale(sx, sy)).Then(Translate(p.X, p.Y))
+}
+
+// Shear 错切：x' = x + kx*y，y' = y + ky*x
+func Shear(kx, ky float64) Transform {
+	return Transform{A: 1, B: ky, C: kx, D: 1}
+}
+
+// Compose 依次应用多个变换，Compose(a, b) 等价于先 a 后 b
+func Compose(ts ...Transform) Transform {
+	result := Identity()
+	for _, t := range ts {
+		result = result.Then(t)
+	}
+	return result
+}
+
+// Then 先应用 t 再应用 u
+func (t Transform) Then(u Transform) Transform {
+	return Transform{
+		A: u.A*t.A + u.C*t.B,
+		B: u.B*t.A + u.D*t.B,
+		C: u.A*t.C + u.C*t.D,
+		D: u.B*t.C + u.D*t.D,
+		E: u.A*t.E + u.C*t.F + u.E,
+		F: u.B*t.E + u.D*t.F + u.F,
+	}
+}
+
+// Det 线性部分的行列式，即面积缩放比例（负数表示镜像）
+func (t Transform) Det() float64 {
+	return t.A*t.D - t.B*t.C
+}
+
+// Inverse 逆变换
+func (t Transform) Inverse() (Transform, error) {
+	det := t.Det()
+	if math.Abs(det) <= Epsilon {
+		return Transform{}, ErrSingular
+	}
+	return Transform{
+		A: t.D / det,
+		B: -t.B / det,
+		C: -t.C / det,
+		D: t.A / det,
+		E: (t.C*t.F - t.D*t.E) / det,
+		F: (t.B*t.E - t.A*t.F) / det,
+	}, nil
+}
+
+// Eq 在容差范围内比较两个变换
+func (t Transform) Eq(u Transform) bool {
+	const tol = 1e-9
+	return math.Abs(t.A-u.A) <= tol && math.Abs(t.B-u.B) <= tol &&
+		math.Abs(t.C-u.C) <= tol && math.Abs(t.D-u.D) <= tol &&
+		math.Abs(t.E-u.E) <= tol && math.Abs(t.F-u.F) <= tol
+}
+
+// Apply 变换点
+func (t Transform) Apply(p Point) Point {
+	return Point{t.A*p.X + t.C*p.Y + t.E, t.B*p.X + t.D*p.Y + t.F}
+}
+
+// ApplyVector 变换向量（不受平移影响）
+func (t Transform) ApplyVector(v Vector) Vector {
+	return Vector{t.A*v.X + t.C*v.Y, t.B*v.X + t.D*v.Y}
+}
+
+// IsSimilarity 是否只包含旋转、均匀缩放、镜像和平移，圆在这类变换下仍是圆
+func (t Transform) IsSimilarity() bool {
+	rotation := math.Abs(t.A-t.D) <= Epsilon && math.Abs(t.B+t.C) <= Epsilon
+	reflection := math.Abs(t.A+t.D) <= Epsilon && math.Abs(t.B-t.C) <= Epsilon
+	return (rotation || reflection) && math.Abs(t.Det()) > Epsilon
+}
+
+// IsAxisAligned 是否把坐标轴映射到坐标轴，轴对齐矩形在这类变换下仍是轴对齐矩形
+func (t Transform) IsAxisAligned() bool {
+	keep := math.Abs(t.B) <= Epsilon && math.Abs(t.C) <= Epsilon
+	swap := math.Abs(t.A) <= Epsilon && math.Abs(t.D) <= Epsilon
+	return keep || swap
+}
+
+// String 变换的字符串表示，格式与 SVG 的 transform 属性相同
+func (t Transform) String() string {
+	// 加 0 把 -0 规范为 0
+	return fmt.Sprintf("matrix(%g %g %g %g %g %g)", t.A+0, t.B+0, t.C+0, t.D+0, t.E+0, t.F+0)
+}
+
+// Transformable 可以自行处理变换的形状
+type Transformable interface {
+	Transform(t Transform) Shape
+}
+
+// Shape 变换形状
+//
+// 圆在相似变换下仍为圆，矩形在轴对齐变换下仍为矩形，其余情况转为多边形，
+// 圆用 CircleSegments 边的内接多边形近似。其他形状需要实现 Transformable 或 Polygonal。
+//
+// 多边形不会再变回圆或矩形，所以逐个应用变换与应用它们的组合不一定得到同一类形状：
+// 先 Scale(2, 1) 再 Scale(0.5, 1) 把圆变成多边形，而组合（恒等变换）保持为圆。
+// 此时逐步变换的结果只是组合结果的近似。
+func (t Transform) Shape(s Shape) (Shape, error) {
+	switch v := valueOf(s).(type) {
+	case Circle:
+		if t.IsSimilarity() {
+			return Circle{Center: t.Apply(v.Center), Radius: v.Radius * math.Sqrt(math.Abs(t.Det()))}, nil
+		}
+		return t.Polygon(v.Polygon(CircleSegments)), nil
+	case Rect:
+		if t.IsAxisAligned() {
+			return NewRect(t.Apply(v.Min), t.Apply(v.Max)), nil
+		}
+		return t.Polygon(v.Polygon()), nil
+	case Polygon:
+		return t.Polygon(v), nil
+	case Transformable:
+		return v.Transform(t), nil
+	case Polygonal:
+		return t.Polygon(v.Polygon()), nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrNotTransformable, s)
+	}
+}
+
+// Polygon 变换多边形的每个顶点
+func (t Transform) Polygon(pg Polygon) Polygon {
+	out := make(Polygon, len(pg))
+	for i, p := range pg {
+		out[i] = t.Apply(p)
+	}
+	return out
+}
+
+// Polygon 用 n 条边的内接正多边形近似圆，n 小于 3 时按 3 处理
+func (c Circle) Polygon(n int) Polygon {
+	n = max(n, 3)
+	pg := make(Polygon, n)
+	for i := range pg {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		pg[i] = Point{c.Center.X + c.Radius*cos, c.Center.Y + c.Radius*sin}
+	}
+	return pg
+}
@@ -0,0 +1,204 @@
+package geometry
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestTransformApply(t *testing.T) {
+	tests := []struct {
+		name string
+		t    Transform
+		in   Point
+		want Point
+	}{
+		{"identity", Identity(), Pt(3, 4), Pt(3, 4)},
+		{"translate", Translate(1, -2), Pt(3, 4), Pt(4, 2)},
+		{"rotate 90", Rotate(math.Pi / 2), Pt(1, 0), Pt(0, 1)},
+		{"rotate about", RotateAbout(math.Pi, Pt(1, 1)), Pt(2, 1), Pt(0, 1)},
+		{"scale", Scale(2, 3), Pt(1, 1), Pt(2, 3)},
+		{"scale about", ScaleAbout(2, 2, Pt(1, 1)), Pt(2, 2), Pt(3, 3)},
+		{"shear", Shear(1, 0), Pt(1, 2), Pt(3, 2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.Apply(tt.in); !got.Eq(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if v := Translate(5, 5).ApplyVector(Vec(1, 2)); v != Vec(1, 2) {
+		t.Errorf("translation should not move vectors, got %v", v)
+	}
+}
+
+func TestTransformComposeAndInverse(t *testing.T) {
+	a := Rotate(0.3)
+	b := Shear(0.5, -0.2)
+	c := Translate(3, -1)
+	d := Scale(2, 0.5)
+
+	p := Pt(1.5, -2)
+	composed := Compose(a, b, c, d)
+	step := d.Apply(c.Apply(b.Apply(a.Apply(p))))
+	if got := composed.Apply(p); !got.Eq(step) {
+		t.Errorf("composition mismatch: %v vs %v", got, step)
+	}
+	if !a.Then(b).Then(c).Eq(a.Then(b.Then(c))) {
+		t.Error("composition should be associative")
+	}
+
+	inv, err := composed.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !composed.Then(inv).Eq(Identity()) || !inv.Then(composed).Eq(Identity()) {
+		t.Errorf("inverse mismatch: %v", composed.Then(inv))
+	}
+	if _, err := Scale(1, 0).Inverse(); !errors.Is(err, ErrSingular) {
+		t.Errorf("expected ErrSingular, got %v", err)
+	}
+}
+
+func TestTransformClassification(t *testing.T) {
+	tests := []struct {
+		name                    string
+		t                       Transform
+		similarity, axisAligned bool
+	}{
+		{"identity", Identity(), true, true},
+		{"rotate 30", Rotate(math.Pi / 6), true, false},
+		{"rotate 90", Rotate(math.Pi / 2), true, true},
+		{"uniform scale", Scale(2, 2), true, true},
+		{"mirror", Scale(-1, 1), true, true},
+		{"non-uniform scale", Scale(2, 1), false, true},
+		{"shear", Shear(0.5, 0), false, false},
+		{"degenerate", Scale(0, 0), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.IsSimilarity(); got != tt.similarity {
+				t.Errorf("IsSimilarity: expected %v, got %v", tt.similarity, got)
+			}
+			if got := tt.t.IsAxisAligned(); got != tt.axisAligned {
+				t.Errorf("IsAxisAligned: expected %v, got %v", tt.axisAligned, got)
+			}
+		})
+	}
+}
+
+// square 自行处理变换的测试形状
+type square struct{ Rect }
+
+func (s square) Transform(t Transform) Shape {
+	return t.Polygon(s.Rect.Polygon())
+}
+
+func TestTransformShape(t *testing.T) {
+	circle := Circle{Center: Pt(1, 0), Radius: 2}
+	rect := NewRect(Pt(0, 0), Pt(4, 2))
+
+	tests := []struct {
+		name     string
+		t        Transform
+		shape    Shape
+		wantType string
+		area     float64
+	}{
+		{"circle stays circle", Compose(Rotate(1), Scale(3, 3), Translate(1, 1)), circle, "Circle", 9 * circle.Area()},
+		{"circle becomes polygon under shear", Shear(1, 0), circle, "Polygon", circle.Area()},
+		{"circle becomes polygon under scale", Scale(2, 1), circle, "Polygon", 2 * circle.Area()},
+		{"rect stays rect", Compose(Scale(-2, 1), Rotate(math.Pi/2)), rect, "Rect", 16},
+		{"rect becomes polygon", Rotate(math.Pi / 4), rect, "Polygon", 8},
+		{"polygon", Shear(0, 2), Polygon{Pt(0, 0), Pt(1, 0), Pt(0, 1)}, "Polygon", 0.5},
+		{"transformable", Scale(2, 2), square{NewRect(Pt(0, 0), Pt(1, 1))}, "Polygon", 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.t.Shape(tt.shape)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var typ string
+			switch got.(type) {
+			case Circle:
+				typ = "Circle"
+			case Rect:
+				typ = "Rect"
+			case Polygon:
+				typ = "Polygon"
+			}
+			if typ != tt.wantType {
+				t.Errorf("expected %s, got %T", tt.wantType, got)
+			}
+			// 多边形近似的圆面积误差在 1% 以内
+			if math.Abs(got.Area()-tt.area) > 0.01*tt.area {
+				t.Errorf("expected area %.4f, got %.4f", tt.area, got.Area())
+			}
+		})
+	}
+}
+
+func TestTransformShapeComposes(t *testing.T) {
+	steps := []Transform{Shear(0.5, 0), Rotate(0.7), Scale(1, 3), Translate(-2, 5)}
+	for _, shape := range []Shape{Circle{Center: Pt(1, 1), Radius: 1}, NewRect(Pt(0, 0), Pt(2, 1))} {
+		stepwise := shape
+		for _, step := range steps {
+			var err error
+			if stepwise, err = step.Shape(stepwise); err != nil {
+				t.Fatal(err)
+			}
+		}
+		direct, err := Compose(steps...).Shape(shape)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		a, b := stepwise.(Polygon), direct.(Polygon)
+		if len(a) != len(b) {
+			t.Fatalf("vertex count mismatch %d vs %d", len(a), len(b))
+		}
+		for i := range a {
+			if a[i].Distance(b[i]) > 1e-9 {
+				t.Errorf("vertex %d: %v vs %v", i, a[i], b[i])
+			}
+		}
+	}
+}
+
+// TestTransformShapeStepwiseApproximates 逐步变换中途转为多边形后不会再变回圆
+func TestTransformShapeStepwiseApproximates(t *testing.T) {
+	circle := &Circle{Center: Pt(1, 1), Radius: 1}
+	stepwise, err := Scale(2, 1).Shape(circle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stepwise, err = Scale(0.5, 1).Shape(stepwise); err != nil {
+		t.Fatal(err)
+	}
+	direct, err := Compose(Scale(2, 1), Scale(0.5, 1)).Shape(circle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pg, ok := stepwise.(Polygon)
+	if !ok || len(pg) != CircleSegments {
+		t.Fatalf("expected %d-gon, got %T", CircleSegments, stepwise)
+	}
+	if direct != *circle {
+		t.Errorf("expected composite to keep %v, got %v", *circle, direct)
+	}
+	for i, p := range pg {
+		if d := p.Distance(circle.Center); math.Abs(d-circle.Radius) > 1e-9 {
+			t.Errorf("vertex %d: distance %g from center", i, d)
+		}
+	}
+}
+
+func TestTransformShapeUnsupported(t *testing.T) {
+	if _, err := Identity().Shape(blob{}); !errors.Is(err, ErrNotTransformable) {
+		t.Errorf("expected ErrNotTransformable, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package matrix
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Triplet 稀疏矩阵中的一个非零元素 (行, 列, 值)
+type Triplet struct {
+	Row, Col int
+	Value    float64
+}
+
+// CSR 压缩稀疏行格式的矩阵
+//
+// 第 i 行的非零元素为 values[indptr[i]:indptr[i+1]]，
+// 对应的列号为 indices[indptr[i]:indptr[i+1]]，列号在行内升序。
+type CSR struct {
+	rows, cols int
+	indptr     []int
+	indices    []int
+	values     []float64
+}
+
+// NewCSR 由三元组创建稀疏矩阵，同一位置的多个值会相加，结果为零的元素被丢弃
+func NewCSR(r, c int, entries []Triplet) (*CSR, error) {
+	if r < 0 || c < 0 {
+		return nil, fmt.Errorf("%w: 非法维度 %dx%d", ErrShape, r, c)
+	}
+	sorted := append([]Triplet(nil), entries...)
+	for _, e := range sorted {
+		if e.Row < 0 || e.Row >= r || e.Col < 0 || e.Col >= c {
+			return nil, fmt.Errorf("%w: 元素 (%d, %d) 超出 %dx%d", ErrShape, e.Row, e.Col, r, c)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Row != sorted[j].Row {
+			return sorted[i].Row < sorted[j].Row
+		}
+		return sorted[i].Col < sorted[j].Col
+	})
+
+	s := &CSR{rows: r, cols: c, indptr: make([]int, r+1)}
+	for i := 0; i < len(sorted); {
+		e := sorted[i]
+		sum := 0.0
+		for ; i < len(sorted) && sorted[i].Row == e.Row && sorted[i].Col == e.Col; i++ {
+			sum += sorted[i].Value
+		}
+		if sum == 0 {
+			continue
+		}
+		s.indices = append(s.indices, e.Col)
+		s.values = append(s.values, sum)
+		s.indptr[e.Row+1]++
+	}
+	for i := 0; i < r; i++ {
+		s.indptr[i+1] += s.indptr[i]
+	}
+	return s, nil
+}
+
+// FromDense 将稠密矩阵转换为稀疏矩阵，绝对值不超过 tol 的元素视为零
+func FromDense(m *Matrix, tol float64) *CSR {
+	s := &CSR{rows: m.rows, cols: m.cols, indptr: make([]int, m.rows+1)}
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			v := m.data[i*m.cols+j]
+			if v > tol || v < -tol {
+				s.indices = append(s.indices, j)
+				s.values = append(s.values, v)
+			}
+		}
+		s.indptr[i+1] = len(s.values)
+	}
+	return s
+}
+
+// Rows 行数
+func (s *CSR) Rows() int { return s.rows }
+
+// Cols 列数
+func (s *CSR) Cols() int { return s.cols }
+
+// NNZ 非零元素个数
+func (s *CSR) NNZ() int { return len(s.values) }
+
+// At 返回第 i 行第 j 列的元素，行内二分查找
+func (s *CSR) At(i, j int) float64 {
+	if i < 0 || i >= s.rows || j < 0 || j >= s.cols {
+		panic(fmt.Sprintf("matrix: 下标 (%d, %d) 越界，矩阵为 %dx%d", i, j, s.rows, s.cols))
+	}
+	lo, hi := s.indptr[i], s.indptr[i+1]
+	k := lo + sort.SearchInts(s.indices[lo:hi], j)
+	if k < hi && s.indices[k] == j {
+		return s.values[k]
+	}
+	return 0
+}
+
+// Dense 转换为稠密矩阵
+func (s *CSR) Dense() *Matrix {
+	m := New(s.rows, s.cols)
+	for i := 0; i < s.rows; i++ {
+		for k := s.indptr[i]; k < s.indptr[i+1]; k++ {
+			m.data[i*s.cols+s.indices[k]] = s.values[k]
+		}
+	}
+	return m
+}
+
+// T 转置，结果仍为 CSR
+func (s *CSR) T() *CSR {
+	t := &CSR{
+		rows:    s.cols,
+		cols:    s.rows,
+		indptr:  make([]int, s.cols+1),
+		indices: make([]int, len(s.indices)),
+		values:  make([]float64, len(s.values)),
+	}
+	for _, j := range s.indices {
+		t.indptr[j+1]++
+	}
+	for j := 0; j < s.cols; j++ {
+		t.indptr[j+1] += t.indptr[j]
+	}
+
+	next := append([]int(nil), t.indptr[:s.cols]...)
+	for i := 0; i < s.rows; i++ {
+		for k := s.indptr[i]; k < s.indptr[i+1]; k++ {
+			j := s.indices[k]
+			t.indices[next[j]] = i
+			t.values[next[j]] = s.values[k]
+			next[j]++
+		}
+	}
+	return t
+}
+
+// MulVec 稀疏矩阵乘以列向量
+func (s *CSR) MulVec(x []float64) ([]float64, error) {
+	if len(x) != s.cols {
+		return nil, fmt.Errorf("%w: %dx%d * 长度%d的向量", ErrShape, s.rows, s.cols, len(x))
+	}
+	out := make([]float64, s.rows)
+	for i := 0; i < s.rows; i++ {
+		sum := 0.0
+		for k := s.indptr[i]; k < s.indptr[i+1]; k++ {
+			sum += s.values[k] * x[s.indices[k]]
+		}
+		out[i] = sum
+	}
+	return out, nil
+}
+
+// MulDense 稀疏矩阵乘以稠密矩阵，结果为稠密矩阵
+func (s *CSR) MulDense(b *Matrix) (*Matrix, error) {
+	if s.cols != b.rows {
+		return nil, fmt.Errorf("%w: %dx%d * %dx%d", ErrShape, s.rows, s.cols, b.rows, b.cols)
+	}
+	out := New(s.rows, b.cols)
+	for i := 0; i < s.rows; i++ {
+		outRow := out.data[i*b.cols : (i+1)*b.cols]
+		for k := s.indptr[i]; k < s.indptr[i+1]; k++ {
+			v := s.values[k]
+			bRow := b.data[s.indices[k]*b.cols : (s.indices[k]+1)*b.cols]
+			for j, bv := range bRow {
+				outRow[j] += v * bv
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,204 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// singularTol 奇异判定的相对容差：主元绝对值不超过 n·ε·‖A‖∞ 时认为矩阵奇异，
+// 与矩阵整体的数量级无关
+const singularTol = 0x1p-52
+
+// normInf 无穷范数，即各行元素绝对值之和的最大值
+func (m *Matrix) normInf() float64 {
+	norm := 0.0
+	for i := 0; i < m.rows; i++ {
+		sum := 0.0
+		for _, v := range m.data[i*m.cols : (i+1)*m.cols] {
+			sum += math.Abs(v)
+		}
+		norm = max(norm, sum)
+	}
+	return norm
+}
+
+// LU 带部分主元选取的 LU 分解：P·A = L·U
+//
+// L 为单位下三角矩阵，U 为上三角矩阵，二者紧凑存储在同一个矩阵中。
+type LU struct {
+	lu    *Matrix
+	pivot []int // pivot[i] 表示分解后第 i 行来自原矩阵的第 pivot[i] 行
+	sign  float64
+}
+
+// LU 对方阵做 LU 分解，矩阵奇异时返回 ErrSingular
+func (m *Matrix) LU() (*LU, error) {
+	if m.rows != m.cols {
+		return nil, fmt.Errorf("%w: LU 分解需要方阵，得到 %dx%d", ErrShape, m.rows, m.cols)
+	}
+
+	n := m.rows
+	a := m.Clone()
+	pivot := make([]int, n)
+	for i := range pivot {
+		pivot[i] = i
+	}
+	sign := 1.0
+	tol := float64(n) * singularTol * m.normInf()
+
+	for k := 0; k < n; k++ {
+		// 选取第 k 列中绝对值最大的元素作为主元
+		p := k
+		maxAbs := math.Abs(a.data[k*n+k])
+		for i := k + 1; i < n; i++ {
+			if v := math.Abs(a.data[i*n+k]); v > maxAbs {
+				p, maxAbs = i, v
+			}
+		}
+		if maxAbs <= tol {
+			return nil, ErrSingular
+		}
+
+		if p != k {
+			rowK := a.data[k*n : (k+1)*n]
+			rowP := a.data[p*n : (p+1)*n]
+			for j := range rowK {
+				rowK[j], rowP[j] = rowP[j], rowK[j]
+			}
+			pivot[k], pivot[p] = pivot[p], pivot[k]
+			sign = -sign
+		}
+
+		pivotValue := a.data[k*n+k]
+		for i := k + 1; i < n; i++ {
+			factor := a.data[i*n+k] / pivotValue
+			a.data[i*n+k] = factor
+			for j := k + 1; j < n; j++ {
+				a.data[i*n+j] -= factor * a.data[k*n+j]
+			}
+		}
+	}
+
+	return &LU{lu: a, pivot: pivot, sign: sign}, nil
+}
+
+// Det 行列式
+func (f *LU) Det() float64 {
+	n := f.lu.rows
+	det := f.sign
+	for i := 0; i < n; i++ {
+		det *= f.lu.data[i*n+i]
+	}
+	return det
+}
+
+// L 返回单位下三角因子
+func (f *LU) L() *Matrix {
+	n := f.lu.rows
+	l := Identity(n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			l.data[i*n+j] = f.lu.data[i*n+j]
+		}
+	}
+	return l
+}
+
+// U 返回上三角因子
+func (f *LU) U() *Matrix {
+	n := f.lu.rows
+	u := New(n, n)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			u.data[i*n+j] = f.lu.data[i*n+j]
+		}
+	}
+	return u
+}
+
+// P 返回置换矩阵，满足 P·A = L·U
+func (f *LU) P() *Matrix {
+	n := f.lu.rows
+	p := New(n, n)
+	for i, src := range f.pivot {
+		p.data[i*n+src] = 1
+	}
+	return p
+}
+
+// Solve 求解 A·x = b
+func (f *LU) Solve(b []float64) ([]float64, error) {
+	n := f.lu.rows
+	if len(b) != n {
+		return nil, fmt.Errorf("%w: 方程组有%d个未知数，右端向量长度为%d", ErrShape, n, len(b))
+	}
+
+	// 前代：L·y = P·b
+	x := make([]float64, n)
+	for i := 0; i < n; i++ {
+		sum := b[f.pivot[i]]
+		for j := 0; j < i; j++ {
+			sum -= f.lu.data[i*n+j] * x[j]
+		}
+		x[i] = sum
+	}
+
+	// 回代：U·x = y
+	for i := n - 1; i >= 0; i-- {
+		sum := x[i]
+		for j := i + 1; j < n; j++ {
+			sum -= f.lu.data[i*n+j] * x[j]
+		}
+		x[i] = sum / f.lu.data[i*n+i]
+	}
+	return x, nil
+}
+
+// Inverse 逆矩阵，逐列求解 A·x = e_i
+func (f *LU) Inverse() *Matrix {
+	n := f.lu.rows
+	inv := New(n, n)
+	e := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := range e {
+			e[i] = 0
+		}
+		e[j] = 1
+		col, _ := f.Solve(e)
+		for i, v := range col {
+			inv.data[i*n+j] = v
+		}
+	}
+	return inv
+}
+
+// Det 行列式，奇异矩阵返回 0
+func (m *Matrix) Det() (float64, error) {
+	f, err := m.LU()
+	if errors.Is(err, ErrSingular) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return f.Det(), nil
+}
+
+// Inverse 逆矩阵
+func (m *Matrix) Inverse() (*Matrix, error) {
+	f, err := m.LU()
+	if err != nil {
+		return nil, err
+	}
+	return f.Inverse(), nil
+}
+
+// Solve 求解线性方程组 A·x = b
+func (m *Matrix) Solve(b []float64) ([]float64, error) {
+	f, err := m.LU()
+	if err != nil {
+		return nil, err
+	}
+	return f.Solve(b)
+}
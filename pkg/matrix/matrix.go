@@ -0,0 +1,229 @@
+// Package matrix 稠密 float64 矩阵与 CSR 稀疏矩阵
+//
+// 稠密矩阵按行主序存储在一维切片中。数值比较请使用 Equal 并给出容差。
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	// ErrShape 矩阵维度不匹配
+	ErrShape = errors.New("matrix: 维度不匹配")
+	// ErrSingular 矩阵奇异（不可逆）
+	ErrSingular = errors.New("matrix: 矩阵奇异")
+)
+
+// blockSize 分块乘法的块大小，32x32 个 float64 约 8KB，三块可放入 L1 缓存
+const blockSize = 32
+
+// Matrix 稠密矩阵
+type Matrix struct {
+	rows, cols int
+	data       []float64
+}
+
+// New 创建 r 行 c 列的零矩阵
+func New(r, c int) *Matrix {
+	if r < 0 || c < 0 {
+		panic(fmt.Sprintf("matrix: 非法维度 %dx%d", r, c))
+	}
+	return &Matrix{rows: r, cols: c, data: make([]float64, r*c)}
+}
+
+// FromRows 由二维切片创建矩阵，每行长度必须相同
+func FromRows(rows [][]float64) (*Matrix, error) {
+	if len(rows) == 0 {
+		return New(0, 0), nil
+	}
+	m := New(len(rows), len(rows[0]))
+	for i, row := range rows {
+		if len(row) != m.cols {
+			return nil, fmt.Errorf("%w: 第%d行有%d列，期望%d列", ErrShape, i, len(row), m.cols)
+		}
+		copy(m.data[i*m.cols:], row)
+	}
+	return m, nil
+}
+
+// Identity 创建 n 阶单位矩阵
+func Identity(n int) *Matrix {
+	m := New(n, n)
+	for i := 0; i < n; i++ {
+		m.data[i*n+i] = 1
+	}
+	return m
+}
+
+// Rows 行数
+func (m *Matrix) Rows() int { return m.rows }
+
+// Cols 列数
+func (m *Matrix) Cols() int { return m.cols }
+
+// At 返回第 i 行第 j 列的元素
+func (m *Matrix) At(i, j int) float64 {
+	m.check(i, j)
+	return m.data[i*m.cols+j]
+}
+
+// Set 设置第 i 行第 j 列的元素
+func (m *Matrix) Set(i, j int, v float64) {
+	m.check(i, j)
+	m.data[i*m.cols+j] = v
+}
+
+// check 检查下标越界
+func (m *Matrix) check(i, j int) {
+	if i < 0 || i >= m.rows || j < 0 || j >= m.cols {
+		panic(fmt.Sprintf("matrix: 下标 (%d, %d) 越界，矩阵为 %dx%d", i, j, m.rows, m.cols))
+	}
+}
+
+// Row 返回第 i 行的副本
+func (m *Matrix) Row(i int) []float64 {
+	m.check(i, 0)
+	return append([]float64(nil), m.data[i*m.cols:(i+1)*m.cols]...)
+}
+
+// Clone 深拷贝
+func (m *Matrix) Clone() *Matrix {
+	return &Matrix{rows: m.rows, cols: m.cols, data: append([]float64(nil), m.data...)}
+}
+
+// Equal 判断两个矩阵维度相同且每个元素之差不超过 tol
+func (m *Matrix) Equal(b *Matrix, tol float64) bool {
+	if m.rows != b.rows || m.cols != b.cols {
+		return false
+	}
+	for i := range m.data {
+		if math.Abs(m.data[i]-b.data[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// Add 矩阵加法
+func (m *Matrix) Add(b *Matrix) (*Matrix, error) {
+	if m.rows != b.rows || m.cols != b.cols {
+		return nil, fmt.Errorf("%w: %dx%d + %dx%d", ErrShape, m.rows, m.cols, b.rows, b.cols)
+	}
+	out := New(m.rows, m.cols)
+	for i := range m.data {
+		out.data[i] = m.data[i] + b.data[i]
+	}
+	return out, nil
+}
+
+// Scale 返回每个元素乘以 k 的新矩阵
+func (m *Matrix) Scale(k float64) *Matrix {
+	out := m.Clone()
+	for i := range out.data {
+		out.data[i] *= k
+	}
+	return out
+}
+
+// T 转置
+func (m *Matrix) T() *Matrix {
+	out := New(m.cols, m.rows)
+	// 分块转置，减少写入时的缓存缺失
+	for ii := 0; ii < m.rows; ii += blockSize {
+		for jj := 0; jj < m.cols; jj += blockSize {
+			for i := ii; i < min(ii+blockSize, m.rows); i++ {
+				for j := jj; j < min(jj+blockSize, m.cols); j++ {
+					out.data[j*m.rows+i] = m.data[i*m.cols+j]
+				}
+			}
+		}
+	}
+	return out
+}
+
+// Mul 矩阵乘法（分块实现）
+func (m *Matrix) Mul(b *Matrix) (*Matrix, error) {
+	if m.cols != b.rows {
+		return nil, fmt.Errorf("%w: %dx%d * %dx%d", ErrShape, m.rows, m.cols, b.rows, b.cols)
+	}
+	return mulBlocked(m, b), nil
+}
+
+// MulVec 矩阵乘以列向量
+func (m *Matrix) MulVec(x []float64) ([]float64, error) {
+	if len(x) != m.cols {
+		return nil, fmt.Errorf("%w: %dx%d * 长度%d的向量", ErrShape, m.rows, m.cols, len(x))
+	}
+	out := make([]float64, m.rows)
+	for i := 0; i < m.rows; i++ {
+		row := m.data[i*m.cols : (i+1)*m.cols]
+		sum := 0.0
+		for j, v := range row {
+			sum += v * x[j]
+		}
+		out[i] = sum
+	}
+	return out, nil
+}
+
+// mulNaive 三重循环的朴素乘法（i-j-k 顺序），作为基准参照
+func mulNaive(a, b *Matrix) *Matrix {
+	out := New(a.rows, b.cols)
+	for i := 0; i < a.rows; i++ {
+		for j := 0; j < b.cols; j++ {
+			sum := 0.0
+			for k := 0; k < a.cols; k++ {
+				sum += a.data[i*a.cols+k] * b.data[k*b.cols+j]
+			}
+			out.data[i*out.cols+j] = sum
+		}
+	}
+	return out
+}
+
+// mulBlocked 分块乘法，块内使用 i-k-j 顺序使 b 和 out 按行连续访问
+func mulBlocked(a, b *Matrix) *Matrix {
+	out := New(a.rows, b.cols)
+	n, m, p := a.rows, a.cols, b.cols
+
+	for ii := 0; ii < n; ii += blockSize {
+		iEnd := min(ii+blockSize, n)
+		for kk := 0; kk < m; kk += blockSize {
+			kEnd := min(kk+blockSize, m)
+			for jj := 0; jj < p; jj += blockSize {
+				jEnd := min(jj+blockSize, p)
+				for i := ii; i < iEnd; i++ {
+					outRow := out.data[i*p+jj : i*p+jEnd]
+					for k := kk; k < kEnd; k++ {
+						// 不跳过 aik == 0：0·Inf 和 0·NaN 为 NaN，跳过会与朴素乘法结果不同
+						aik := a.data[i*m+k]
+						bRow := b.data[k*p+jj : k*p+jEnd]
+						for j, bkj := range bRow {
+							outRow[j] += aik * bkj
+						}
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// String 按行格式化输出
+func (m *Matrix) String() string {
+	var sb strings.Builder
+	for i := 0; i < m.rows; i++ {
+		sb.WriteString("[")
+		for j := 0; j < m.cols; j++ {
+			if j > 0 {
+				sb.WriteString(" ")
+			}
+			fmt.Fprintf(&sb, "%8.3f", m.data[i*m.cols+j])
+		}
+		sb.WriteString("]\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,319 @@
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+const tol = 1e-9
+
+func mustRows(t testing.TB, rows [][]float64) *Matrix {
+	t.Helper()
+	m, err := FromRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func randomMatrix(rng *rand.Rand, r, c int) *Matrix {
+	m := New(r, c)
+	for i := range m.data {
+		m.data[i] = rng.Float64()*2 - 1
+	}
+	return m
+}
+
+func TestFromRowsShape(t *testing.T) {
+	if _, err := FromRows([][]float64{{1, 2}, {3}}); !errors.Is(err, ErrShape) {
+		t.Errorf("expected ErrShape, got %v", err)
+	}
+}
+
+func TestMulAndTranspose(t *testing.T) {
+	a := mustRows(t, [][]float64{{1, 2, 3}, {4, 5, 6}})
+	b := mustRows(t, [][]float64{{7, 8}, {9, 10}, {11, 12}})
+	want := mustRows(t, [][]float64{{58, 64}, {139, 154}})
+
+	got, err := a.Mul(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want, tol) {
+		t.Errorf("expected\n%s got\n%s", want, got)
+	}
+
+	if !a.T().Equal(mustRows(t, [][]float64{{1, 4}, {2, 5}, {3, 6}}), 0) {
+		t.Errorf("unexpected transpose\n%s", a.T())
+	}
+
+	if _, err := a.Mul(a); !errors.Is(err, ErrShape) {
+		t.Errorf("expected ErrShape, got %v", err)
+	}
+}
+
+// TestBlockedMatchesNaive 分块乘法在非块大小整数倍的维度上与朴素乘法一致
+func TestBlockedMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, dims := range [][3]int{{1, 1, 1}, {5, 7, 3}, {33, 31, 65}, {70, 40, 50}} {
+		a := randomMatrix(rng, dims[0], dims[1])
+		b := randomMatrix(rng, dims[1], dims[2])
+		if !mulBlocked(a, b).Equal(mulNaive(a, b), 1e-10) {
+			t.Errorf("blocked and naive differ for %v", dims)
+		}
+		if !a.T().T().Equal(a, 0) {
+			t.Errorf("double transpose differs for %v", dims)
+		}
+	}
+
+	// 0 乘以 Inf 或 NaN 得到 NaN，两种实现都不能跳过零元素
+	a := mustRows(t, [][]float64{{0, 1}, {1, 0}})
+	b := mustRows(t, [][]float64{{math.Inf(1), 2}, {3, math.NaN()}})
+	blocked, naive := mulBlocked(a, b), mulNaive(a, b)
+	for i := range 2 {
+		for j := range 2 {
+			if x, y := blocked.At(i, j), naive.At(i, j); x != y && !(math.IsNaN(x) && math.IsNaN(y)) {
+				t.Errorf("(%d,%d): blocked %g, naive %g", i, j, x, y)
+			}
+		}
+	}
+	if !math.IsNaN(blocked.At(0, 0)) {
+		t.Errorf("0·Inf should give NaN, got %g", blocked.At(0, 0))
+	}
+}
+
+func TestLU(t *testing.T) {
+	a := mustRows(t, [][]float64{
+		{0, 2, 1},
+		{1, 1, 1},
+		{2, 1, 0},
+	})
+	f, err := a.LU()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pa, _ := f.P().Mul(a)
+	lu, _ := f.L().Mul(f.U())
+	if !pa.Equal(lu, tol) {
+		t.Errorf("P·A != L·U\n%s\n%s", pa, lu)
+	}
+	if det := f.Det(); math.Abs(det-3) > tol {
+		t.Errorf("expected det 3, got %v", det)
+	}
+}
+
+func TestDetInverseSolve(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]float64
+		det  float64
+	}{
+		{"2x2", [][]float64{{4, 7}, {2, 6}}, 10},
+		{"needs pivot", [][]float64{{0, 1}, {1, 0}}, -1},
+		{"3x3", [][]float64{{6, 1, 1}, {4, -2, 5}, {2, 8, 7}}, -306},
+		{"identity", [][]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := mustRows(t, tt.rows)
+			det, err := a.Det()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(det-tt.det) > 1e-9*math.Max(1, math.Abs(tt.det)) {
+				t.Errorf("expected det %v, got %v", tt.det, det)
+			}
+
+			inv, err := a.Inverse()
+			if err != nil {
+				t.Fatal(err)
+			}
+			prod, _ := a.Mul(inv)
+			if !prod.Equal(Identity(a.rows), tol) {
+				t.Errorf("A·A⁻¹ != I\n%s", prod)
+			}
+
+			x := make([]float64, a.rows)
+			for i := range x {
+				x[i] = float64(i + 1)
+			}
+			b, _ := a.MulVec(x)
+			got, err := a.Solve(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range x {
+				if math.Abs(got[i]-x[i]) > tol {
+					t.Errorf("expected x=%v, got %v", x, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestRandomSolveResidual(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for _, n := range []int{10, 50, 100} {
+		a := randomMatrix(rng, n, n)
+		b := make([]float64, n)
+		for i := range b {
+			b[i] = rng.Float64()
+		}
+
+		x, err := a.Solve(b)
+		if err != nil {
+			t.Fatalf("n=%d: %v", n, err)
+		}
+		ax, _ := a.MulVec(x)
+		for i := range b {
+			if math.Abs(ax[i]-b[i]) > 1e-8 {
+				t.Fatalf("n=%d: residual %g at %d", n, ax[i]-b[i], i)
+			}
+		}
+	}
+}
+
+func TestSingular(t *testing.T) {
+	a := mustRows(t, [][]float64{{1, 2}, {2, 4}})
+	if det, err := a.Det(); err != nil || det != 0 {
+		t.Errorf("expected det 0, got %v, %v", det, err)
+	}
+	if _, err := a.Inverse(); !errors.Is(err, ErrSingular) {
+		t.Errorf("expected ErrSingular, got %v", err)
+	}
+	if _, err := a.Solve([]float64{1, 2}); !errors.Is(err, ErrSingular) {
+		t.Errorf("expected ErrSingular, got %v", err)
+	}
+	if _, err := a.Scale(1e20).LU(); !errors.Is(err, ErrSingular) {
+		t.Errorf("scaled up: expected ErrSingular, got %v", err)
+	}
+	if _, err := New(2, 2).LU(); !errors.Is(err, ErrSingular) {
+		t.Errorf("zero matrix: expected ErrSingular, got %v", err)
+	}
+	// 奇异判定与数量级无关，很小但良态的矩阵可以求逆
+	if _, err := Identity(3).Scale(1e-14).Inverse(); err != nil {
+		t.Errorf("scaled identity: %v", err)
+	}
+	if _, err := New(2, 3).LU(); !errors.Is(err, ErrShape) {
+		t.Errorf("expected ErrShape for non-square, got %v", err)
+	}
+}
+
+func TestCSR(t *testing.T) {
+	s, err := NewCSR(3, 4, []Triplet{
+		{2, 3, 5},
+		{0, 1, 2},
+		{1, 0, 1},
+		{0, 1, 3}, // 与 (0,1) 累加
+		{1, 2, 4},
+		{1, 2, -4}, // 抵消为零
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dense := mustRows(t, [][]float64{
+		{0, 5, 0, 0},
+		{1, 0, 0, 0},
+		{0, 0, 0, 5},
+	})
+	if s.NNZ() != 3 {
+		t.Errorf("expected 3 non-zeros, got %d", s.NNZ())
+	}
+	if !s.Dense().Equal(dense, 0) {
+		t.Errorf("unexpected dense form\n%s", s.Dense())
+	}
+	if s.At(0, 1) != 5 || s.At(1, 2) != 0 {
+		t.Errorf("unexpected At values")
+	}
+	if !s.T().Dense().Equal(dense.T(), 0) {
+		t.Errorf("unexpected transpose\n%s", s.T().Dense())
+	}
+
+	if _, err := NewCSR(2, 2, []Triplet{{2, 0, 1}}); !errors.Is(err, ErrShape) {
+		t.Errorf("expected ErrShape, got %v", err)
+	}
+	for _, dims := range [][2]int{{-1, 2}, {2, -1}} {
+		if _, err := NewCSR(dims[0], dims[1], nil); !errors.Is(err, ErrShape) {
+			t.Errorf("%dx%d: expected ErrShape, got %v", dims[0], dims[1], err)
+		}
+	}
+}
+
+func TestCSRMatchesDense(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	a := randomMatrix(rng, 40, 30)
+	for i := range a.data {
+		if rng.Float64() < 0.8 {
+			a.data[i] = 0
+		}
+	}
+	s := FromDense(a, 0)
+
+	x := make([]float64, a.cols)
+	for i := range x {
+		x[i] = rng.Float64()
+	}
+	want, _ := a.MulVec(x)
+	got, err := s.MulVec(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if math.Abs(want[i]-got[i]) > tol {
+			t.Fatalf("MulVec differs at %d: %v vs %v", i, want[i], got[i])
+		}
+	}
+
+	b := randomMatrix(rng, 30, 20)
+	wantM, _ := a.Mul(b)
+	gotM, err := s.MulDense(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gotM.Equal(wantM, tol) {
+		t.Error("MulDense differs from dense multiplication")
+	}
+}
+
+// ===== 基准测试 =====
+
+func BenchmarkMul(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{64, 256, 512} {
+		x := randomMatrix(rng, n, n)
+		y := randomMatrix(rng, n, n)
+
+		b.Run(fmt.Sprintf("naive/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				mulNaive(x, y)
+			}
+		})
+		b.Run(fmt.Sprintf("blocked/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				mulBlocked(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkSolve(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	a := randomMatrix(rng, 200, 200)
+	rhs := make([]float64, 200)
+	for i := range rhs {
+		rhs[i] = rng.Float64()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.Solve(rhs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
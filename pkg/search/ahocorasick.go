@@ -0,0 +1,131 @@
+package search
+
+import "slices"
+
+// Match 多模式查找的一个匹配，text[Start:End] 等于第 Pattern 个模式
+type Match struct {
+	Pattern int
+	Start   int
+	End     int
+}
+
+// acNode 字典树节点
+type acNode[T Symbol] struct {
+	next map[T]int
+	fail int   // 最长的真后缀所在节点
+	out  []int // 在此结束的模式编号，包括经失配链可达的，按长度降序
+}
+
+// AhoCorasick Aho–Corasick 多模式查找器
+//
+// 把所有模式建成字典树并用 BFS 计算失配链接，扫描一遍文本即可找出所有模式的
+// 所有出现位置，耗时与模式个数无关。
+type AhoCorasick[T Symbol] struct {
+	patterns [][]T
+	nodes    []acNode[T]
+}
+
+// NewAhoCorasick 预处理一组模式，模式可以重复或为空
+func NewAhoCorasick[T Symbol](patterns ...[]T) *AhoCorasick[T] {
+	ac := &AhoCorasick[T]{
+		patterns: make([][]T, len(patterns)),
+		nodes:    []acNode[T]{{}},
+	}
+	for id, p := range patterns {
+		ac.patterns[id] = slices.Clone(p)
+		state := 0
+		for _, c := range p {
+			next, ok := ac.nodes[state].next[c]
+			if !ok {
+				next = len(ac.nodes)
+				ac.nodes = append(ac.nodes, acNode[T]{})
+				if ac.nodes[state].next == nil {
+					ac.nodes[state].next = make(map[T]int)
+				}
+				ac.nodes[state].next[c] = next
+			}
+			state = next
+		}
+		ac.nodes[state].out = append(ac.nodes[state].out, id)
+	}
+	ac.link()
+	return ac
+}
+
+// link 按层计算失配链接，并把失配节点的输出并入当前节点
+func (ac *AhoCorasick[T]) link() {
+	queue := make([]int, 0, len(ac.nodes))
+	for _, child := range ac.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		node := &ac.nodes[state]
+		node.out = append(node.out, ac.nodes[node.fail].out...)
+		for c, child := range node.next {
+			ac.nodes[child].fail = ac.step(node.fail, c)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// step 从 state 读入 c 后的状态
+func (ac *AhoCorasick[T]) step(state int, c T) int {
+	for {
+		if next, ok := ac.nodes[state].next[c]; ok {
+			return next
+		}
+		if state == 0 {
+			return 0
+		}
+		state = ac.nodes[state].fail
+	}
+}
+
+// Len 模式个数
+func (ac *AhoCorasick[T]) Len() int { return len(ac.patterns) }
+
+// FindAll 返回所有匹配（包括重叠的），按结束位置升序，结束位置相同时长的在前
+func (ac *AhoCorasick[T]) FindAll(text []T) []Match {
+	var out []Match
+	ac.scan(text, func(m Match) bool {
+		out = append(out, m)
+		return true
+	})
+	return out
+}
+
+// Contains text 中是否出现了任一模式
+func (ac *AhoCorasick[T]) Contains(text []T) bool {
+	found := false
+	ac.scan(text, func(Match) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// scan 依次报告匹配，yield 返回 false 时停止
+func (ac *AhoCorasick[T]) scan(text []T, yield func(Match) bool) {
+	emit := func(state, end int) bool {
+		for _, id := range ac.nodes[state].out {
+			if !yield(Match{Pattern: id, Start: end - len(ac.patterns[id]), End: end}) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// 根节点的输出只有空模式
+	if !emit(0, 0) {
+		return
+	}
+	state := 0
+	for i, c := range text {
+		state = ac.step(state, c)
+		if !emit(state, i+1) {
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package search
+
+import "slices"
+
+// Horspool Boyer–Moore–Horspool 查找器
+//
+// 从右向左比较窗口，失配时按窗口最后一个符号查坏字符表跳跃。
+// 字节查找使用 256 项的数组；rune 查找中大于 255 的符号使用映射。
+type Horspool[T Symbol] struct {
+	pattern []T
+	small   [256]int
+	large   map[T]int
+}
+
+// NewHorspool 预处理模式
+func NewHorspool[T Symbol](pattern []T) *Horspool[T] {
+	m := len(pattern)
+	h := &Horspool[T]{pattern: slices.Clone(pattern)}
+	for i := range h.small {
+		h.small[i] = m
+	}
+	// 最后一个符号不参与：它在窗口末尾时应当按更早的出现位置移动
+	for i := 0; i < m-1; i++ {
+		c := pattern[i]
+		if uint64(c) < 256 {
+			h.small[c] = m - 1 - i
+			continue
+		}
+		if h.large == nil {
+			h.large = make(map[T]int)
+		}
+		h.large[c] = m - 1 - i
+	}
+	return h
+}
+
+// shift 窗口末尾为 c 时的跳跃距离
+func (h *Horspool[T]) shift(c T) int {
+	if uint64(c) < 256 {
+		return h.small[c]
+	}
+	if s, ok := h.large[c]; ok {
+		return s
+	}
+	return len(h.pattern)
+}
+
+// Index 返回第一次出现的位置，不存在时返回 -1
+func (h *Horspool[T]) Index(text []T) int {
+	return h.next(text, 0)
+}
+
+// IndexAll 返回所有出现的位置（包括重叠的）
+func (h *Horspool[T]) IndexAll(text []T) []int {
+	if len(h.pattern) == 0 {
+		return allPositions(len(text))
+	}
+	var out []int
+	for i := h.next(text, 0); i >= 0; i = h.next(text, i+1) {
+		out = append(out, i)
+	}
+	return out
+}
+
+// next 从 from 开始查找
+func (h *Horspool[T]) next(text []T, from int) int {
+	p := h.pattern
+	m := len(p)
+	if m == 0 {
+		return from
+	}
+	last := p[m-1]
+	for i := from; i+m <= len(text); {
+		c := text[i+m-1]
+		if c == last && equal(text[i:], p[:m-1]) {
+			return i
+		}
+		i += h.shift(c)
+	}
+	return -1
+}
@@ -0,0 +1,75 @@
+package search
+
+import "slices"
+
+// KMP Knuth–Morris–Pratt 查找器
+//
+// 失配时根据前缀函数移动模式，文本指针从不回退，最坏 O(n+m)。
+type KMP[T Symbol] struct {
+	pattern []T
+	// border[i] 为 pattern[:i+1] 最长的真前缀兼后缀长度
+	border []int
+}
+
+// NewKMP 预处理模式
+func NewKMP[T Symbol](pattern []T) *KMP[T] {
+	border := make([]int, len(pattern))
+	k := 0
+	for i := 1; i < len(pattern); i++ {
+		for k > 0 && pattern[i] != pattern[k] {
+			k = border[k-1]
+		}
+		if pattern[i] == pattern[k] {
+			k++
+		}
+		border[i] = k
+	}
+	return &KMP[T]{pattern: slices.Clone(pattern), border: border}
+}
+
+// Index 返回第一次出现的位置，不存在时返回 -1
+func (m *KMP[T]) Index(text []T) int {
+	pos := -1
+	m.scan(text, func(i int) bool {
+		pos = i
+		return false
+	})
+	return pos
+}
+
+// IndexAll 返回所有出现的位置（包括重叠的）
+func (m *KMP[T]) IndexAll(text []T) []int {
+	if len(m.pattern) == 0 {
+		return allPositions(len(text))
+	}
+	var out []int
+	m.scan(text, func(i int) bool {
+		out = append(out, i)
+		return true
+	})
+	return out
+}
+
+// scan 依次报告匹配的起始位置，yield 返回 false 时停止
+func (m *KMP[T]) scan(text []T, yield func(int) bool) {
+	p := m.pattern
+	if len(p) == 0 {
+		yield(0)
+		return
+	}
+	k := 0
+	for i, c := range text {
+		for k > 0 && c != p[k] {
+			k = m.border[k-1]
+		}
+		if c == p[k] {
+			k++
+		}
+		if k == len(p) {
+			if !yield(i - len(p) + 1) {
+				return
+			}
+			k = m.border[k-1]
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package search
+
+import "slices"
+
+// primeRK Rabin–Karp 的哈希基数，与标准库 internal/bytealg 相同
+const primeRK = 16777619
+
+// RabinKarp Rabin–Karp 查找器
+//
+// 对每个长度为 m 的窗口维护滚动哈希（模 2^64），哈希相同时再逐个比较确认。
+type RabinKarp[T Symbol] struct {
+	pattern []T
+	hash    uint64
+	pow     uint64 // primeRK^m，用于移出窗口最左边的符号
+}
+
+// NewRabinKarp 预处理模式
+func NewRabinKarp[T Symbol](pattern []T) *RabinKarp[T] {
+	rk := &RabinKarp[T]{pattern: slices.Clone(pattern), pow: 1}
+	for _, c := range pattern {
+		rk.hash = rk.hash*primeRK + uint64(c)
+		rk.pow *= primeRK
+	}
+	return rk
+}
+
+// Index 返回第一次出现的位置，不存在时返回 -1
+func (rk *RabinKarp[T]) Index(text []T) int {
+	pos := -1
+	rk.scan(text, func(i int) bool {
+		pos = i
+		return false
+	})
+	return pos
+}
+
+// IndexAll 返回所有出现的位置（包括重叠的）
+func (rk *RabinKarp[T]) IndexAll(text []T) []int {
+	if len(rk.pattern) == 0 {
+		return allPositions(len(text))
+	}
+	var out []int
+	rk.scan(text, func(i int) bool {
+		out = append(out, i)
+		return true
+	})
+	return out
+}
+
+// scan 依次报告匹配的起始位置，yield 返回 false 时停止
+func (rk *RabinKarp[T]) scan(text []T, yield func(int) bool) {
+	p := rk.pattern
+	m := len(p)
+	if m == 0 {
+		yield(0)
+		return
+	}
+	if len(text) < m {
+		return
+	}
+
+	var h uint64
+	for _, c := range text[:m] {
+		h = h*primeRK + uint64(c)
+	}
+	for i := 0; ; i++ {
+		if h == rk.hash && equal(text[i:], p) {
+			if !yield(i) {
+				return
+			}
+		}
+		if i+m >= len(text) {
+			return
+		}
+		h = h*primeRK + uint64(text[i+m]) - rk.pow*uint64(text[i])
+	}
+}
@@ -0,0 +1,56 @@
+// Package search 子串查找算法
+//
+// 所有算法都对字节或 rune 切片泛型实现：按字节查找得到字节下标，
+// 按 rune 查找（先用 []rune(s) 转换）得到字符下标。模式在构造时预处理一次，
+// 之后可以在任意多个文本上重复使用，实例可被多个 goroutine 并发读取。
+//
+//	算法            预处理        查找（最坏）   特点
+//	KMP             O(m)          O(n)          不回退文本，适合流式数据
+//	Horspool        O(m)          O(nm)         平均亚线性，模式越长越快
+//	RabinKarp       O(m)          O(nm)         滚动哈希，平均 O(n+m)
+//	AhoCorasick     O(Σm)         O(n+z)        多模式一次扫描，z 为匹配数
+//
+// 空模式在每个位置都匹配，与 strings.Index 的约定一致。
+package search
+
+// Symbol 可查找的符号类型
+type Symbol interface {
+	~byte | ~rune
+}
+
+// Searcher 单模式查找器
+type Searcher[T Symbol] interface {
+	// Index 返回模式在 text 中第一次出现的位置，不存在时返回 -1
+	Index(text []T) int
+	// IndexAll 返回模式所有出现的位置（包括重叠的），按升序排列
+	IndexAll(text []T) []int
+}
+
+// Index 使用 Horspool 算法在 s 中查找 substr，返回字节下标
+func Index(s, substr string) int {
+	return NewHorspool([]byte(substr)).Index([]byte(s))
+}
+
+// Count 统计 substr 在 s 中出现的次数（包括重叠的）
+func Count(s, substr string) int {
+	return len(NewKMP([]byte(substr)).IndexAll([]byte(s)))
+}
+
+// equal 比较 a 和 b 的前 len(b) 个符号
+func equal[T Symbol](a, b []T) bool {
+	for i := range b {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// allPositions 空模式的匹配位置：0..n
+func allPositions(n int) []int {
+	out := make([]int, n+1)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
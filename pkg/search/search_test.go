@@ -0,0 +1,271 @@
+package search
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// searchers 用同一模式构造所有单模式查找器
+func searchers[T Symbol](pattern []T) map[string]Searcher[T] {
+	return map[string]Searcher[T]{
+		"KMP":       NewKMP(pattern),
+		"Horspool":  NewHorspool(pattern),
+		"RabinKarp": NewRabinKarp(pattern),
+	}
+}
+
+// naiveAll 借助 strings.Index 找出所有（可重叠的）字节位置
+func naiveAll(text, pattern string) []int {
+	var out []int
+	for i := 0; i <= len(text); i++ {
+		j := strings.Index(text[i:], pattern)
+		if j < 0 {
+			break
+		}
+		out = append(out, i+j)
+		i += j
+	}
+	return out
+}
+
+func TestSearchers(t *testing.T) {
+	tests := []struct {
+		text, pattern string
+		want          []int
+	}{
+		{"hello world", "world", []int{6}},
+		{"hello world", "o", []int{4, 7}},
+		{"aaaa", "aa", []int{0, 1, 2}},
+		{"abababab", "abab", []int{0, 2, 4}},
+		{"abcabdabcabc", "abcabc", []int{6}},
+		{"short", "longer pattern", nil},
+		{"abc", "", []int{0, 1, 2, 3}},
+		{"", "", []int{0}},
+		{"", "a", nil},
+		{"needle", "needle", []int{0}},
+		{"xyz\xffxyz", "\xffx", []int{3}},
+	}
+	for _, tt := range tests {
+		for name, s := range searchers([]byte(tt.pattern)) {
+			t.Run(name+"/"+tt.text+"/"+tt.pattern, func(t *testing.T) {
+				if got := s.IndexAll([]byte(tt.text)); !slices.Equal(got, tt.want) {
+					t.Errorf("IndexAll: expected %v, got %v", tt.want, got)
+				}
+				want := -1
+				if len(tt.want) > 0 {
+					want = tt.want[0]
+				}
+				if got := s.Index([]byte(tt.text)); got != want {
+					t.Errorf("Index: expected %d, got %d", want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestRunes(t *testing.T) {
+	text := []rune("你好，世界！世界很大")
+	for name, s := range searchers([]rune("世界")) {
+		if got := s.IndexAll(text); !slices.Equal(got, []int{3, 6}) {
+			t.Errorf("%s: expected [3 6], got %v", name, got)
+		}
+	}
+	// 非 ASCII 的坏字符不能让 Horspool 跳过匹配
+	if got := NewHorspool([]rune("界很")).Index(text); got != 7 {
+		t.Errorf("Horspool: expected 7, got %d", got)
+	}
+}
+
+func TestHelpers(t *testing.T) {
+	if got := Index("chicken", "ken"); got != 4 {
+		t.Errorf("Index: expected 4, got %d", got)
+	}
+	if got := Count("aaaaa", "aa"); got != 4 {
+		t.Errorf("Count: expected 4, got %d", got)
+	}
+}
+
+func TestAhoCorasick(t *testing.T) {
+	ac := NewAhoCorasick([]byte("he"), []byte("she"), []byte("his"), []byte("hers"), []byte("he"))
+	got := ac.FindAll([]byte("ushers"))
+	want := []Match{
+		{Pattern: 1, Start: 1, End: 4},
+		{Pattern: 0, Start: 2, End: 4},
+		{Pattern: 4, Start: 2, End: 4},
+		{Pattern: 3, Start: 2, End: 6},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if !ac.Contains([]byte("this")) || ac.Contains([]byte("xyz")) {
+		t.Error("Contains mismatch")
+	}
+	if ac.Len() != 5 {
+		t.Errorf("expected 5 patterns, got %d", ac.Len())
+	}
+
+	empty := NewAhoCorasick([]rune(""), []rune("ab"))
+	got = empty.FindAll([]rune("ab"))
+	want = []Match{{0, 0, 0}, {0, 1, 1}, {1, 0, 2}, {0, 2, 2}}
+	if !slices.Equal(got, want) {
+		t.Errorf("empty pattern: expected %v, got %v", want, got)
+	}
+	if NewAhoCorasick[byte]().Contains([]byte("abc")) {
+		t.Error("no patterns should never match")
+	}
+}
+
+// checkAgainstStrings 比较各算法在字节和 rune 上的结果与 strings 包
+func checkAgainstStrings(t *testing.T, text, pattern string) {
+	t.Helper()
+	want := naiveAll(text, pattern)
+	for name, s := range searchers([]byte(pattern)) {
+		if got := s.Index([]byte(text)); got != strings.Index(text, pattern) {
+			t.Fatalf("%s.Index(%q, %q) = %d, strings.Index = %d", name, text, pattern, got, strings.Index(text, pattern))
+		}
+		if got := s.IndexAll([]byte(text)); !slices.Equal(got, want) {
+			t.Fatalf("%s.IndexAll(%q, %q) = %v, want %v", name, text, pattern, got, want)
+		}
+	}
+
+	// 合法 UTF-8 中非空模式的字节匹配总是落在字符边界上，可以换算成 rune 下标比较
+	if pattern == "" || !utf8.ValidString(text) || !utf8.ValidString(pattern) {
+		return
+	}
+	runeWant := make([]int, len(want))
+	for i, b := range want {
+		runeWant[i] = utf8.RuneCountInString(text[:b])
+	}
+	for name, s := range searchers([]rune(pattern)) {
+		if got := s.IndexAll([]rune(text)); !slices.Equal(got, runeWant) {
+			t.Fatalf("%s.IndexAll(runes %q, %q) = %v, want %v", name, text, pattern, got, runeWant)
+		}
+	}
+}
+
+func FuzzIndex(f *testing.F) {
+	seeds := [][2]string{
+		{"hello world", "o w"},
+		{"aaaaaaaaab", "aaab"},
+		{"abababab", "abab"},
+		{"你好世界世界", "世界"},
+		{"", ""},
+		{"\xff\xfe", "\xfe"},
+	}
+	for _, s := range seeds {
+		f.Add(s[0], s[1])
+	}
+	f.Fuzz(checkAgainstStrings)
+}
+
+func FuzzAhoCorasick(f *testing.F) {
+	f.Add("ushers", "he", "she", "hers")
+	f.Add("aaaa", "a", "aa", "")
+	f.Add("世界世界", "世", "界世", "x")
+	f.Fuzz(func(t *testing.T, text, p1, p2, p3 string) {
+		patterns := []string{p1, p2, p3}
+		ac := NewAhoCorasick([]byte(p1), []byte(p2), []byte(p3))
+
+		got := make([][]int, len(patterns))
+		for _, m := range ac.FindAll([]byte(text)) {
+			if text[m.Start:m.End] != patterns[m.Pattern] {
+				t.Fatalf("match %v does not cover pattern %q", m, patterns[m.Pattern])
+			}
+			got[m.Pattern] = append(got[m.Pattern], m.Start)
+		}
+		contains := false
+		for i, p := range patterns {
+			want := naiveAll(text, p)
+			if !slices.Equal(got[i], want) {
+				t.Fatalf("pattern %q in %q: expected %v, got %v", p, text, want, got[i])
+			}
+			contains = contains || len(want) > 0
+		}
+		if ac.Contains([]byte(text)) != contains {
+			t.Fatalf("Contains(%q) = %v", text, !contains)
+		}
+	})
+}
+
+// TestRandomAgainstStrings 在小字母表上随机生成用例，覆盖大量部分匹配
+func TestRandomAgainstStrings(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	alphabet := []string{"a", "b", "c", "世"}
+	gen := func(n int) string {
+		var sb strings.Builder
+		for range n {
+			sb.WriteString(alphabet[rng.Intn(len(alphabet))])
+		}
+		return sb.String()
+	}
+	for range 2000 {
+		checkAgainstStrings(t, gen(rng.Intn(40)), gen(rng.Intn(5)))
+	}
+}
+
+// benchText 近似英文的文本，pattern 只出现在末尾
+func benchText(n int) string {
+	rng := rand.New(rand.NewSource(1))
+	words := strings.Fields("the quick brown fox jumps over a lazy dog while some other words fill space")
+	var sb strings.Builder
+	for sb.Len() < n {
+		sb.WriteString(words[rng.Intn(len(words))])
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+func BenchmarkIndex(b *testing.B) {
+	cases := []struct {
+		name, text, pattern string
+	}{
+		{"english", benchText(64<<10) + "needle in a haystack", "needle in a haystack"},
+		{"worst", strings.Repeat("a", 64<<10) + "b", strings.Repeat("a", 31) + "b"},
+	}
+	for _, c := range cases {
+		text := []byte(c.text)
+		b.Run(c.name+"/strings.Index", func(b *testing.B) {
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				strings.Index(c.text, c.pattern)
+			}
+		})
+		for name, s := range searchers([]byte(c.pattern)) {
+			b.Run(c.name+"/"+name, func(b *testing.B) {
+				b.SetBytes(int64(len(text)))
+				for i := 0; i < b.N; i++ {
+					s.Index(text)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkMultiPattern(b *testing.B) {
+	text := benchText(64 << 10)
+	patterns := strings.Fields("fox dog cat bird lazy brown jumps whale words space")
+	bs := make([][]byte, len(patterns))
+	for i, p := range patterns {
+		bs[i] = []byte(p)
+	}
+	ac := NewAhoCorasick(bs...)
+	data := []byte(text)
+
+	b.Run("strings.Count", func(b *testing.B) {
+		b.SetBytes(int64(len(text)))
+		for i := 0; i < b.N; i++ {
+			for _, p := range patterns {
+				strings.Count(text, p)
+			}
+		}
+	})
+	b.Run("AhoCorasick", func(b *testing.B) {
+		b.SetBytes(int64(len(text)))
+		for i := 0; i < b.N; i++ {
+			ac.FindAll(data)
+		}
+	})
+}
@@ -0,0 +1,223 @@
+// Package sorting 泛型排序算法
+//
+// 除 Radix 外，每个算法都接收一个比较函数 cmp(a, b)，
+// a 小于 b 时返回负数，相等返回 0，大于返回正数（与 cmp.Compare 一致）。
+//
+//	算法        时间复杂度        稳定
+//	Insertion   O(n²)            是
+//	Merge       O(n log n)       是
+//	Heap        O(n log n)       否
+//	Intro       O(n log n)       否
+//	Radix       O(n·8)           是
+package sorting
+
+import "math/bits"
+
+// insertionThreshold 小于该长度的区间直接使用插入排序
+const insertionThreshold = 12
+
+// Insertion 插入排序，稳定，适合小规模或基本有序的数据
+func Insertion[S ~[]E, E any](s S, cmp func(a, b E) int) {
+	insertionRange(s, 0, len(s), cmp)
+}
+
+// insertionRange 对 s[lo:hi] 做插入排序
+func insertionRange[S ~[]E, E any](s S, lo, hi int, cmp func(a, b E) int) {
+	for i := lo + 1; i < hi; i++ {
+		x := s[i]
+		j := i
+		for j > lo && cmp(s[j-1], x) > 0 {
+			s[j] = s[j-1]
+			j--
+		}
+		s[j] = x
+	}
+}
+
+// Merge 自顶向下归并排序，稳定，需要 O(n) 额外空间
+func Merge[S ~[]E, E any](s S, cmp func(a, b E) int) {
+	if len(s) < 2 {
+		return
+	}
+	buf := make(S, len(s))
+	mergeSort(s, buf, cmp)
+}
+
+// mergeSort 排序 s，buf 为同长度的临时空间
+func mergeSort[S ~[]E, E any](s, buf S, cmp func(a, b E) int) {
+	if len(s) <= insertionThreshold {
+		Insertion(s, cmp)
+		return
+	}
+
+	mid := len(s) / 2
+	mergeSort(s[:mid], buf[:mid], cmp)
+	mergeSort(s[mid:], buf[mid:], cmp)
+
+	// 两半已经有序时无需合并
+	if cmp(s[mid-1], s[mid]) <= 0 {
+		return
+	}
+
+	copy(buf, s)
+	i, j, k := 0, mid, 0
+	for i < mid && j < len(s) {
+		// 相等时取左半部分的元素以保持稳定
+		if cmp(buf[j], buf[i]) < 0 {
+			s[k] = buf[j]
+			j++
+		} else {
+			s[k] = buf[i]
+			i++
+		}
+		k++
+	}
+	k += copy(s[k:], buf[i:mid])
+	copy(s[k:], buf[j:])
+}
+
+// Heap 堆排序，原地排序，不稳定
+func Heap[S ~[]E, E any](s S, cmp func(a, b E) int) {
+	heapRange(s, 0, len(s), cmp)
+}
+
+// heapRange 对 s[lo:hi] 做堆排序
+func heapRange[S ~[]E, E any](s S, lo, hi int, cmp func(a, b E) int) {
+	n := hi - lo
+	for i := n/2 - 1; i >= 0; i-- {
+		siftDown(s, lo, i, n, cmp)
+	}
+	for end := n - 1; end > 0; end-- {
+		s[lo], s[lo+end] = s[lo+end], s[lo]
+		siftDown(s, lo, 0, end, cmp)
+	}
+}
+
+// siftDown 在以 lo 为起点、长度为 n 的大顶堆中下沉 root
+func siftDown[S ~[]E, E any](s S, lo, root, n int, cmp func(a, b E) int) {
+	for {
+		child := 2*root + 1
+		if child >= n {
+			return
+		}
+		if child+1 < n && cmp(s[lo+child], s[lo+child+1]) < 0 {
+			child++
+		}
+		if cmp(s[lo+root], s[lo+child]) >= 0 {
+			return
+		}
+		s[lo+root], s[lo+child] = s[lo+child], s[lo+root]
+		root = child
+	}
+}
+
+// Intro 内省排序：快速排序为主，递归过深时退化为堆排序，小区间使用插入排序
+func Intro[S ~[]E, E any](s S, cmp func(a, b E) int) {
+	if len(s) < 2 {
+		return
+	}
+	depth := 2 * bits.Len(uint(len(s)))
+	introRange(s, 0, len(s), depth, cmp)
+}
+
+// introRange 对 s[lo:hi] 做内省排序
+func introRange[S ~[]E, E any](s S, lo, hi, depth int, cmp func(a, b E) int) {
+	for hi-lo > insertionThreshold {
+		if depth == 0 {
+			heapRange(s, lo, hi, cmp)
+			return
+		}
+		depth--
+
+		p := partition(s, lo, hi, cmp)
+		// 先递归较短的一侧，保证栈深度为 O(log n)
+		if p-lo < hi-p-1 {
+			introRange(s, lo, p, depth, cmp)
+			lo = p + 1
+		} else {
+			introRange(s, p+1, hi, depth, cmp)
+			hi = p
+		}
+	}
+	insertionRange(s, lo, hi, cmp)
+}
+
+// partition 三数取中选取枢轴并做 Lomuto 划分，返回枢轴的最终位置
+func partition[S ~[]E, E any](s S, lo, hi int, cmp func(a, b E) int) int {
+	mid := lo + (hi-lo)/2
+	last := hi - 1
+
+	// 排好 s[lo]、s[mid]、s[last]，中位数放到 last 作为枢轴
+	if cmp(s[mid], s[lo]) < 0 {
+		s[mid], s[lo] = s[lo], s[mid]
+	}
+	if cmp(s[last], s[lo]) < 0 {
+		s[last], s[lo] = s[lo], s[last]
+	}
+	if cmp(s[mid], s[last]) < 0 {
+		s[mid], s[last] = s[last], s[mid]
+	}
+
+	pivot := s[last]
+	i := lo
+	for j := lo; j < last; j++ {
+		if cmp(s[j], pivot) < 0 {
+			s[i], s[j] = s[j], s[i]
+			i++
+		}
+	}
+	s[i], s[last] = s[last], s[i]
+	return i
+}
+
+// Radix LSD 基数排序，稳定
+//
+// key 将元素映射为无符号整数，排序结果按 key 升序。
+// 有符号整数请使用 SignedKey 转换，使负数排在正数之前。
+func Radix[S ~[]E, E any](s S, key func(E) uint64) {
+	if len(s) < 2 {
+		return
+	}
+
+	keys := make([]uint64, len(s))
+	var all uint64
+	for i, e := range s {
+		keys[i] = key(e)
+		all |= keys[i]
+	}
+
+	buf := make(S, len(s))
+	bufKeys := make([]uint64, len(s))
+	src, dst := s, buf
+	srcKeys, dstKeys := keys, bufKeys
+
+	// 每次处理一个字节，跳过所有元素都为 0 的高位字节
+	passes := 0
+	for shift := uint(0); shift < 64 && all>>shift != 0; shift += 8 {
+		var count [257]int
+		for _, k := range srcKeys {
+			count[(k>>shift)&0xff+1]++
+		}
+		for b := 1; b < len(count); b++ {
+			count[b] += count[b-1]
+		}
+		for i, k := range srcKeys {
+			b := (k >> shift) & 0xff
+			dst[count[b]] = src[i]
+			dstKeys[count[b]] = k
+			count[b]++
+		}
+		src, dst = dst, src
+		srcKeys, dstKeys = dstKeys, srcKeys
+		passes++
+	}
+
+	if passes%2 == 1 {
+		copy(s, src)
+	}
+}
+
+// SignedKey 将有符号整数映射为保持顺序的无符号整数
+func SignedKey(v int64) uint64 {
+	return uint64(v) ^ (1 << 63)
+}
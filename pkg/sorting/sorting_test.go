@@ -0,0 +1,199 @@
+package sorting
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// record 带原始位置的元素，用于检查稳定性
+type record struct {
+	key   int
+	index int
+}
+
+func compareRecords(a, b record) int {
+	return cmp.Compare(a.key, b.key)
+}
+
+// algorithm 统一的测试入口
+type algorithm struct {
+	name   string
+	stable bool
+	sort   func(s []record)
+}
+
+var algorithms = []algorithm{
+	{"Insertion", true, func(s []record) { Insertion(s, compareRecords) }},
+	{"Merge", true, func(s []record) { Merge(s, compareRecords) }},
+	{"Heap", false, func(s []record) { Heap(s, compareRecords) }},
+	{"Intro", false, func(s []record) { Intro(s, compareRecords) }},
+	{"Radix", true, func(s []record) {
+		Radix(s, func(r record) uint64 { return SignedKey(int64(r.key)) })
+	}},
+}
+
+// generate 生成各种形态的输入，keyRange 越小重复元素越多
+func generate(rng *rand.Rand, n, keyRange int, shape string) []record {
+	s := make([]record, n)
+	for i := range s {
+		s[i] = record{key: rng.Intn(keyRange) - keyRange/2, index: i}
+	}
+	switch shape {
+	case "sorted":
+		slices.SortStableFunc(s, compareRecords)
+	case "reversed":
+		slices.SortStableFunc(s, func(a, b record) int { return compareRecords(b, a) })
+	case "organ":
+		slices.SortStableFunc(s, compareRecords)
+		slices.Reverse(s[n/2:])
+	}
+	for i := range s {
+		s[i].index = i
+	}
+	return s
+}
+
+// TestProperties 对随机输入检查有序性、排列不变性和稳定性
+func TestProperties(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sizes := []int{0, 1, 2, 3, 11, 12, 13, 50, 257, 1000}
+	shapes := []string{"random", "sorted", "reversed", "organ"}
+	keyRanges := []int{2, 10, 1 << 20}
+
+	for _, alg := range algorithms {
+		t.Run(alg.name, func(t *testing.T) {
+			for _, n := range sizes {
+				if alg.name == "Insertion" && n > 257 {
+					continue
+				}
+				for _, shape := range shapes {
+					for _, keyRange := range keyRanges {
+						input := generate(rng, n, keyRange, shape)
+						got := slices.Clone(input)
+						alg.sort(got)
+
+						want := slices.Clone(input)
+						slices.SortStableFunc(want, compareRecords)
+
+						label := fmt.Sprintf("n=%d shape=%s keys=%d", n, shape, keyRange)
+						checkSorted(t, label, got)
+						checkPermutation(t, label, input, got)
+						if alg.stable && !slices.Equal(got, want) {
+							t.Fatalf("%s: not stable", label)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func checkSorted(t *testing.T, label string, s []record) {
+	t.Helper()
+	for i := 1; i < len(s); i++ {
+		if s[i-1].key > s[i].key {
+			t.Fatalf("%s: not sorted at %d: %d > %d", label, i, s[i-1].key, s[i].key)
+		}
+	}
+}
+
+func checkPermutation(t *testing.T, label string, input, got []record) {
+	t.Helper()
+	if len(input) != len(got) {
+		t.Fatalf("%s: length changed %d -> %d", label, len(input), len(got))
+	}
+	seen := make([]bool, len(input))
+	for _, r := range got {
+		if r.index < 0 || r.index >= len(input) || seen[r.index] || input[r.index] != r {
+			t.Fatalf("%s: result is not a permutation of the input", label)
+		}
+		seen[r.index] = true
+	}
+}
+
+func TestRadixUnsignedAndNamedSlice(t *testing.T) {
+	type scores []uint32
+	s := scores{300, 1, 70000, 0, 255, 256, 1 << 31}
+	Radix(s, func(v uint32) uint64 { return uint64(v) })
+	if !slices.IsSorted(s) {
+		t.Errorf("expected sorted, got %v", s)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	words := []string{"pear", "apple", "fig", "banana", "cherry", "date"}
+	want := slices.Clone(words)
+	slices.Sort(want)
+
+	for name, sortFn := range map[string]func([]string, func(a, b string) int){
+		"Insertion": Insertion[[]string],
+		"Merge":     Merge[[]string],
+		"Heap":      Heap[[]string],
+		"Intro":     Intro[[]string],
+	} {
+		got := slices.Clone(words)
+		sortFn(got, cmp.Compare[string])
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+// ===== 基准测试 =====
+
+func benchInput(shape string, n int) []int {
+	rng := rand.New(rand.NewSource(42))
+	s := make([]int, n)
+	for i := range s {
+		s[i] = rng.Int()
+	}
+	switch shape {
+	case "sorted":
+		slices.Sort(s)
+	case "reversed":
+		slices.Sort(s)
+		slices.Reverse(s)
+	}
+	return s
+}
+
+func BenchmarkSort(b *testing.B) {
+	const n = 10000
+	intKey := func(v int) uint64 { return SignedKey(int64(v)) }
+
+	sorters := []struct {
+		name string
+		sort func([]int)
+	}{
+		{"slices.Sort", slices.Sort[[]int]},
+		{"Merge", func(s []int) { Merge(s, cmp.Compare[int]) }},
+		{"Heap", func(s []int) { Heap(s, cmp.Compare[int]) }},
+		{"Intro", func(s []int) { Intro(s, cmp.Compare[int]) }},
+		{"Radix", func(s []int) { Radix(s, intKey) }},
+	}
+
+	for _, shape := range []string{"random", "sorted", "reversed"} {
+		input := benchInput(shape, n)
+		for _, sorter := range sorters {
+			b.Run(shape+"/"+sorter.name, func(b *testing.B) {
+				s := make([]int, n)
+				for i := 0; i < b.N; i++ {
+					copy(s, input)
+					sorter.sort(s)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkInsertionSmall(b *testing.B) {
+	input := benchInput("random", 32)
+	s := make([]int, len(input))
+	for i := 0; i < b.N; i++ {
+		copy(s, input)
+		Insertion(s, cmp.Compare[int])
+	}
+}
@@ -0,0 +1,419 @@
+// Package sqlmap 基于 `db` 结构体标签的 SQL 语句构建与结果映射
+//
+// 标签格式为 `db:"column[,pk]"`：
+//   - column 为数据库列名，"-" 表示忽略该字段
+//   - pk 标记主键列，Update 在未指定条件列时使用主键作为 WHERE 条件
+//
+// 没有 db 标签的字段不参与映射；匿名嵌入的结构体字段会被展开，
+// 未导出的嵌入结构体指针除外。
+// 生成的语句中表名和列名按占位符对应的数据库方言加引号，
+// 因此 order、user 等保留字也可以用作列名。
+package sqlmap
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Placeholder 占位符风格，同时决定标识符的引号方式
+type Placeholder int
+
+const (
+	// Question 使用 ? 占位符和 `name` 形式的标识符（MySQL、SQLite）
+	Question Placeholder = iota
+	// Dollar 使用 $1, $2 占位符和 "name" 形式的标识符（PostgreSQL）
+	Dollar
+)
+
+// Field 一个映射到数据库列的结构体字段
+type Field struct {
+	Name       string // 结构体字段名
+	Column     string // 数据库列名
+	PrimaryKey bool   // 是否为主键
+	index      []int
+}
+
+// Mapper 结构体与 SQL 之间的映射器
+type Mapper struct {
+	Tag         string
+	Placeholder Placeholder
+
+	cache sync.Map // fieldsKey -> []Field
+}
+
+// fieldsKey 字段缓存的键，Tag 可以在创建后修改，因此与类型一起作为键
+type fieldsKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// New 创建使用 db 标签和 ? 占位符的映射器
+func New() *Mapper {
+	return &Mapper{Tag: "db", Placeholder: Question}
+}
+
+// Fields 返回类型中所有映射字段，v 可以是结构体、结构体指针或 reflect.Type
+func (m *Mapper) Fields(v interface{}) ([]Field, error) {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("sqlmap: 需要结构体类型，得到 %v", t)
+	}
+
+	key := fieldsKey{t, m.Tag}
+	if cached, ok := m.cache.Load(key); ok {
+		return cached.([]Field), nil
+	}
+
+	fields := m.collect(t, nil)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("sqlmap: 类型 %s 没有带 %s 标签的字段", t, m.Tag)
+	}
+	m.cache.Store(key, fields)
+	return fields, nil
+}
+
+// collect 递归收集字段，展开匿名嵌入结构体
+func (m *Mapper) collect(t reflect.Type, parent []int) []Field {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		index := append(append([]int(nil), parent...), i)
+
+		tag, hasTag := sf.Tag.Lookup(m.Tag)
+		if tag == "-" {
+			continue
+		}
+
+		if sf.Anonymous && !hasTag {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				// 与 encoding/json 一致：未导出的嵌入指针无法通过反射赋值，不展开
+				if !sf.IsExported() {
+					continue
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, m.collect(ft, index)...)
+			}
+			continue
+		}
+
+		if !hasTag || !sf.IsExported() {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		field := Field{Name: sf.Name, Column: parts[0], index: index}
+		if field.Column == "" {
+			field.Column = strings.ToLower(sf.Name)
+		}
+		for _, opt := range parts[1:] {
+			if strings.TrimSpace(opt) == "pk" {
+				field.PrimaryKey = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// Values 返回列名到字段值的映射
+func (m *Mapper) Values(v interface{}) (map[string]interface{}, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := m.Fields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		result[f.Column] = fieldByIndex(rv, f.index).Interface()
+	}
+	return result, nil
+}
+
+// Insert 构建 INSERT 语句及其参数
+func (m *Mapper) Insert(table string, v interface{}) (string, []interface{}, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return "", nil, err
+	}
+	fields, err := m.Fields(rv.Type())
+	if err != nil {
+		return "", nil, err
+	}
+
+	columns := make([]string, len(fields))
+	holders := make([]string, len(fields))
+	args := make([]interface{}, len(fields))
+	for i, f := range fields {
+		columns[i] = m.quote(f.Column)
+		holders[i] = m.placeholder(i + 1)
+		args[i] = fieldByIndex(rv, f.index).Interface()
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		m.quote(table), strings.Join(columns, ", "), strings.Join(holders, ", "))
+	return query, args, nil
+}
+
+// Update 构建 UPDATE 语句及其参数
+//
+// keys 指定 WHERE 条件使用的列；为空时使用标记为 pk 的列。
+// 条件列不会出现在 SET 子句中。
+func (m *Mapper) Update(table string, v interface{}, keys ...string) (string, []interface{}, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return "", nil, err
+	}
+	fields, err := m.Fields(rv.Type())
+	if err != nil {
+		return "", nil, err
+	}
+
+	isKey := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		isKey[k] = true
+	}
+	if len(keys) == 0 {
+		for _, f := range fields {
+			if f.PrimaryKey {
+				isKey[f.Column] = true
+			}
+		}
+	}
+	if len(isKey) == 0 {
+		return "", nil, fmt.Errorf("sqlmap: 更新 %s 需要条件列或 pk 标签", table)
+	}
+
+	var sets, wheres []string
+	var setArgs, whereArgs []interface{}
+	for _, f := range fields {
+		value := fieldByIndex(rv, f.index).Interface()
+		if isKey[f.Column] {
+			wheres = append(wheres, f.Column)
+			whereArgs = append(whereArgs, value)
+			delete(isKey, f.Column)
+			continue
+		}
+		sets = append(sets, f.Column)
+		setArgs = append(setArgs, value)
+	}
+	// 剩余的条件列只可能来自调用方传入的 keys
+	for _, column := range keys {
+		if isKey[column] {
+			return "", nil, fmt.Errorf("sqlmap: 条件列 %s 不存在", column)
+		}
+	}
+	if len(sets) == 0 {
+		return "", nil, fmt.Errorf("sqlmap: 更新 %s 没有可设置的列", table)
+	}
+
+	n := 0
+	for i, column := range sets {
+		n++
+		sets[i] = m.quote(column) + " = " + m.placeholder(n)
+	}
+	for i, column := range wheres {
+		n++
+		wheres[i] = m.quote(column) + " = " + m.placeholder(n)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		m.quote(table), strings.Join(sets, ", "), strings.Join(wheres, " AND "))
+	return query, append(setArgs, whereArgs...), nil
+}
+
+// Select 构建 SELECT 语句，where 中的列按顺序生成等值条件占位符
+func (m *Mapper) Select(table string, v interface{}, where ...string) (string, error) {
+	fields, err := m.Fields(v)
+	if err != nil {
+		return "", err
+	}
+
+	known := make(map[string]bool, len(fields))
+	columns := make([]string, len(fields))
+	for i, f := range fields {
+		columns[i] = m.quote(f.Column)
+		known[f.Column] = true
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), m.quote(table))
+	if len(where) == 0 {
+		return query, nil
+	}
+
+	conds := make([]string, len(where))
+	for i, column := range where {
+		if !known[column] {
+			return "", fmt.Errorf("sqlmap: 条件列 %s 不存在", column)
+		}
+		conds[i] = m.quote(column) + " = " + m.placeholder(i+1)
+	}
+	return query + " WHERE " + strings.Join(conds, " AND "), nil
+}
+
+// ScanRow 将 rows 的当前行扫描到 dest（结构体指针）中，按列名匹配字段
+func (m *Mapper) ScanRow(rows *sql.Rows, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("sqlmap: ScanRow 需要非空结构体指针，得到 %T", dest)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	targets, err := m.targets(rv.Elem(), columns)
+	if err != nil {
+		return err
+	}
+	return rows.Scan(targets...)
+}
+
+// ScanAll 读取 rows 的所有行并追加到 dest（结构体切片指针）中
+func (m *Mapper) ScanAll(rows *sql.Rows, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("sqlmap: ScanAll 需要切片指针，得到 %T", dest)
+	}
+
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	structType := elemType
+	if isPtr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("sqlmap: ScanAll 的元素必须是结构体，得到 %s", elemType)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		item := reflect.New(structType)
+		targets, err := m.targets(item.Elem(), columns)
+		if err != nil {
+			return err
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return err
+		}
+		if isPtr {
+			slice = reflect.Append(slice, item)
+		} else {
+			slice = reflect.Append(slice, item.Elem())
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rv.Elem().Set(slice)
+	return nil
+}
+
+// targets 为每一列找到对应字段的地址
+func (m *Mapper) targets(rv reflect.Value, columns []string) ([]interface{}, error) {
+	fields, err := m.Fields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	byColumn := make(map[string]Field, len(fields))
+	for _, f := range fields {
+		byColumn[f.Column] = f
+	}
+
+	targets := make([]interface{}, len(columns))
+	for i, column := range columns {
+		f, ok := byColumn[column]
+		if !ok {
+			return nil, fmt.Errorf("sqlmap: 列 %s 在 %s 中没有对应字段", column, rv.Type())
+		}
+		targets[i] = allocByIndex(rv, f.index).Addr().Interface()
+	}
+	return targets, nil
+}
+
+// placeholder 返回第 n 个参数的占位符（从1开始）
+func (m *Mapper) placeholder(n int) string {
+	if m.Placeholder == Dollar {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// quote 按方言为标识符加引号，schema.table 形式的名字逐段处理，
+// 名字中的引号字符按 SQL 规则写两次
+func (m *Mapper) quote(name string) string {
+	q := "`"
+	if m.Placeholder == Dollar {
+		q = `"`
+	}
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = q + strings.ReplaceAll(part, q, q+q) + q
+	}
+	return strings.Join(parts, ".")
+}
+
+// structValue 解引用指针并确认是结构体
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("sqlmap: 空指针 %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("sqlmap: 需要结构体，得到 %T", v)
+	}
+	return rv, nil
+}
+
+// fieldByIndex 读取嵌套字段，遇到空的嵌入指针时返回零值
+func fieldByIndex(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Zero(rv.Type().Elem().FieldByIndex(index[i:]).Type)
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv
+}
+
+// allocByIndex 定位可写字段，必要时为空的嵌入指针分配内存
+func allocByIndex(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv
+}
@@ -0,0 +1,422 @@
+package sqlmap
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// ===== 进程内的假驱动 =====
+
+// fakeResult 预设的查询结果
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// fakeDriver 记录执行过的语句，并按查询语句返回预设结果
+type fakeDriver struct {
+	mu      sync.Mutex
+	execs   []string
+	args    [][]driver.Value
+	results map[string]fakeResult
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{d: c.d, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, fmt.Errorf("fake: 不支持事务") }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.execs = append(s.d.execs, s.query)
+	s.d.args = append(s.d.args, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	res, ok := s.d.results[s.query]
+	if !ok {
+		return nil, fmt.Errorf("fake: 未预设查询 %q", s.query)
+	}
+	return &fakeRows{res: res}, nil
+}
+
+type fakeRows struct {
+	res fakeResult
+	pos int
+}
+
+func (r *fakeRows) Columns() []string { return r.res.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.res.rows) {
+		return io.EOF
+	}
+	copy(dest, r.res.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+var (
+	fake     = &fakeDriver{results: make(map[string]fakeResult)}
+	fakeOnce sync.Once
+)
+
+func openFake(t *testing.T) *sql.DB {
+	t.Helper()
+	fakeOnce.Do(func() { sql.Register("sqlmapfake", fake) })
+	db, err := sql.Open("sqlmapfake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// ===== 测试用的结构体 =====
+
+type Timestamps struct {
+	Created string `db:"created_at"`
+}
+
+type testUser struct {
+	ID       int64  `db:"user_id,pk"`
+	Username string `db:"username"`
+	Email    string `db:"email"`
+	Password string `db:"-"`
+	Active   bool   `db:"is_active"`
+	note     string `db:"note"`
+	Ignored  int
+	Timestamps
+}
+
+func TestFields(t *testing.T) {
+	fields, err := New().Fields(&testUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range fields {
+		got = append(got, f.Column)
+	}
+	want := []string{"user_id", "username", "email", "is_active", "created_at"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if !fields[0].PrimaryKey {
+		t.Error("expected user_id to be primary key")
+	}
+
+	if _, err := New().Fields(42); err == nil {
+		t.Error("expected error for non-struct")
+	}
+}
+
+func TestStatements(t *testing.T) {
+	u := testUser{ID: 7, Username: "john", Email: "j@x.io", Active: true,
+		Timestamps: Timestamps{Created: "2024-01-01"}}
+
+	tests := []struct {
+		name      string
+		build     func(m *Mapper) (string, []interface{}, error)
+		style     Placeholder
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name: "insert",
+			build: func(m *Mapper) (string, []interface{}, error) {
+				return m.Insert("users", u)
+			},
+			wantQuery: "INSERT INTO `users` (`user_id`, `username`, `email`, `is_active`, `created_at`) VALUES (?, ?, ?, ?, ?)",
+			wantArgs:  []interface{}{int64(7), "john", "j@x.io", true, "2024-01-01"},
+		},
+		{
+			name: "insert dollar",
+			build: func(m *Mapper) (string, []interface{}, error) {
+				return m.Insert("users", &u)
+			},
+			style:     Dollar,
+			wantQuery: `INSERT INTO "users" ("user_id", "username", "email", "is_active", "created_at") VALUES ($1, $2, $3, $4, $5)`,
+			wantArgs:  []interface{}{int64(7), "john", "j@x.io", true, "2024-01-01"},
+		},
+		{
+			name: "update by pk",
+			build: func(m *Mapper) (string, []interface{}, error) {
+				return m.Update("users", u)
+			},
+			style:     Dollar,
+			wantQuery: `UPDATE "users" SET "username" = $1, "email" = $2, "is_active" = $3, "created_at" = $4 WHERE "user_id" = $5`,
+			wantArgs:  []interface{}{"john", "j@x.io", true, "2024-01-01", int64(7)},
+		},
+		{
+			name: "update by keys",
+			build: func(m *Mapper) (string, []interface{}, error) {
+				return m.Update("users", u, "email", "username")
+			},
+			wantQuery: "UPDATE `users` SET `user_id` = ?, `is_active` = ?, `created_at` = ? WHERE `username` = ? AND `email` = ?",
+			wantArgs:  []interface{}{int64(7), true, "2024-01-01", "john", "j@x.io"},
+		},
+		{
+			name: "select",
+			build: func(m *Mapper) (string, []interface{}, error) {
+				q, err := m.Select("users", testUser{}, "user_id", "is_active")
+				return q, nil, err
+			},
+			wantQuery: "SELECT `user_id`, `username`, `email`, `is_active`, `created_at` FROM `users` WHERE `user_id` = ? AND `is_active` = ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			m.Placeholder = tt.style
+			query, args, err := tt.build(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("expected %q, got %q", tt.wantQuery, query)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("expected args %v, got %v", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestQuoteIdentifiers(t *testing.T) {
+	type order struct {
+		ID    int64  `db:"id,pk"`
+		User  string `db:"user"`
+		Order int    `db:"order"`
+	}
+	v := order{ID: 1, User: "alice", Order: 2}
+
+	tests := []struct {
+		name  string
+		style Placeholder
+		table string
+		want  string
+	}{
+		{"question", Question, "order", "UPDATE `order` SET `user` = ?, `order` = ? WHERE `id` = ?"},
+		{"dollar", Dollar, "order", `UPDATE "order" SET "user" = $1, "order" = $2 WHERE "id" = $3`},
+		{"schema", Dollar, "shop.order", `UPDATE "shop"."order" SET "user" = $1, "order" = $2 WHERE "id" = $3`},
+		{"escape", Dollar, `we"ird`, `UPDATE "we""ird" SET "user" = $1, "order" = $2 WHERE "id" = $3`},
+		{"escape backtick", Question, "we`ird", "UPDATE `we``ird` SET `user` = ?, `order` = ? WHERE `id` = ?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			m.Placeholder = tt.style
+			query, _, err := m.Update(tt.table, v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, query)
+			}
+		})
+	}
+}
+
+type hiddenBase struct {
+	ID int64 `db:"id"`
+}
+
+// withHiddenBase 未导出的嵌入指针无法通过反射分配，不参与映射
+type withHiddenBase struct {
+	*hiddenBase
+	Name string `db:"name"`
+}
+
+func TestUnexportedEmbeddedPointer(t *testing.T) {
+	m := New()
+	fields, err := m.Fields(withHiddenBase{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 1 || fields[0].Column != "name" {
+		t.Errorf("expected only name, got %+v", fields)
+	}
+
+	db := openFake(t)
+	query, err := m.Select("hidden", withHiddenBase{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	fake.results[query] = fakeResult{columns: []string{"name"}, rows: [][]driver.Value{{"alice"}}}
+	fake.mu.Unlock()
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []withHiddenBase
+	if err := m.ScanAll(rows, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "alice" || got[0].hiddenBase != nil {
+		t.Errorf("unexpected rows %+v", got)
+	}
+}
+
+func TestFieldsCacheByTag(t *testing.T) {
+	type row struct {
+		Name string `db:"name" sql:"full_name"`
+	}
+	m := New()
+	if _, err := m.Fields(row{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改 Tag 后不能复用按 db 标签缓存的字段
+	m.Tag = "sql"
+	fields, err := m.Fields(row{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 1 || fields[0].Column != "full_name" {
+		t.Errorf("expected column full_name, got %+v", fields)
+	}
+}
+
+func TestStatementErrors(t *testing.T) {
+	type noKey struct {
+		Name string `db:"name"`
+	}
+	m := New()
+
+	if _, _, err := m.Update("t", noKey{}); err == nil {
+		t.Error("expected error when no key columns")
+	}
+	if _, _, err := m.Update("t", testUser{}, "missing"); err == nil {
+		t.Error("expected error for unknown key column")
+	}
+	if _, err := m.Select("t", testUser{}, "missing"); err == nil {
+		t.Error("expected error for unknown where column")
+	}
+	if _, _, err := m.Insert("t", (*testUser)(nil)); err == nil {
+		t.Error("expected error for nil pointer")
+	}
+}
+
+func TestExecAndScan(t *testing.T) {
+	db := openFake(t)
+	m := New()
+
+	query, args, err := m.Insert("users", testUser{ID: 1, Username: "alice", Email: "a@x.io"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	last := fake.execs[len(fake.execs)-1]
+	lastArgs := fake.args[len(fake.args)-1]
+	fake.mu.Unlock()
+	if last != query || len(lastArgs) != 5 || lastArgs[1] != "alice" {
+		t.Errorf("unexpected exec %q %v", last, lastArgs)
+	}
+
+	selectQuery, err := m.Select("users", testUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.mu.Lock()
+	fake.results[selectQuery] = fakeResult{
+		columns: []string{"user_id", "username", "email", "is_active", "created_at"},
+		rows: [][]driver.Value{
+			{int64(1), "alice", "a@x.io", true, "2024-01-01"},
+			{int64(2), "bob", "b@x.io", false, "2024-02-01"},
+		},
+	}
+	fake.mu.Unlock()
+
+	t.Run("scan all", func(t *testing.T) {
+		rows, err := db.Query(selectQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		var users []testUser
+		if err := m.ScanAll(rows, &users); err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 2 {
+			t.Fatalf("expected 2 users, got %d", len(users))
+		}
+		want := testUser{ID: 2, Username: "bob", Email: "b@x.io", Timestamps: Timestamps{Created: "2024-02-01"}}
+		if users[1] != want {
+			t.Errorf("expected %+v, got %+v", want, users[1])
+		}
+	})
+
+	t.Run("scan row", func(t *testing.T) {
+		rows, err := db.Query(selectQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		if !rows.Next() {
+			t.Fatal("expected a row")
+		}
+		var u testUser
+		if err := m.ScanRow(rows, &u); err != nil {
+			t.Fatal(err)
+		}
+		if u.Username != "alice" || !u.Active {
+			t.Errorf("unexpected row %+v", u)
+		}
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		fake.mu.Lock()
+		fake.results["SELECT bogus FROM users"] = fakeResult{
+			columns: []string{"bogus"},
+			rows:    [][]driver.Value{{int64(1)}},
+		}
+		fake.mu.Unlock()
+
+		rows, err := db.Query("SELECT bogus FROM users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+
+		var users []*testUser
+		if err := m.ScanAll(rows, &users); err == nil {
+			t.Error("expected error for unmapped column")
+		}
+	})
+}
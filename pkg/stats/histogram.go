@@ -0,0 +1,159 @@
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrInvalidBuckets 桶边界为空、含 NaN 或不是严格递增
+	ErrInvalidBuckets = errors.New("stats: 无效的桶边界")
+	// ErrBucketMismatch 合并的两个直方图桶边界不同
+	ErrBucketMismatch = errors.New("stats: 桶边界不一致")
+)
+
+// LinearBuckets 从 start 开始、宽度为 width 的 count 个桶上界
+func LinearBuckets(start, width float64, count int) []float64 {
+	bounds := make([]float64, max(count, 0))
+	for i := range bounds {
+		bounds[i] = start + float64(i)*width
+	}
+	return bounds
+}
+
+// ExponentialBuckets 从 start 开始、每个是前一个 factor 倍的 count 个桶上界
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	bounds := make([]float64, max(count, 0))
+	for i := range bounds {
+		bounds[i] = start * math.Pow(factor, float64(i))
+	}
+	return bounds
+}
+
+// Bucket 直方图的一个桶，包含 (上一个桶的 Upper, Upper] 内的观测值
+type Bucket struct {
+	Upper float64 // 最后一个桶为 +Inf
+	Count int
+}
+
+// Histogram 固定桶直方图
+//
+// 每个边界是一个桶的上界（含），超过最大边界的观测值落入 +Inf 桶。
+type Histogram struct {
+	bounds []float64
+	counts []int // len(bounds)+1
+	total  int
+	sum    float64
+}
+
+// NewHistogram 以给定的桶上界创建直方图，边界必须严格递增
+func NewHistogram(bounds ...float64) (*Histogram, error) {
+	if len(bounds) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一个边界", ErrInvalidBuckets)
+	}
+	for i, b := range bounds {
+		if math.IsNaN(b) || (i > 0 && b <= bounds[i-1]) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBuckets, bounds)
+		}
+	}
+	return &Histogram{
+		bounds: slices.Clone(bounds),
+		counts: make([]int, len(bounds)+1),
+	}, nil
+}
+
+// Observe 记录一个观测值，NaN 被忽略
+func (h *Histogram) Observe(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	h.counts[sort.SearchFloat64s(h.bounds, x)]++
+	h.total++
+	h.sum += x
+}
+
+// Merge 合并另一个桶边界相同的直方图
+func (h *Histogram) Merge(o *Histogram) error {
+	if !slices.Equal(h.bounds, o.bounds) {
+		return ErrBucketMismatch
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.total += o.total
+	h.sum += o.sum
+	return nil
+}
+
+// Count 观测值个数
+func (h *Histogram) Count() int { return h.total }
+
+// Sum 观测值总和
+func (h *Histogram) Sum() float64 { return h.sum }
+
+// Buckets 各桶的计数（非累计）
+func (h *Histogram) Buckets() []Bucket {
+	out := make([]Bucket, len(h.counts))
+	for i, c := range h.counts {
+		out[i] = Bucket{Upper: h.upper(i), Count: c}
+	}
+	return out
+}
+
+// Quantile 假设桶内均匀分布，估计 q 分位数
+//
+// 第一个桶的下界视为 0（边界为负时直接返回其上界）；
+// 落在 +Inf 桶中的分位数无法估计，返回最大的有限边界。
+func (h *Histogram) Quantile(q float64) float64 {
+	if h.total == 0 || !(q >= 0 && q <= 1) {
+		return math.NaN()
+	}
+	rank := q * float64(h.total)
+	cum := 0.0
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		if rank <= cum+float64(c) {
+			if i == len(h.bounds) {
+				return h.bounds[len(h.bounds)-1]
+			}
+			lower := 0.0
+			if i > 0 {
+				lower = h.bounds[i-1]
+			} else if h.bounds[0] < 0 {
+				return h.bounds[0]
+			}
+			return lower + (h.bounds[i]-lower)*(rank-cum)/float64(c)
+		}
+		cum += float64(c)
+	}
+	return h.bounds[len(h.bounds)-1]
+}
+
+// String 以文本条形图展示各桶
+func (h *Histogram) String() string {
+	const width = 40
+	peak := slices.Max(h.counts)
+	var sb strings.Builder
+	for i, c := range h.counts {
+		bar := 0
+		if peak > 0 {
+			bar = c * width / peak
+		}
+		line := fmt.Sprintf("<= %-8g %6d %s", h.upper(i), c, strings.Repeat("#", bar))
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return sb.String()
+}
+
+func (h *Histogram) upper(i int) float64 {
+	if i == len(h.bounds) {
+		return math.Inf(1)
+	}
+	return h.bounds[i]
+}
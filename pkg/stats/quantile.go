@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"math"
+	"slices"
+)
+
+// Quantile 计算已排序数据的 q 分位数（0 <= q <= 1）
+//
+// 在相邻的两个顺序统计量之间线性插值（与 NumPy 默认的 linear 方法一致）。
+// 数据为空或 q 超出范围时返回 NaN。
+func Quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 || !(q >= 0 && q <= 1) {
+		return math.NaN()
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := pos - float64(lo)
+	return sorted[lo] + frac*(sorted[lo+1]-sorted[lo])
+}
+
+// Exact 保存全部观测值以计算精确分位数
+//
+// 零值即可使用。排序推迟到第一次查询时进行。
+type Exact struct {
+	values []float64
+	sorted bool
+}
+
+// Add 加入一个观测值，NaN 被忽略
+func (e *Exact) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	e.values = append(e.values, x)
+	e.sorted = false
+}
+
+// AddAll 加入多个观测值
+func (e *Exact) AddAll(xs ...float64) {
+	for _, x := range xs {
+		e.Add(x)
+	}
+}
+
+// Merge 合并另一个累加器的观测值
+func (e *Exact) Merge(o *Exact) {
+	e.AddAll(o.values...)
+}
+
+// Count 观测值个数
+func (e *Exact) Count() int { return len(e.values) }
+
+// Quantile q 分位数
+func (e *Exact) Quantile(q float64) float64 {
+	e.sort()
+	return Quantile(e.values, q)
+}
+
+// Percentiles 一次计算多个分位数
+func (e *Exact) Percentiles(qs ...float64) []float64 {
+	e.sort()
+	out := make([]float64, len(qs))
+	for i, q := range qs {
+		out[i] = Quantile(e.values, q)
+	}
+	return out
+}
+
+// Values 按升序返回观测值的副本
+func (e *Exact) Values() []float64 {
+	e.sort()
+	return slices.Clone(e.values)
+}
+
+func (e *Exact) sort() {
+	if !e.sorted {
+		slices.Sort(e.values)
+		e.sorted = true
+	}
+}
@@ -0,0 +1,137 @@
+// Package stats 流式统计
+//
+// 所有累加器都只需单次遍历数据，并且可以合并：每个 goroutine 各自累加，
+// 最后用 Merge 汇总，无需在热路径上加锁。累加器本身不是并发安全的。
+//
+//	类型        内容                          内存
+//	Summary     计数、总和、均值、方差、极值   O(1)
+//	Exact       精确分位数                    O(n)
+//	TDigest     近似分位数                    O(compression)
+//	Histogram   固定桶直方图                  O(桶数)
+//
+// 没有数据时，未定义的统计量（均值、极值、分位数等）返回 NaN。
+// 值为 NaN 的观测值被所有累加器忽略，不计入个数。
+package stats
+
+import (
+	"fmt"
+	"math"
+)
+
+// Summary 基本统计量，方差使用 Welford 算法在线计算，数值稳定
+//
+// 零值即可使用。
+type Summary struct {
+	n    int
+	sum  float64
+	mean float64
+	m2   float64 // 与均值之差的平方和
+	min  float64
+	max  float64
+}
+
+// Add 加入一个观测值，NaN 被忽略
+func (s *Summary) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	s.n++
+	s.sum += x
+	if s.n == 1 {
+		s.min, s.max = x, x
+	} else {
+		s.min = math.Min(s.min, x)
+		s.max = math.Max(s.max, x)
+	}
+	delta := x - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (x - s.mean)
+}
+
+// AddAll 加入多个观测值
+func (s *Summary) AddAll(xs ...float64) {
+	for _, x := range xs {
+		s.Add(x)
+	}
+}
+
+// Merge 合并另一个累加器的结果（Chan 等人的并行方差算法）
+func (s *Summary) Merge(o *Summary) {
+	if o.n == 0 {
+		return
+	}
+	if s.n == 0 {
+		*s = *o
+		return
+	}
+	n := s.n + o.n
+	delta := o.mean - s.mean
+	s.m2 += o.m2 + delta*delta*float64(s.n)*float64(o.n)/float64(n)
+	s.mean += delta * float64(o.n) / float64(n)
+	s.sum += o.sum
+	s.min = math.Min(s.min, o.min)
+	s.max = math.Max(s.max, o.max)
+	s.n = n
+}
+
+// Count 观测值个数
+func (s *Summary) Count() int { return s.n }
+
+// Sum 总和
+func (s *Summary) Sum() float64 { return s.sum }
+
+// Mean 均值
+func (s *Summary) Mean() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return s.mean
+}
+
+// Min 最小值
+func (s *Summary) Min() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return s.min
+}
+
+// Max 最大值
+func (s *Summary) Max() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return s.max
+}
+
+// Variance 总体方差
+func (s *Summary) Variance() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return s.m2 / float64(s.n)
+}
+
+// SampleVariance 样本方差（除以 n-1），少于两个观测值时返回 NaN
+func (s *Summary) SampleVariance() float64 {
+	if s.n < 2 {
+		return math.NaN()
+	}
+	return s.m2 / float64(s.n-1)
+}
+
+// StdDev 总体标准差
+func (s *Summary) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// SampleStdDev 样本标准差
+func (s *Summary) SampleStdDev() float64 {
+	return math.Sqrt(s.SampleVariance())
+}
+
+// String 统计量的字符串表示
+func (s *Summary) String() string {
+	return fmt.Sprintf("n=%d sum=%g mean=%g stddev=%g min=%g max=%g",
+		s.n, s.sum, s.Mean(), s.StdDev(), s.Min(), s.Max())
+}
@@ -0,0 +1,350 @@
+package stats
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func almostEqual(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
+}
+
+func TestSummary(t *testing.T) {
+	var s Summary
+	if !math.IsNaN(s.Mean()) || !math.IsNaN(s.Min()) || s.Count() != 0 {
+		t.Errorf("empty summary: %v", &s)
+	}
+
+	s.AddAll(2, 4, 4, 4, 5, 5, 7, 9)
+	s.Add(math.NaN()) // NaN 被忽略，与 TDigest、Histogram 一致
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"count", float64(s.Count()), 8},
+		{"sum", s.Sum(), 40},
+		{"mean", s.Mean(), 5},
+		{"min", s.Min(), 2},
+		{"max", s.Max(), 9},
+		{"variance", s.Variance(), 4},
+		{"stddev", s.StdDev(), 2},
+		{"sample variance", s.SampleVariance(), 32.0 / 7},
+	}
+	for _, tt := range tests {
+		if !almostEqual(tt.got, tt.want, 1e-12) {
+			t.Errorf("%s: expected %g, got %g", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+// TestSummaryStability 大偏移量下 Welford 算法仍能得到准确的方差
+func TestSummaryStability(t *testing.T) {
+	var s Summary
+	for _, x := range []float64{4, 7, 13, 16} {
+		s.Add(1e9 + x)
+	}
+	if !almostEqual(s.SampleVariance(), 30, 1e-6) {
+		t.Errorf("expected variance 30, got %g", s.SampleVariance())
+	}
+}
+
+// TestMergeAcrossGoroutines 各 goroutine 分别累加后合并，结果与顺序累加一致
+func TestMergeAcrossGoroutines(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	data := make([]float64, 40000)
+	for i := range data {
+		data[i] = rng.ExpFloat64() * 100
+	}
+
+	var (
+		whole  Summary
+		exact  Exact
+		digest = NewTDigest(0)
+	)
+	for _, x := range data {
+		whole.Add(x)
+		exact.Add(x)
+		digest.Add(x)
+	}
+	bounds := ExponentialBuckets(1, 2, 10)
+	wholeHist, _ := NewHistogram(bounds...)
+	for _, x := range data {
+		wholeHist.Observe(x)
+	}
+
+	// 各 goroutine 只写自己的累加器，等待结束后按固定顺序合并，
+	// 合并顺序影响 TDigest 的质心划分，固定顺序使结果可复现
+	const workers = 8
+	var (
+		wg    sync.WaitGroup
+		sums  [workers]Summary
+		exs   [workers]Exact
+		tds   [workers]*TDigest
+		hists [workers]*Histogram
+	)
+	chunk := len(data) / workers
+	for w := range workers {
+		tds[w] = NewTDigest(0)
+		hists[w], _ = NewHistogram(bounds...)
+		part := data[w*chunk : (w+1)*chunk]
+		wg.Go(func() {
+			for _, x := range part {
+				sums[w].Add(x)
+				exs[w].Add(x)
+				tds[w].Add(x)
+				hists[w].Observe(x)
+			}
+		})
+	}
+	wg.Wait()
+
+	var (
+		sum    Summary
+		merged Exact
+		td     = NewTDigest(0)
+	)
+	hist, _ := NewHistogram(bounds...)
+	for w := range workers {
+		sum.Merge(&sums[w])
+		merged.Merge(&exs[w])
+		td.Merge(tds[w])
+		if err := hist.Merge(hists[w]); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if sum.Count() != whole.Count() || sum.Min() != whole.Min() || sum.Max() != whole.Max() {
+		t.Errorf("merged %v, whole %v", &sum, &whole)
+	}
+	if !almostEqual(sum.Mean(), whole.Mean(), 1e-9) || !almostEqual(sum.Variance(), whole.Variance(), 1e-6) {
+		t.Errorf("merged %v, whole %v", &sum, &whole)
+	}
+	if merged.Quantile(0.99) != exact.Quantile(0.99) {
+		t.Errorf("exact p99 mismatch: %g vs %g", merged.Quantile(0.99), exact.Quantile(0.99))
+	}
+	if td.Count() != digest.Count() {
+		t.Errorf("digest count %g vs %g", td.Count(), digest.Count())
+	}
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		want := exact.Quantile(q)
+		if got := td.Quantile(q); math.Abs(got-want) > 0.02*want {
+			t.Errorf("merged digest q%g: expected ~%g, got %g", q, want, got)
+		}
+	}
+	for i, b := range hist.Buckets() {
+		if b != wholeHist.Buckets()[i] {
+			t.Errorf("bucket %d: %v vs %v", i, b, wholeHist.Buckets()[i])
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		q, want float64
+	}{
+		{0, 1},
+		{0.25, 2},
+		{0.5, 3},
+		{0.6, 3.4},
+		{1, 5},
+	}
+	for _, tt := range tests {
+		if got := Quantile(sorted, tt.q); !almostEqual(got, tt.want, 1e-12) {
+			t.Errorf("q=%g: expected %g, got %g", tt.q, tt.want, got)
+		}
+	}
+	for _, q := range []float64{-0.1, 1.1, math.NaN()} {
+		if got := Quantile(sorted, q); !math.IsNaN(got) {
+			t.Errorf("q=%g: expected NaN, got %g", q, got)
+		}
+	}
+	if !math.IsNaN(Quantile(nil, 0.5)) {
+		t.Error("empty data should give NaN")
+	}
+
+	var e Exact
+	e.AddAll(5, 1, math.NaN(), 4)
+	e.Add(2)
+	e.Add(3)
+	if got := e.Percentiles(0.5, 1); e.Count() != 5 || got[0] != 3 || got[1] != 5 {
+		t.Errorf("unexpected percentiles %v", got)
+	}
+}
+
+func TestTDigestAccuracy(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	dists := []struct {
+		name string
+		gen  func() float64
+	}{
+		{"uniform", rng.Float64},
+		{"normal", rng.NormFloat64},
+		{"exponential", rng.ExpFloat64},
+	}
+	for _, dist := range dists {
+		t.Run(dist.name, func(t *testing.T) {
+			d := NewTDigest(100)
+			var e Exact
+			for range 100000 {
+				x := dist.gen()
+				d.Add(x)
+				e.Add(x)
+			}
+			if n := d.Centroids(); n > 200 {
+				t.Errorf("too many centroids: %d", n)
+			}
+			if d.Min() != e.Quantile(0) || d.Max() != e.Quantile(1) {
+				t.Errorf("extremes: %g..%g vs %g..%g", d.Min(), d.Max(), e.Quantile(0), e.Quantile(1))
+			}
+			// 以秩误差衡量：估计值在真实分布中的位置与 q 的差
+			for _, q := range []float64{0.01, 0.1, 0.5, 0.9, 0.99, 0.999} {
+				est := d.Quantile(q)
+				rank := rankOf(e.Values(), est)
+				tol := 0.005
+				if q <= 0.01 || q >= 0.99 {
+					tol = 0.001
+				}
+				if math.Abs(rank-q) > tol {
+					t.Errorf("q=%g: estimate %g has rank %g", q, est, rank)
+				}
+				if cdf := d.CDF(est); math.Abs(cdf-q) > tol {
+					t.Errorf("CDF(%g) = %g, expected ~%g", est, cdf, q)
+				}
+			}
+		})
+	}
+}
+
+// rankOf x 在已排序数据中的相对位置
+func rankOf(sorted []float64, x float64) float64 {
+	lo, hi := 0, len(sorted)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if sorted[mid] < x {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return float64(lo) / float64(len(sorted))
+}
+
+func TestTDigestSmall(t *testing.T) {
+	d := NewTDigest(0)
+	if !math.IsNaN(d.Quantile(0.5)) || !math.IsNaN(d.CDF(1)) {
+		t.Error("empty digest should give NaN")
+	}
+	d.Add(7)
+	if d.Quantile(0.3) != 7 {
+		t.Errorf("single value: got %g", d.Quantile(0.3))
+	}
+	for _, x := range []float64{1, 2, 3, 4, 5, 6, 8, 9} {
+		d.Add(x)
+	}
+	if got := d.Quantile(0.5); got != 5 {
+		t.Errorf("median: expected 5, got %g", got)
+	}
+	if d.Quantile(0) != 1 || d.Quantile(1) != 9 {
+		t.Errorf("extremes: %g, %g", d.Quantile(0), d.Quantile(1))
+	}
+	if d.CDF(0) != 0 || d.CDF(9) != 1 {
+		t.Errorf("CDF outside range: %g, %g", d.CDF(0), d.CDF(9))
+	}
+	d.AddWeighted(100, 0)
+	d.Add(math.NaN())
+	if d.Count() != 9 {
+		t.Errorf("ignored values were counted: %g", d.Count())
+	}
+
+	// 零值可以直接使用，与 NewTDigest(0) 的结果一致
+	var zero TDigest
+	n := NewTDigest(0)
+	for i := range 1000 {
+		zero.Add(float64(i + 1))
+		n.Add(float64(i + 1))
+	}
+	if zero.Min() != 1 || zero.Max() != 1000 || zero.Centroids() != n.Centroids() || zero.Quantile(0.99) != n.Quantile(0.99) {
+		t.Errorf("zero value: min %g max %g centroids %d p99 %g, want %d centroids p99 %g",
+			zero.Min(), zero.Max(), zero.Centroids(), zero.Quantile(0.99), n.Centroids(), n.Quantile(0.99))
+	}
+	var merged TDigest
+	merged.Merge(n)
+	if merged.Min() != 1 || merged.Max() != 1000 {
+		t.Errorf("merge into zero value: min %g max %g", merged.Min(), merged.Max())
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h, err := NewHistogram(LinearBuckets(10, 10, 3)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []float64{1, 10, 11, 15, 20, 25, 30, 31, 100} {
+		h.Observe(x)
+	}
+	want := []Bucket{{10, 2}, {20, 3}, {30, 2}, {math.Inf(1), 2}}
+	got := h.Buckets()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("bucket %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+	h.Observe(math.NaN())
+	if h.Count() != 9 || h.Sum() != 243 {
+		t.Errorf("count %d, sum %g", h.Count(), h.Sum())
+	}
+
+	tests := []struct {
+		q, want float64
+	}{
+		{0, 0},
+		{1.0 / 9, 5},
+		{0.5, 55.0 / 3},
+		{1, 30},
+	}
+	for _, tt := range tests {
+		if got := h.Quantile(tt.q); !almostEqual(got, tt.want, 1e-9) {
+			t.Errorf("q=%g: expected %g, got %g", tt.q, tt.want, got)
+		}
+	}
+}
+
+func TestHistogramErrors(t *testing.T) {
+	invalid := [][]float64{
+		nil,
+		{1, 1},
+		{2, 1},
+		{1, math.NaN()},
+		LinearBuckets(0, 1, 0),
+	}
+	for _, bounds := range invalid {
+		if _, err := NewHistogram(bounds...); !errors.Is(err, ErrInvalidBuckets) {
+			t.Errorf("%v: expected ErrInvalidBuckets, got %v", bounds, err)
+		}
+	}
+
+	a, _ := NewHistogram(1, 2, 3)
+	b, _ := NewHistogram(1, 2, 4)
+	if err := a.Merge(b); !errors.Is(err, ErrBucketMismatch) {
+		t.Errorf("expected ErrBucketMismatch, got %v", err)
+	}
+}
+
+func TestBucketHelpers(t *testing.T) {
+	exp := ExponentialBuckets(1, 2, 4)
+	for i, want := range []float64{1, 2, 4, 8} {
+		if exp[i] != want {
+			t.Errorf("exponential bucket %d: expected %g, got %g", i, want, exp[i])
+		}
+	}
+	lin := LinearBuckets(0, 0.5, 3)
+	for i, want := range []float64{0, 0.5, 1} {
+		if lin[i] != want {
+			t.Errorf("linear bucket %d: expected %g, got %g", i, want, lin[i])
+		}
+	}
+}
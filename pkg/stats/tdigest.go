@@ -0,0 +1,221 @@
+package stats
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// DefaultCompression TDigest 的默认压缩参数
+const DefaultCompression = 100
+
+// centroid 一组相邻观测值的均值和权重
+type centroid struct {
+	mean  float64
+	count float64
+}
+
+// TDigest 近似分位数（Dunning 的合并式 t-digest）
+//
+// 观测值先进入缓冲区，缓冲区满时与已有质心一起排序合并。质心的大小受 k1 尺度函数
+// 约束：靠近两端的质心很小，中间的质心较大，因此尾部分位数（p99、p999）的误差
+// 远小于中位数附近。内存占用约为 compression 个质心。
+//
+// 零值即可使用，压缩参数为 DefaultCompression。
+type TDigest struct {
+	compression float64    // 为 0 时使用 DefaultCompression
+	centroids   []centroid // 按均值升序
+	buffer      []centroid
+	count       float64
+	min, max    float64
+}
+
+// NewTDigest 创建 TDigest，compression 越大精度越高，不大于 0 时使用 DefaultCompression
+func NewTDigest(compression float64) *TDigest {
+	if compression <= 0 {
+		compression = DefaultCompression
+	}
+	return &TDigest{compression: compression}
+}
+
+// Add 加入一个观测值
+func (t *TDigest) Add(x float64) {
+	t.AddWeighted(x, 1)
+}
+
+// AddWeighted 加入权重为 w 的观测值，w 不大于 0 或 x 为 NaN 时忽略
+func (t *TDigest) AddWeighted(x, w float64) {
+	if w <= 0 || math.IsNaN(x) {
+		return
+	}
+	t.buffer = append(t.buffer, centroid{mean: x, count: w})
+	t.observeRange(x, x)
+	t.count += w
+	if len(t.buffer) >= t.bufferLimit() {
+		t.compress()
+	}
+}
+
+// Merge 合并另一个 TDigest，o 本身不会被修改
+func (t *TDigest) Merge(o *TDigest) {
+	if o.count == 0 {
+		return
+	}
+	t.buffer = append(t.buffer, o.centroids...)
+	t.buffer = append(t.buffer, o.buffer...)
+	t.observeRange(o.min, o.max)
+	t.count += o.count
+	t.compress()
+}
+
+// observeRange 用新观测值的范围更新最小值和最大值，在增加 count 之前调用
+func (t *TDigest) observeRange(lo, hi float64) {
+	if t.count == 0 {
+		t.min, t.max = lo, hi
+		return
+	}
+	t.min = math.Min(t.min, lo)
+	t.max = math.Max(t.max, hi)
+}
+
+// Count 观测值的总权重
+func (t *TDigest) Count() float64 { return t.count }
+
+// Min 最小值
+func (t *TDigest) Min() float64 {
+	if t.count == 0 {
+		return math.NaN()
+	}
+	return t.min
+}
+
+// Max 最大值
+func (t *TDigest) Max() float64 {
+	if t.count == 0 {
+		return math.NaN()
+	}
+	return t.max
+}
+
+// Centroids 当前的质心个数
+func (t *TDigest) Centroids() int {
+	t.compress()
+	return len(t.centroids)
+}
+
+// Quantile q 分位数的估计值
+//
+// 在相邻质心的中心之间线性插值，最两端分别向 Min 和 Max 插值。
+func (t *TDigest) Quantile(q float64) float64 {
+	if t.count == 0 || !(q >= 0 && q <= 1) {
+		return math.NaN()
+	}
+	t.compress()
+	cs := t.centroids
+	if len(cs) == 1 {
+		return cs[0].mean
+	}
+
+	index := q * t.count
+	first, last := cs[0], cs[len(cs)-1]
+	if index < first.count/2 {
+		return t.min + (first.mean-t.min)*index/(first.count/2)
+	}
+	if index >= t.count-last.count/2 {
+		tail := index - (t.count - last.count/2)
+		return last.mean + (t.max-last.mean)*tail/(last.count/2)
+	}
+
+	// center 为当前质心中心对应的累计权重
+	center := first.count / 2
+	for i := 0; i < len(cs)-1; i++ {
+		next := center + (cs[i].count+cs[i+1].count)/2
+		if index < next {
+			frac := (index - center) / (next - center)
+			return cs[i].mean + frac*(cs[i+1].mean-cs[i].mean)
+		}
+		center = next
+	}
+	return last.mean
+}
+
+// CDF 小于等于 x 的观测值所占比例的估计值
+func (t *TDigest) CDF(x float64) float64 {
+	if t.count == 0 || math.IsNaN(x) {
+		return math.NaN()
+	}
+	switch {
+	case x < t.min:
+		return 0
+	case x >= t.max:
+		return 1
+	}
+	t.compress()
+	cs := t.centroids
+	if len(cs) == 1 {
+		return (x - t.min) / (t.max - t.min)
+	}
+
+	first := cs[0]
+	if x < first.mean {
+		return (x - t.min) / (first.mean - t.min) * first.count / 2 / t.count
+	}
+	center := first.count / 2
+	for i := 0; i < len(cs)-1; i++ {
+		next := center + (cs[i].count+cs[i+1].count)/2
+		if x < cs[i+1].mean {
+			frac := (x - cs[i].mean) / (cs[i+1].mean - cs[i].mean)
+			return (center + frac*(next-center)) / t.count
+		}
+		center = next
+	}
+	last := cs[len(cs)-1]
+	frac := (x - last.mean) / (t.max - last.mean)
+	return (center + frac*last.count/2) / t.count
+}
+
+// bufferLimit 缓冲区容量
+func (t *TDigest) bufferLimit() int {
+	return int(math.Ceil(t.comp())) * 5
+}
+
+// scale k1 尺度函数，把分位数映射到质心编号空间
+func (t *TDigest) scale(q float64) float64 {
+	return t.comp() / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// comp 压缩参数，零值 TDigest 使用 DefaultCompression
+func (t *TDigest) comp() float64 {
+	if t.compression <= 0 {
+		return DefaultCompression
+	}
+	return t.compression
+}
+
+// compress 把缓冲区与已有质心排序后合并，每个质心在尺度空间中的跨度不超过 1
+func (t *TDigest) compress() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := append(t.centroids, t.buffer...)
+	t.buffer = t.buffer[:0]
+	slices.SortFunc(all, func(a, b centroid) int { return cmp.Compare(a.mean, b.mean) })
+
+	merged := make([]centroid, 0, len(t.centroids)+1)
+	merged = append(merged, all[0])
+	// done 为已封闭质心的累计权重
+	done := 0.0
+	for _, c := range all[1:] {
+		cur := &merged[len(merged)-1]
+		lo := done / t.count
+		hi := (done + cur.count + c.count) / t.count
+		if t.scale(hi)-t.scale(lo) <= 1 {
+			cur.count += c.count
+			cur.mean += (c.mean - cur.mean) * c.count / cur.count
+			continue
+		}
+		done += cur.count
+		merged = append(merged, c)
+	}
+	t.centroids = merged
+}
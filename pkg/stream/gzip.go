@@ -0,0 +1,48 @@
+package stream
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// GzipWriter 压缩写入的数据，level 取 compress/gzip 的压缩级别
+//
+// 关闭时先写出 gzip 尾部，再关闭内层。级别无效时第一次写入返回错误。
+func GzipWriter(level int) WriterDecorator {
+	return func(inner io.WriteCloser) io.WriteCloser {
+		zw, err := gzip.NewWriterLevel(inner, level)
+		if err != nil {
+			return &writer{inner: inner, write: func([]byte) (int, error) { return 0, err }}
+		}
+		return &gzipWriter{zw: zw, inner: inner}
+	}
+}
+
+type gzipWriter struct {
+	zw    *gzip.Writer
+	inner io.WriteCloser
+}
+
+func (g *gzipWriter) Write(p []byte) (int, error) { return g.zw.Write(p) }
+
+func (g *gzipWriter) Close() error {
+	return errors.Join(g.zw.Close(), g.inner.Close())
+}
+
+// GunzipReader 解压读取的数据，头部无效时第一次读取返回错误
+func GunzipReader() ReaderDecorator {
+	return func(r io.Reader) io.Reader {
+		var zr *gzip.Reader
+		return readerFunc(func(p []byte) (int, error) {
+			if zr == nil {
+				var err error
+				if zr, err = gzip.NewReader(r); err != nil {
+					zr = nil
+					return 0, err
+				}
+			}
+			return zr.Read(p)
+		})
+	}
+}
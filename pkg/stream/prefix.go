@@ -0,0 +1,32 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+)
+
+// PrefixLines 在每一行的开头加上 prefix，常用于给子进程或并发任务的日志加标签
+//
+// 行可以跨越多次写入；空写入不会产生前缀。返回的字节数是调用方传入的字节数。
+func PrefixLines(prefix string) WriterDecorator {
+	return func(inner io.WriteCloser) io.WriteCloser {
+		atLineStart := true
+		return &writer{inner: inner, write: func(p []byte) (int, error) {
+			var buf bytes.Buffer
+			for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+				if len(line) == 0 {
+					continue
+				}
+				if atLineStart {
+					buf.WriteString(prefix)
+				}
+				buf.Write(line)
+				atLineStart = line[len(line)-1] == '\n'
+			}
+			if _, err := inner.Write(buf.Bytes()); err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}}
+	}
+}
@@ -0,0 +1,102 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrInvalidRate 限速器的速率不是正数
+var ErrInvalidRate = errors.New("stream: 速率必须为正数")
+
+// Limiter 令牌桶限速器，每秒补充 rate 个字节，最多积累 burst 个
+//
+// 同一个 Limiter 可以被多个流共享，从而限制它们的总速率。
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+	sleep  func(time.Duration)
+}
+
+// NewLimiter 创建限速器，burst 不大于 0 时取 rate；桶初始为满
+//
+// rate 不大于 0 时返回 ErrInvalidRate；不需要限速时不要加 RateLimitWriter/RateLimitReader。
+func NewLimiter(rate, burst int) (*Limiter, error) {
+	if rate <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidRate, rate)
+	}
+	if burst <= 0 {
+		burst = rate
+	}
+	return &Limiter{
+		rate:   float64(rate),
+		burst:  max(burst, 1),
+		tokens: float64(burst),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}, nil
+}
+
+// wait 取走 n 个令牌（n 不超过 burst），不足时睡眠到足够为止
+func (l *Limiter) wait(n int) {
+	l.mu.Lock()
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		l.tokens = min(l.tokens, float64(l.burst))
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	// 令牌为负表示预支，睡眠到补足为止；预支让后来者排在后面
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay > 0 {
+		l.sleep(delay)
+	}
+}
+
+// RateLimitWriter 限制写入这一层的速率，大块数据按 burst 分段写入
+func RateLimitWriter(l *Limiter) WriterDecorator {
+	return func(inner io.WriteCloser) io.WriteCloser {
+		return &writer{inner: inner, write: func(p []byte) (int, error) {
+			written := 0
+			for len(p) > 0 {
+				chunk := p[:min(len(p), l.burst)]
+				l.wait(len(chunk))
+				n, err := inner.Write(chunk)
+				written += n
+				if err != nil {
+					return written, err
+				}
+				p = p[n:]
+			}
+			return written, nil
+		}}
+	}
+}
+
+// RateLimitReader 限制从这一层读取的速率，每次最多读取 burst 个字节
+func RateLimitReader(l *Limiter) ReaderDecorator {
+	return func(r io.Reader) io.Reader {
+		return readerFunc(func(p []byte) (int, error) {
+			if len(p) > l.burst {
+				p = p[:l.burst]
+			}
+			n, err := r.Read(p)
+			if n > 0 {
+				l.wait(n)
+			}
+			return n, err
+		})
+	}
+}
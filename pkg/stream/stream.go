@@ -0,0 +1,181 @@
+// Package stream 可组合的 io.Reader / io.Writer 装饰器
+//
+// 每个装饰器都是一个函数，接收内层的流并返回包装后的流，可以按任意顺序叠加：
+//
+//	w := stream.ChainWriter(file,
+//		stream.CountWriter(&raw),  // 统计压缩前的字节数
+//		stream.GzipWriter(gzip.BestSpeed),
+//		stream.CountWriter(&packed), // 统计压缩后的字节数
+//	)
+//	defer w.Close()
+//
+// 列表中靠前的装饰器在外层，数据依次经过它们再到达底层的流。
+// 顺序决定了每一层看到的是什么数据，例如哈希放在压缩之前得到原文的摘要，放在之后得到压缩包的摘要。
+package stream
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// WriterDecorator 包装一个写入流
+type WriterDecorator func(w io.WriteCloser) io.WriteCloser
+
+// ReaderDecorator 包装一个读取流
+type ReaderDecorator func(r io.Reader) io.Reader
+
+// ChainWriter 按顺序叠加装饰器，第一个在最外层
+//
+// 关闭返回的流会依次关闭所有装饰层（例如刷新 gzip 尾部），但不会关闭 w 本身。
+func ChainWriter(w io.Writer, decorators ...WriterDecorator) io.WriteCloser {
+	var out io.WriteCloser = nopCloser{w}
+	for i := len(decorators) - 1; i >= 0; i-- {
+		out = decorators[i](out)
+	}
+	return out
+}
+
+// ChainReader 按顺序叠加装饰器，第一个在最外层
+func ChainReader(r io.Reader, decorators ...ReaderDecorator) io.Reader {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		r = decorators[i](r)
+	}
+	return r
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// writer 由写函数和内层流组成的装饰层，Close 直接关闭内层
+type writer struct {
+	write func(p []byte) (int, error)
+	inner io.WriteCloser
+}
+
+func (w *writer) Write(p []byte) (int, error) { return w.write(p) }
+
+func (w *writer) Close() error { return w.inner.Close() }
+
+// readerFunc 把函数适配为 io.Reader
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+// Counter 字节计数器，可并发读取
+type Counter struct {
+	n atomic.Int64
+}
+
+// N 已经过的字节数
+func (c *Counter) N() int64 { return c.n.Load() }
+
+// CountWriter 统计经过这一层的字节数
+func CountWriter(c *Counter) WriterDecorator {
+	return func(inner io.WriteCloser) io.WriteCloser {
+		return &writer{inner: inner, write: func(p []byte) (int, error) {
+			n, err := inner.Write(p)
+			c.n.Add(int64(n))
+			return n, err
+		}}
+	}
+}
+
+// CountReader 统计经过这一层的字节数
+func CountReader(c *Counter) ReaderDecorator {
+	return func(r io.Reader) io.Reader {
+		return readerFunc(func(p []byte) (int, error) {
+			n, err := r.Read(p)
+			c.n.Add(int64(n))
+			return n, err
+		})
+	}
+}
+
+// Hash 流经数据的 SHA-256 摘要
+type Hash struct {
+	mu sync.Mutex
+	h  hash.Hash
+}
+
+// NewHash 创建摘要
+func NewHash() *Hash {
+	return &Hash{h: sha256.New()}
+}
+
+func (h *Hash) add(p []byte) {
+	h.mu.Lock()
+	h.h.Write(p)
+	h.mu.Unlock()
+}
+
+// Sum 当前的摘要
+func (h *Hash) Sum() []byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.h.Sum(nil)
+}
+
+// Hex 当前摘要的十六进制表示
+func (h *Hash) Hex() string {
+	return hex.EncodeToString(h.Sum())
+}
+
+// HashWriter 把成功写入内层的数据同时计入摘要
+func HashWriter(h *Hash) WriterDecorator {
+	return func(inner io.WriteCloser) io.WriteCloser {
+		return &writer{inner: inner, write: func(p []byte) (int, error) {
+			n, err := inner.Write(p)
+			h.add(p[:n])
+			return n, err
+		}}
+	}
+}
+
+// HashReader 把读到的数据同时计入摘要
+func HashReader(h *Hash) ReaderDecorator {
+	return func(r io.Reader) io.Reader {
+		return readerFunc(func(p []byte) (int, error) {
+			n, err := r.Read(p)
+			h.add(p[:n])
+			return n, err
+		})
+	}
+}
+
+// ProgressFunc 进度回调，done 为已处理的字节数，total 为调用方给出的总量（未知时为 0）
+type ProgressFunc func(done, total int64)
+
+// ProgressWriter 每次写入后报告累计字节数
+func ProgressWriter(total int64, fn ProgressFunc) WriterDecorator {
+	return func(inner io.WriteCloser) io.WriteCloser {
+		var done int64
+		return &writer{inner: inner, write: func(p []byte) (int, error) {
+			n, err := inner.Write(p)
+			if n > 0 {
+				done += int64(n)
+				fn(done, total)
+			}
+			return n, err
+		}}
+	}
+}
+
+// ProgressReader 每次读取后报告累计字节数
+func ProgressReader(total int64, fn ProgressFunc) ReaderDecorator {
+	return func(r io.Reader) io.Reader {
+		var done int64
+		return readerFunc(func(p []byte) (int, error) {
+			n, err := r.Read(p)
+			if n > 0 {
+				done += int64(n)
+				fn(done, total)
+			}
+			return n, err
+		})
+	}
+}
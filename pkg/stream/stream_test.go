@@ -0,0 +1,255 @@
+package stream
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// payload 可压缩的多行文本
+var payload = strings.Repeat("the quick brown fox jumps over the lazy dog\n", 200)
+
+func sha(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func gunzip(t *testing.T, data []byte) string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	return string(out)
+}
+
+// write 把 payload 分成小块写入，模拟流式写入
+func write(t *testing.T, w io.WriteCloser, data string) {
+	t.Helper()
+	for len(data) > 0 {
+		n := min(len(data), 37)
+		if _, err := io.WriteString(w, data[:n]); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCountOrder(t *testing.T) {
+	var out bytes.Buffer
+	var before, after Counter
+	write(t, ChainWriter(&out, CountWriter(&before), GzipWriter(gzip.BestCompression), CountWriter(&after)), payload)
+
+	if before.N() != int64(len(payload)) {
+		t.Errorf("outer counter should see plain bytes: %d", before.N())
+	}
+	if after.N() != int64(out.Len()) || after.N() >= before.N() {
+		t.Errorf("inner counter should see compressed bytes: %d (output %d)", after.N(), out.Len())
+	}
+	if got := gunzip(t, out.Bytes()); got != payload {
+		t.Error("round trip mismatch")
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	var out bytes.Buffer
+	plain, packed := NewHash(), NewHash()
+	write(t, ChainWriter(&out, HashWriter(plain), GzipWriter(gzip.DefaultCompression), HashWriter(packed)), payload)
+
+	if plain.Hex() != sha([]byte(payload)) {
+		t.Error("hash before gzip should digest the plain text")
+	}
+	if packed.Hex() != sha(out.Bytes()) {
+		t.Error("hash after gzip should digest the compressed stream")
+	}
+
+	// 读取方向：先解压再哈希，得到原文的摘要
+	got := NewHash()
+	var n Counter
+	r := ChainReader(bytes.NewReader(out.Bytes()), HashReader(got), GunzipReader(), CountReader(&n))
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	if got.Hex() != plain.Hex() || n.N() != int64(out.Len()) {
+		t.Errorf("reader chain: hash %s, counted %d of %d", got.Hex(), n.N(), out.Len())
+	}
+}
+
+func TestPrefixOrder(t *testing.T) {
+	// 先加前缀再压缩：解压后每行都有前缀
+	var out bytes.Buffer
+	write(t, ChainWriter(&out, PrefixLines("[job] "), GzipWriter(gzip.BestSpeed)), "a\nb\n")
+	if got := gunzip(t, out.Bytes()); got != "[job] a\n[job] b\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+
+	// 先压缩再加前缀：前缀混进了压缩数据，gzip 头部被破坏
+	out.Reset()
+	write(t, ChainWriter(&out, GzipWriter(gzip.BestSpeed), PrefixLines("[job] ")), "a\nb\n")
+	if !bytes.HasPrefix(out.Bytes(), []byte("[job] ")) {
+		t.Error("prefix should be the first bytes of the output")
+	}
+	if _, err := io.ReadAll(ChainReader(&out, GunzipReader())); !errors.Is(err, gzip.ErrHeader) {
+		t.Errorf("expected gzip.ErrHeader, got %v", err)
+	}
+}
+
+func TestPrefixLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{"single line", []string{"hello\n"}, "> hello\n"},
+		{"no trailing newline", []string{"a\nb"}, "> a\n> b"},
+		{"split across writes", []string{"he", "llo\nwor", "ld\n"}, "> hello\n> world\n"},
+		{"empty lines", []string{"\n\n"}, "> \n> \n"},
+		{"empty write", []string{"", "x"}, "> x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := ChainWriter(&out, PrefixLines("> "))
+			for _, s := range tt.writes {
+				if n, err := io.WriteString(w, s); err != nil || n != len(s) {
+					t.Fatalf("write %q: n=%d err=%v", s, n, err)
+				}
+			}
+			if out.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestProgressOrder(t *testing.T) {
+	var out bytes.Buffer
+	var outer, inner []int64
+	record := func(dst *[]int64) ProgressFunc {
+		return func(done, total int64) {
+			if total != int64(len(payload)) {
+				t.Errorf("unexpected total %d", total)
+			}
+			*dst = append(*dst, done)
+		}
+	}
+	write(t, ChainWriter(&out,
+		ProgressWriter(int64(len(payload)), record(&outer)),
+		GzipWriter(gzip.BestCompression),
+		ProgressWriter(int64(len(payload)), record(&inner)),
+	), payload)
+
+	if len(outer) == 0 || outer[len(outer)-1] != int64(len(payload)) {
+		t.Errorf("outer progress should reach %d: %v", len(payload), outer)
+	}
+	// gzip 会缓冲数据，压缩后的进度次数更少、总量更小
+	if len(inner) == 0 || len(inner) >= len(outer) || inner[len(inner)-1] != int64(out.Len()) {
+		t.Errorf("inner progress: %d reports, last %v, output %d", len(inner), inner, out.Len())
+	}
+}
+
+// fakeClock 睡眠时推进时间的时钟
+type fakeClock struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func newTestLimiter(t *testing.T, rate, burst int) (*Limiter, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l, err := NewLimiter(rate, burst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return clock.now }
+	l.sleep = func(d time.Duration) {
+		clock.now = clock.now.Add(d)
+		clock.slept += d
+	}
+	return l, clock
+}
+
+func TestRateLimitOrder(t *testing.T) {
+	const rate = 1000
+
+	// 限速在压缩之前：按原文字节计费
+	l, clock := newTestLimiter(t, rate, 100)
+	var out bytes.Buffer
+	write(t, ChainWriter(&out, RateLimitWriter(l), GzipWriter(gzip.BestCompression)), payload)
+	plain := clock.slept
+	want := time.Duration(len(payload)-100) * time.Second / rate
+	if diff := plain - want; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("expected to sleep ~%v, slept %v", want, plain)
+	}
+
+	// 限速在压缩之后：只按压缩后的字节计费，等待时间短得多
+	l, clock = newTestLimiter(t, rate, 100)
+	out.Reset()
+	write(t, ChainWriter(&out, GzipWriter(gzip.BestCompression), RateLimitWriter(l)), payload)
+	packed := clock.slept
+	want = time.Duration(max(out.Len()-100, 0)) * time.Second / rate
+	if diff := packed - want; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("expected to sleep ~%v, slept %v", want, packed)
+	}
+	if packed*5 > plain {
+		t.Errorf("compressed stream should be throttled much less: %v vs %v", packed, plain)
+	}
+}
+
+func TestRateLimitReader(t *testing.T) {
+	l, clock := newTestLimiter(t, 100, 10)
+	var n Counter
+	r := ChainReader(strings.NewReader(strings.Repeat("x", 60)), CountReader(&n), RateLimitReader(l))
+	buf := make([]byte, 64)
+	first, _ := r.Read(buf)
+	if first != 10 {
+		t.Errorf("reads should be capped at burst, got %d", first)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	if n.N() != 60 || clock.slept != 500*time.Millisecond {
+		t.Errorf("read %d bytes, slept %v", n.N(), clock.slept)
+	}
+
+	for _, rate := range []int{0, -1} {
+		if _, err := NewLimiter(rate, 10); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("rate %d: expected ErrInvalidRate, got %v", rate, err)
+		}
+	}
+}
+
+// failWriter 写入总是失败的底层流
+type failWriter struct{}
+
+func (f *failWriter) Write([]byte) (int, error) { return 0, io.ErrShortWrite }
+
+func TestErrors(t *testing.T) {
+	var n Counter
+	h := NewHash()
+	w := ChainWriter(&failWriter{}, CountWriter(&n), HashWriter(h))
+	if _, err := w.Write([]byte("abc")); !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("expected io.ErrShortWrite, got %v", err)
+	}
+	if n.N() != 0 || h.Hex() != sha(nil) {
+		t.Error("failed writes should not be counted or hashed")
+	}
+
+	w = ChainWriter(io.Discard, GzipWriter(42))
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("expected error for invalid gzip level")
+	}
+}
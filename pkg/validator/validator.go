@@ -0,0 +1,541 @@
+// Package validator 基于 `validate` 结构体标签的校验引擎
+//
+// 标签由逗号分隔的规则组成，例如 `validate:"required,min=3,email"`。
+// 内置规则：
+//   - required       值不能为零值（nil 指针、空字符串、空切片等）
+//   - omitempty      值为零值时跳过其余规则
+//   - min=N, max=N   字符串/切片/映射比较长度，数字比较数值
+//   - len=N          长度必须等于 N
+//   - email          合法的电子邮件地址
+//   - oneof=a b c    值必须是空格分隔的候选项之一
+//   - regexp=PATTERN 值必须匹配正则表达式，必须是最后一条规则，
+//     其后的整段标签都属于 PATTERN，因此 PATTERN 可以包含逗号，例如 {2,4}
+//   - dive           之后的规则作用于切片或映射的每个元素
+//
+// 嵌套结构体（包括 dive 之后的结构体元素）会被递归校验，
+// 通过指针回到正在校验的结构体时不再重复进入。
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Lang 错误消息语言
+type Lang string
+
+const (
+	// Chinese 中文消息
+	Chinese Lang = "zh"
+	// English 英文消息
+	English Lang = "en"
+)
+
+// RuleFunc 规则函数，field 为字段值（已解引用），param 为 = 之后的参数
+type RuleFunc func(field reflect.Value, param string) (bool, error)
+
+// FieldError 单个字段的校验失败
+type FieldError struct {
+	Field string      // 字段路径，例如 Address.City 或 Tags[1]
+	Rule  string      // 失败的规则名
+	Param string      // 规则参数
+	Value interface{} // 字段的实际值
+
+	message map[Lang]string
+}
+
+// Error 返回中文错误消息
+func (e *FieldError) Error() string {
+	return e.Message(Chinese)
+}
+
+// Message 返回指定语言的错误消息
+func (e *FieldError) Message(lang Lang) string {
+	if msg, ok := e.message[lang]; ok {
+		return msg
+	}
+	return e.message[English]
+}
+
+// Errors 一次校验中收集到的全部字段错误
+type Errors []*FieldError
+
+// Error 返回所有字段的中文错误消息
+func (es Errors) Error() string {
+	return strings.Join(es.Messages(Chinese), "; ")
+}
+
+// Messages 返回指定语言的错误消息列表
+func (es Errors) Messages(lang Lang) []string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Message(lang)
+	}
+	return msgs
+}
+
+// ByField 按字段路径分组返回错误
+func (es Errors) ByField() map[string][]*FieldError {
+	result := make(map[string][]*FieldError)
+	for _, e := range es {
+		result[e.Field] = append(result[e.Field], e)
+	}
+	return result
+}
+
+// rule 已注册的规则及其消息模板
+type rule struct {
+	fn       RuleFunc
+	messages map[Lang]string
+	// lengthMessages 字段按长度比较时使用的消息模板（可选）
+	lengthMessages map[Lang]string
+}
+
+// Validator 校验器，可安全地并发使用
+type Validator struct {
+	tag string
+
+	mu    sync.RWMutex
+	rules map[string]rule
+}
+
+// New 创建带有内置规则的校验器
+func New() *Validator {
+	v := &Validator{tag: "validate", rules: make(map[string]rule)}
+	for name, r := range builtinRules() {
+		v.rules[name] = r
+	}
+	return v
+}
+
+// RegisterRule 注册自定义规则，同名规则会被覆盖
+//
+// messages 为各语言的消息模板，可使用 {field}、{param} 和 {value} 占位符。
+func (v *Validator) RegisterRule(name string, fn RuleFunc, messages map[Lang]string) error {
+	if name == "" || strings.ContainsAny(name, ",= ") {
+		return fmt.Errorf("validator: 非法的规则名 %q", name)
+	}
+	if name == "dive" || name == "omitempty" {
+		return fmt.Errorf("validator: %s 是保留关键字", name)
+	}
+	if fn == nil {
+		return fmt.Errorf("validator: 规则 %s 的函数不能为空", name)
+	}
+
+	copied := make(map[Lang]string, len(messages))
+	for lang, msg := range messages {
+		copied[lang] = msg
+	}
+	if _, ok := copied[English]; !ok {
+		copied[English] = "{field} failed on the '" + name + "' rule"
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules[name] = rule{fn: fn, messages: copied}
+	return nil
+}
+
+// Struct 校验结构体
+//
+// 校验失败时返回 Errors；标签书写错误（未知规则、参数非法）时返回普通 error。
+func (v *Validator) Struct(s interface{}) error {
+	rv := reflect.ValueOf(s)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("validator: 不能校验空指针 %T", s)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("validator: 需要结构体，得到 %T", s)
+	}
+
+	w := &walk{visiting: make(map[visit]bool)}
+	if p := reflect.ValueOf(s); p.Kind() == reflect.Ptr {
+		w.visiting[visit{p.Pointer(), p.Type()}] = true
+	}
+	if err := v.validateStruct(rv, "", w); err != nil {
+		return err
+	}
+	if len(w.errs) > 0 {
+		return w.errs
+	}
+	return nil
+}
+
+// visit 一个指向结构体的指针，类型也是键的一部分：嵌入的第一个字段与外层结构体地址相同
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// walk 一次校验的状态
+type walk struct {
+	errs     Errors
+	visiting map[visit]bool // 当前递归路径上经过的指针，用于发现自引用
+}
+
+// splitRules 按逗号拆分标签，regexp= 之后的部分原样作为最后一条规则
+func splitRules(tag string) []string {
+	var rules []string
+	for tag != "" {
+		if strings.HasPrefix(strings.TrimSpace(tag), "regexp=") {
+			return append(rules, tag)
+		}
+		r, rest, _ := strings.Cut(tag, ",")
+		rules = append(rules, r)
+		tag = rest
+	}
+	return rules
+}
+
+// Rules 返回结构体各字段声明的规则（字段名 -> 规则列表）
+func (v *Validator) Rules(s interface{}) (map[string][]string, error) {
+	t := reflect.TypeOf(s)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("validator: 需要结构体，得到 %T", s)
+	}
+
+	result := make(map[string][]string)
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get(v.tag)
+		if tag == "" || tag == "-" {
+			continue
+		}
+		result[t.Field(i).Name] = splitRules(tag)
+	}
+	return result, nil
+}
+
+// validateStruct 校验结构体的每个导出字段
+func (v *Validator) validateStruct(rv reflect.Value, prefix string, w *walk) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		tag := sf.Tag.Get(v.tag)
+		if tag == "-" {
+			continue
+		}
+
+		path := sf.Name
+		if prefix != "" {
+			path = prefix + "." + sf.Name
+		}
+
+		if err := v.validateField(rv.Field(i), path, splitRules(tag), w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateField 依次应用规则，遇到 dive 时转入元素校验
+func (v *Validator) validateField(field reflect.Value, path string, rules []string, w *walk) error {
+	for i, r := range rules {
+		name, param, _ := strings.Cut(strings.TrimSpace(r), "=")
+
+		switch name {
+		case "":
+			continue
+		case "omitempty":
+			if field.IsZero() {
+				return nil
+			}
+			continue
+		case "dive":
+			return v.dive(field, path, rules[i+1:], w)
+		}
+
+		v.mu.RLock()
+		rl, ok := v.rules[name]
+		v.mu.RUnlock()
+		if !ok {
+			return fmt.Errorf("validator: 字段 %s 使用了未知规则 %q", path, name)
+		}
+
+		// required 需要看到原始的指针值，其余规则作用于解引用后的值
+		target := field
+		if name != "required" {
+			target = indirect(field)
+			if !target.IsValid() {
+				continue
+			}
+		}
+
+		passed, err := rl.fn(target, param)
+		if err != nil {
+			return fmt.Errorf("validator: 字段 %s 的规则 %s: %w", path, name, err)
+		}
+		if !passed {
+			templates := rl.messages
+			if rl.lengthMessages != nil && hasLength(target) {
+				templates = rl.lengthMessages
+			}
+			w.errs = append(w.errs, newFieldError(path, name, param, field, templates))
+			// 一个字段只报告第一个失败的规则
+			return nil
+		}
+	}
+
+	// 嵌套结构体递归校验，路径上已经经过的指针不再进入
+	inner := field
+	var entered []visit
+	defer func() {
+		for _, k := range entered {
+			delete(w.visiting, k)
+		}
+	}()
+	for inner.IsValid() && (inner.Kind() == reflect.Ptr || inner.Kind() == reflect.Interface) {
+		if inner.IsNil() {
+			return nil
+		}
+		if inner.Kind() == reflect.Ptr {
+			k := visit{inner.Pointer(), inner.Type()}
+			if w.visiting[k] {
+				return nil
+			}
+			w.visiting[k] = true
+			entered = append(entered, k)
+		}
+		inner = inner.Elem()
+	}
+	if inner.IsValid() && inner.Kind() == reflect.Struct {
+		return v.validateStruct(inner, path, w)
+	}
+	return nil
+}
+
+// dive 对切片、数组或映射的每个元素应用剩余规则
+func (v *Validator) dive(field reflect.Value, path string, rules []string, w *walk) error {
+	field = indirect(field)
+	if !field.IsValid() {
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < field.Len(); i++ {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			if err := v.validateField(field.Index(i), elemPath, rules, w); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := field.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			elemPath := fmt.Sprintf("%s[%v]", path, key.Interface())
+			if err := v.validateField(field.MapIndex(key), elemPath, rules, w); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("validator: 字段 %s 类型为 %s，不能使用 dive", path, field.Kind())
+	}
+	return nil
+}
+
+// newFieldError 根据消息模板生成字段错误
+func newFieldError(path, name, param string, field reflect.Value, templates map[Lang]string) *FieldError {
+	var value interface{}
+	if field.IsValid() && field.CanInterface() {
+		value = field.Interface()
+	}
+
+	message := make(map[Lang]string, len(templates))
+	for lang, tmpl := range templates {
+		message[lang] = strings.NewReplacer(
+			"{field}", path,
+			"{param}", param,
+			"{value}", fmt.Sprint(value),
+		).Replace(tmpl)
+	}
+
+	return &FieldError{Field: path, Rule: name, Param: param, Value: value, message: message}
+}
+
+// indirect 解引用指针和接口，nil 时返回无效值
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// hasLength 判断值是否按长度比较
+func hasLength(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// ===== 内置规则 =====
+
+var emailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+
+var (
+	regexpCacheMu sync.Mutex
+	regexpCache   = make(map[string]*regexp.Regexp)
+)
+
+// builtinRules 返回内置规则表
+func builtinRules() map[string]rule {
+	return map[string]rule{
+		"required": {
+			fn: func(field reflect.Value, _ string) (bool, error) {
+				return field.IsValid() && !field.IsZero(), nil
+			},
+			messages: map[Lang]string{
+				Chinese: "{field}为必填字段",
+				English: "{field} is required",
+			},
+		},
+		"min": {
+			fn: compareRule(func(actual, limit float64) bool { return actual >= limit }),
+			messages: map[Lang]string{
+				Chinese: "{field}不能小于{param}",
+				English: "{field} must be {param} or greater",
+			},
+			lengthMessages: map[Lang]string{
+				Chinese: "{field}长度不能小于{param}",
+				English: "{field} must be at least {param} characters or items long",
+			},
+		},
+		"max": {
+			fn: compareRule(func(actual, limit float64) bool { return actual <= limit }),
+			messages: map[Lang]string{
+				Chinese: "{field}不能大于{param}",
+				English: "{field} must be {param} or less",
+			},
+			lengthMessages: map[Lang]string{
+				Chinese: "{field}长度不能大于{param}",
+				English: "{field} must be at most {param} characters or items long",
+			},
+		},
+		"len": {
+			fn: compareRule(func(actual, limit float64) bool { return actual == limit }),
+			messages: map[Lang]string{
+				Chinese: "{field}必须等于{param}",
+				English: "{field} must be equal to {param}",
+			},
+			lengthMessages: map[Lang]string{
+				Chinese: "{field}长度必须为{param}",
+				English: "{field} must be exactly {param} characters or items long",
+			},
+		},
+		"email": {
+			fn: func(field reflect.Value, _ string) (bool, error) {
+				if field.Kind() != reflect.String {
+					return false, fmt.Errorf("email 只能用于字符串")
+				}
+				return emailPattern.MatchString(field.String()), nil
+			},
+			messages: map[Lang]string{
+				Chinese: "{field}必须是有效的邮箱地址",
+				English: "{field} must be a valid email address",
+			},
+		},
+		"oneof": {
+			fn: func(field reflect.Value, param string) (bool, error) {
+				options := strings.Fields(param)
+				if len(options) == 0 {
+					return false, fmt.Errorf("oneof 需要至少一个候选项")
+				}
+				actual := fmt.Sprint(field.Interface())
+				for _, option := range options {
+					if actual == option {
+						return true, nil
+					}
+				}
+				return false, nil
+			},
+			messages: map[Lang]string{
+				Chinese: "{field}必须是[{param}]中的一个",
+				English: "{field} must be one of [{param}]",
+			},
+		},
+		"regexp": {
+			fn: func(field reflect.Value, param string) (bool, error) {
+				if field.Kind() != reflect.String {
+					return false, fmt.Errorf("regexp 只能用于字符串")
+				}
+				re, err := compileCached(param)
+				if err != nil {
+					return false, err
+				}
+				return re.MatchString(field.String()), nil
+			},
+			messages: map[Lang]string{
+				Chinese: "{field}格式不正确",
+				English: "{field} has an invalid format",
+			},
+		},
+	}
+}
+
+// compareRule 构造 min/max/len 规则：长度类型比较长度，数字比较数值
+func compareRule(ok func(actual, limit float64) bool) RuleFunc {
+	return func(field reflect.Value, param string) (bool, error) {
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false, fmt.Errorf("参数 %q 不是数字", param)
+		}
+
+		var actual float64
+		switch field.Kind() {
+		case reflect.String:
+			actual = float64(utf8.RuneCountInString(field.String()))
+		case reflect.Slice, reflect.Array, reflect.Map:
+			actual = float64(field.Len())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			actual = float64(field.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			actual = float64(field.Uint())
+		case reflect.Float32, reflect.Float64:
+			actual = field.Float()
+		default:
+			return false, fmt.Errorf("不支持的类型 %s", field.Kind())
+		}
+		return ok(actual, limit), nil
+	}
+}
+
+// compileCached 编译并缓存正则表达式
+func compileCached(pattern string) (*regexp.Regexp, error) {
+	regexpCacheMu.Lock()
+	defer regexpCacheMu.Unlock()
+
+	if re, ok := regexpCache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexpCache[pattern] = re
+	return re, nil
+}
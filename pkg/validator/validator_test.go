@@ -0,0 +1,303 @@
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type address struct {
+	City string `validate:"required"`
+	Zip  string `validate:"regexp=^[0-9]{6}$"`
+}
+
+type item struct {
+	Name  string `validate:"required"`
+	Count int    `validate:"min=1"`
+}
+
+type account struct {
+	ID       int               `validate:"required"`
+	Username string            `validate:"required,min=3,max=12"`
+	Email    string            `validate:"required,email"`
+	Age      int               `validate:"min=18,max=120"`
+	Role     string            `validate:"oneof=admin user guest"`
+	Nickname string            `validate:"omitempty,min=2"`
+	Tags     []string          `validate:"max=3,dive,min=2"`
+	Items    []item            `validate:"dive"`
+	Labels   map[string]string `validate:"dive,required"`
+	Address  *address
+	Manager  *address `validate:"required"`
+	secret   string
+}
+
+func validAccount() account {
+	return account{
+		ID:       1,
+		Username: "john_doe",
+		Email:    "john@example.com",
+		Age:      30,
+		Role:     "admin",
+		Tags:     []string{"go", "db"},
+		Items:    []item{{Name: "book", Count: 1}},
+		Labels:   map[string]string{"team": "core"},
+		Address:  &address{City: "Beijing", Zip: "100000"},
+		Manager:  &address{City: "Shanghai", Zip: "200000"},
+	}
+}
+
+func TestValidStruct(t *testing.T) {
+	a := validAccount()
+	if err := New().Struct(a); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := New().Struct(&a); err != nil {
+		t.Fatalf("expected no error for pointer, got %v", err)
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(a *account)
+		field  string
+		rule   string
+	}{
+		{"required int", func(a *account) { a.ID = 0 }, "ID", "required"},
+		{"min length", func(a *account) { a.Username = "jo" }, "Username", "min"},
+		{"max length counts runes", func(a *account) { a.Username = "张三李四王五赵六钱七孙八" }, "", ""},
+		{"max length", func(a *account) { a.Username = "abcdefghijklm" }, "Username", "max"},
+		{"email", func(a *account) { a.Email = "not-an-email" }, "Email", "email"},
+		{"min value", func(a *account) { a.Age = 10 }, "Age", "min"},
+		{"oneof", func(a *account) { a.Role = "root" }, "Role", "oneof"},
+		{"omitempty skips", func(a *account) { a.Nickname = "" }, "", ""},
+		{"omitempty applies", func(a *account) { a.Nickname = "x" }, "Nickname", "min"},
+		{"slice length", func(a *account) { a.Tags = []string{"aa", "bb", "cc", "dd"} }, "Tags", "max"},
+		{"dive element", func(a *account) { a.Tags = []string{"go", "x"} }, "Tags[1]", "min"},
+		{"dive struct", func(a *account) { a.Items[0].Count = 0 }, "Items[0].Count", "min"},
+		{"dive map", func(a *account) { a.Labels["team"] = "" }, "Labels[team]", "required"},
+		{"nested struct", func(a *account) { a.Address.City = "" }, "Address.City", "required"},
+		{"nested regexp", func(a *account) { a.Address.Zip = "12ab" }, "Address.Zip", "regexp"},
+		{"nil optional pointer", func(a *account) { a.Address = nil }, "", ""},
+		{"nil required pointer", func(a *account) { a.Manager = nil }, "Manager", "required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := validAccount()
+			a.Address = &address{City: "Beijing", Zip: "100000"}
+			a.Items = []item{{Name: "book", Count: 1}}
+			a.Labels = map[string]string{"team": "core"}
+			tt.mutate(&a)
+
+			err := New().Struct(a)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected Errors, got %v", err)
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected 1 error, got %d: %v", len(errs), errs)
+			}
+			if errs[0].Field != tt.field || errs[0].Rule != tt.rule {
+				t.Errorf("expected %s/%s, got %s/%s", tt.field, tt.rule, errs[0].Field, errs[0].Rule)
+			}
+		})
+	}
+}
+
+func TestMultipleErrorsAndMessages(t *testing.T) {
+	a := validAccount()
+	a.Username = "jo"
+	a.Age = 10
+	a.Email = ""
+
+	err := New().Struct(a)
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("expected Errors, got %T", err)
+	}
+
+	zh := errs.Messages(Chinese)
+	en := errs.Messages(English)
+	wantZh := []string{"Username长度不能小于3", "Email为必填字段", "Age不能小于18"}
+	wantEn := []string{
+		"Username must be at least 3 characters or items long",
+		"Email is required",
+		"Age must be 18 or greater",
+	}
+	if !reflect.DeepEqual(zh, wantZh) {
+		t.Errorf("expected %v, got %v", wantZh, zh)
+	}
+	if !reflect.DeepEqual(en, wantEn) {
+		t.Errorf("expected %v, got %v", wantEn, en)
+	}
+	if !strings.Contains(err.Error(), "Email为必填字段") {
+		t.Errorf("unexpected Error(): %s", err.Error())
+	}
+	if len(errs.ByField()["Age"]) != 1 {
+		t.Errorf("expected one Age error, got %v", errs.ByField())
+	}
+}
+
+func TestCustomRule(t *testing.T) {
+	type form struct {
+		Code string `validate:"required,upper"`
+	}
+
+	v := New()
+	err := v.RegisterRule("upper", func(field reflect.Value, _ string) (bool, error) {
+		s := field.String()
+		return s == strings.ToUpper(s), nil
+	}, map[Lang]string{
+		Chinese: "{field}必须为大写，当前值{value}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Struct(form{Code: "ABC"}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	err = v.Struct(form{Code: "abc"})
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("expected one field error, got %v", err)
+	}
+	if got := errs[0].Message(Chinese); got != "Code必须为大写，当前值abc" {
+		t.Errorf("unexpected zh message %q", got)
+	}
+	if got := errs[0].Message(English); got != "Code failed on the 'upper' rule" {
+		t.Errorf("unexpected en message %q", got)
+	}
+
+	for _, name := range []string{"", "a,b", "dive", "omitempty"} {
+		if err := v.RegisterRule(name, func(reflect.Value, string) (bool, error) { return true, nil }, nil); err == nil {
+			t.Errorf("expected error registering %q", name)
+		}
+	}
+}
+
+func TestTagErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+	}{
+		{"unknown rule", struct {
+			A string `validate:"bogus"`
+		}{}},
+		{"bad min param", struct {
+			A string `validate:"min=abc"`
+		}{"x"}},
+		{"bad regexp", struct {
+			A string `validate:"regexp=[a-"`
+		}{"x"}},
+		{"dive on string", struct {
+			A string `validate:"dive,required"`
+		}{"x"}},
+		{"not a struct", 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().Struct(tt.input)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if _, ok := err.(Errors); ok {
+				t.Errorf("expected a tag error, got field errors %v", err)
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	rules, err := New().Rules(&account{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rules["Username"], []string{"required", "min=3", "max=12"}) {
+		t.Errorf("unexpected rules %v", rules["Username"])
+	}
+	if _, ok := rules["Address"]; ok {
+		t.Error("untagged field should not have rules")
+	}
+}
+
+func TestRegexpWithComma(t *testing.T) {
+	type code struct {
+		Code string   `validate:"required,regexp=^[A-Z]{2,4}-[0-9]{1,3}$"`
+		Tags []string `validate:"dive,regexp=^[a-z]{2,}$"`
+	}
+	tests := []struct {
+		name  string
+		input code
+		field string
+	}{
+		{"valid", code{Code: "AB-12", Tags: []string{"go"}}, ""},
+		{"too long", code{Code: "ABCDE-1", Tags: []string{"go"}}, "Code"},
+		{"element", code{Code: "ABC-1", Tags: []string{"go", "x"}}, "Tags[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().Struct(tt.input)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("expected valid, got %v", err)
+				}
+				return
+			}
+			errs, ok := err.(Errors)
+			if !ok || len(errs) != 1 || errs[0].Field != tt.field || errs[0].Rule != "regexp" {
+				t.Errorf("expected regexp error on %s, got %v", tt.field, err)
+			}
+		})
+	}
+
+	rules, err := New().Rules(code{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"required", "regexp=^[A-Z]{2,4}-[0-9]{1,3}$"}; !reflect.DeepEqual(rules["Code"], want) {
+		t.Errorf("expected %q, got %q", want, rules["Code"])
+	}
+}
+
+type node struct {
+	Name     string `validate:"required"`
+	Next     *node
+	Children []*node `validate:"dive"`
+}
+
+func TestSelfReference(t *testing.T) {
+	// 回到路径上已有的结构体时停止：a.Next.Next 和 a.Children[0] 都是 a
+	a := &node{Name: "a"}
+	b := &node{Next: a}
+	a.Next = b
+	a.Children = []*node{a, b}
+
+	errs, ok := New().Struct(a).(Errors)
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Field)
+	}
+	if want := []string{"Next.Name", "Children[1].Name"}; !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("expected errors at %v, got %v", want, got)
+	}
+
+	// 同一个指针出现在不同分支时各自校验
+	shared := &node{}
+	tree := node{Name: "root", Children: []*node{shared, shared}}
+	errs, ok = New().Struct(tree).(Errors)
+	if !ok || len(errs) != 2 {
+		t.Errorf("expected errors on both children, got %v", errs)
+	}
+}